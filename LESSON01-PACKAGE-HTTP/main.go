@@ -1,24 +1,70 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
 
 	http.HandleFunc("/demo", demoHandler)
 
-	log.Println("Server is starting ...")
-	err := http.ListenAndServe(":8080", nil) // localhost:8080
-	if err != nil {
-		log.Fatal("Server start error: ", err)
+	// http.ListenAndServe không có timeout, client chậm có thể giữ kết nối mãi
+	server := &http.Server{
+		Addr:              ":8080", // localhost:8080
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Có TLS_CERT_FILE và TLS_KEY_FILE thì chạy HTTPS, HTTP/2 được bật tự động
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+
+	go func() {
+		log.Println("Server is starting ...")
+		var err error
+		if certFile != "" && keyFile != "" {
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server start error: ", err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// Chờ request đang xử lý xong, tối đa 10 giây
+	log.Println("Server is shutting down ...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatal("Server shutdown error: ", err)
 	}
 }
 
 func demoHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("%+v", r)
+	// Không log cả *http.Request vì sẽ lộ header Authorization, Cookie
+	slog.Info("request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(),
+	)
 
 	if r.Method != http.MethodGet {
 		http.Error(w, "Phuong thuc nay khong duoc ho tro", http.StatusMethodNotAllowed)
@@ -0,0 +1,35 @@
+// Command sitemap tải sitemap từ API đang chạy rồi ghi bản tĩnh (kèm .gz) vào storage.
+//
+//	go run ./cmd/sitemap -api http://localhost:8080 -base-url https://mamba.com -out ./public
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mamba.com/route-group/internal/sitemap"
+	"mamba.com/route-group/internal/storage"
+)
+
+func main() {
+	api := flag.String("api", "http://localhost:8080", "URL of the running API that serves /sitemap.xml")
+	baseURL := flag.String("base-url", "http://localhost:8080", "public URL of the site")
+	out := flag.String("out", "./public", "storage directory for the generated files")
+	timeout := flag.Duration("timeout", time.Minute, "timeout for the whole download")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	store := storage.NewLocalStorage(*out)
+	err := sitemap.Mirror(ctx, http.DefaultClient, strings.TrimSuffix(*api, "/"), strings.TrimSuffix(*baseURL, "/"), store)
+	if err != nil {
+		log.Fatal("Cannot write sitemap: ", err)
+	}
+
+	log.Printf("Sitemap written to %s", *out)
+}
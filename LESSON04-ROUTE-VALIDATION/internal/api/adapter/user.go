@@ -0,0 +1,130 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/utils"
+)
+
+// UserV1 là representation cũ: id số nguyên, chỉ có thông tin cơ bản
+type UserV1 struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// UserV2 dùng UUID làm định danh và có thêm profile, avatar, timestamps
+type UserV2 struct {
+	UUID      string        `json:"uuid"`
+	Name      string        `json:"name"`
+	Email     string        `json:"email"`
+	Profile   UserProfileV2 `json:"profile"`
+	Avatar    string        `json:"avatar"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type UserProfileV2 struct {
+	Bio     string `json:"bio"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+// UserV2Fields là các giá trị hợp lệ cho ?fields=
+var UserV2Fields = []string{"uuid", "name", "email", "profile", "avatar", "created_at", "updated_at"}
+
+func ToUserV1(user models.User) UserV1 {
+	return UserV1{
+		ID:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+	}
+}
+
+func ToUserV2(user models.User) UserV2 {
+	return UserV2{
+		UUID:  user.UUID,
+		Name:  user.Name,
+		Email: user.Email,
+		Profile: UserProfileV2{
+			Bio:     user.Profile.Bio,
+			Phone:   user.Profile.Phone,
+			Address: user.Profile.Address,
+		},
+		Avatar:    user.Avatar,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// UserETag dùng chung cho v1 và v2: sửa user qua version nào cũng làm ETag ở version kia đổi theo
+func UserETag(user models.User) string {
+	return utils.VersionETag("user", user.ID, user.Version)
+}
+
+func ToUsersV1(users []models.User) []UserV1 {
+	result := make([]UserV1, 0, len(users))
+	for _, user := range users {
+		result = append(result, ToUserV1(user))
+	}
+	return result
+}
+
+func (p UserProfileV2) ToModel() models.UserProfile {
+	return models.UserProfile{
+		Bio:     p.Bio,
+		Phone:   p.Phone,
+		Address: p.Address,
+	}
+}
+
+// ParseFields tách "name,email" thành danh sách field, rỗng nghĩa là lấy tất cả
+func ParseFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	allowed := make(map[string]bool, len(UserV2Fields))
+	for _, field := range UserV2Fields {
+		allowed[field] = true
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !allowed[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// SelectFields chỉ giữ lại các field được yêu cầu, fields rỗng thì trả về đầy đủ
+func SelectFields(user UserV2, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return user, nil
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		selected[field] = all[field]
+	}
+
+	return selected, nil
+}
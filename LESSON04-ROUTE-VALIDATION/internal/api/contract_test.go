@@ -0,0 +1,203 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1handler "mamba.com/route-group/internal/api/v1/handler"
+	v2handler "mamba.com/route-group/internal/api/v2/handler"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/openapi"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/service"
+	"mamba.com/route-group/utils"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := utils.RegisterValidators(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+const productBody = `{"name":"Widget","price":200000,"product_image":{"image_name":"a","image_link":"a.png"},` +
+	`"tags":["a","b","c","d"],"product_attribute":[{"attribute_name":"c","attribute_value":"r"}],` +
+	`"product_info":{"4b3c2a3e-8a3c-4c9e-9f2e-1b2c3d4e5f60":{"info_key":"k","info_value":"v"}}}`
+
+func register(r gin.IRouter) {
+	userService := service.NewUserService(repository.NewInMemoryUserRepository(nil))
+	productRepo := repository.NewInMemoryProductRepository(nil)
+	newsRepo := repository.NewInMemoryNewsRepository(nil)
+
+	users := v1handler.NewUserHandler(userService)
+	r.GET("/api/v1/users/:id", users.GetUsersByIdV1)
+	r.POST("/api/v1/users", users.PostUsersV1)
+	r.POST("/api/v1/users/bulk", users.PostUsersBulkV1)
+	r.PUT("/api/v1/users/bulk", users.PutUsersBulkV1)
+	r.DELETE("/api/v1/users/bulk", users.DeleteUsersBulkV1)
+	r.PUT("/api/v1/users/:id", users.PutUsersByIdV1)
+	r.DELETE("/api/v1/users/:id", users.DeleteUsersByIdV1)
+
+	products := v1handler.NewProductHandler(productRepo, imports.NewManager(0), v1handler.ImportLimits{})
+	r.GET("/api/v1/products/:slug", products.GetProductsBySlugV1)
+	r.POST("/api/v1/products", products.PostProductsV1)
+	r.POST("/api/v1/products/bulk", products.PostProductsBulkV1)
+	r.PUT("/api/v1/products/bulk", products.PutProductsBulkV1)
+	r.DELETE("/api/v1/products/bulk", products.DeleteProductsBulkV1)
+	r.PUT("/api/v1/products/:id", products.PutProductsByIdV1)
+
+	categories := v1handler.NewCategoryHandler(repository.NewInMemoryCategoryRepository())
+	r.POST("/api/v1/categories/bulk", categories.PostCategoriesBulkV1)
+	r.PUT("/api/v1/categories/bulk", categories.PutCategoriesBulkV1)
+	r.DELETE("/api/v1/categories/bulk", categories.DeleteCategoriesBulkV1)
+
+	news := v1handler.NewNewsHandler(newsRepo, "", nil)
+	r.GET("/api/v1/news/feed.rss", news.GetNewsFeedRssV1)
+
+	usersV2 := v2handler.NewUserHandler(userService)
+	r.GET("/api/v2/users/:uuid", usersV2.GetUsersByUuidV2)
+	r.POST("/api/v2/users", usersV2.PostUsersV2)
+	r.PUT("/api/v2/users/:uuid", usersV2.PutUsersByUuidV2)
+}
+
+// strictEngine sinh spec từ route rồi dựng engine mới có validator strict đứng trước route,
+// gin chỉ gắn middleware cho route đăng ký sau Use. Spec đi qua JSON như khi đọc từ -openapi-spec.
+func strictEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	describe := gin.New()
+	register(describe)
+	spec := openapi.NewGenerator(openapi.Info{Title: "test", Version: "1"})
+	v1handler.DescribeOpenAPI(spec)
+	v2handler.DescribeOpenAPI(spec)
+
+	data, err := json.Marshal(spec.Generate(describe.Routes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := openapi.NewValidator(&doc, openapi.ValidatorOptions{ValidateResponses: true, StrictResponses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(validator.Middleware())
+	register(r)
+	return r
+}
+
+type step struct {
+	name    string
+	method  string
+	path    string
+	body    string
+	header  map[string]string
+	status  int
+	capture string // lưu header ETag của response vào biến này
+}
+
+func TestStrictContract(t *testing.T) {
+	r := strictEngine(t)
+	etags := map[string]string{}
+
+	steps := []step{
+		{name: "create user", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"Alice","email":"alice@example.com"}`, status: http.StatusCreated},
+		{name: "duplicate email", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"Alice","email":"alice@example.com"}`, status: http.StatusConflict},
+		{name: "get user", method: http.MethodGet, path: "/api/v1/users/1", status: http.StatusOK, capture: "user"},
+		{name: "user not modified", method: http.MethodGet, path: "/api/v1/users/1", header: map[string]string{"If-None-Match": "{user}"}, status: http.StatusNotModified},
+		{name: "get user as XML", method: http.MethodGet, path: "/api/v1/users/1", header: map[string]string{"Accept": "application/xml"}, status: http.StatusOK, capture: "userXML"},
+		{name: "JSON ETag does not validate XML", method: http.MethodGet, path: "/api/v1/users/1", header: map[string]string{"Accept": "application/xml", "If-None-Match": "{user}"}, status: http.StatusOK},
+		{name: "user XML not modified", method: http.MethodGet, path: "/api/v1/users/1", header: map[string]string{"Accept": "application/xml", "If-None-Match": "{userXML}"}, status: http.StatusNotModified},
+		{name: "user not found", method: http.MethodGet, path: "/api/v1/users/99", status: http.StatusNotFound},
+		{name: "update without If-Match", method: http.MethodPut, path: "/api/v1/users/1", body: `{"name":"Alice B","email":"alice@example.com"}`, status: http.StatusPreconditionRequired},
+		{name: "update stale If-Match", method: http.MethodPut, path: "/api/v1/users/1", body: `{"name":"Alice B","email":"alice@example.com"}`, header: map[string]string{"If-Match": `"stale"`}, status: http.StatusPreconditionFailed},
+		// ETag của representation nào cũng dùng được cho If-Match
+		{name: "update user", method: http.MethodPut, path: "/api/v1/users/1", body: `{"name":"Alice B","email":"alice@example.com"}`, header: map[string]string{"If-Match": "{userXML}"}, status: http.StatusOK},
+		{name: "users bulk partial", method: http.MethodPost, path: "/api/v1/users/bulk", body: `{"mode":"partial","items":[{"name":"Bob","email":"bob@example.com"},{"name":"Alice","email":"alice@example.com"}]}`, status: http.StatusMultiStatus},
+		{name: "users bulk atomic rejected", method: http.MethodPost, path: "/api/v1/users/bulk", body: `{"mode":"atomic","items":[{"name":"Carol","email":"carol@example.com"},{"name":"Bob","email":"bob@example.com"}]}`, status: http.StatusConflict},
+		{name: "users bulk update atomic stale", method: http.MethodPut, path: "/api/v1/users/bulk", body: `{"items":[{"id":1,"if_match":"*","name":"Alice C"},{"id":2,"if_match":"\"stale\"","name":"Bob B"}]}`, status: http.StatusPreconditionFailed},
+		{name: "users bulk update partial", method: http.MethodPut, path: "/api/v1/users/bulk", body: `{"mode":"partial","items":[{"id":1,"if_match":"*","name":"Alice C"},{"id":99,"if_match":"*","name":"Nobody"}]}`, status: http.StatusMultiStatus},
+		{name: "users bulk update without if_match", method: http.MethodPut, path: "/api/v1/users/bulk", body: `{"items":[{"id":1,"name":"Alice D"}]}`, status: http.StatusPreconditionRequired},
+		{name: "users bulk delete atomic missing", method: http.MethodDelete, path: "/api/v1/users/bulk", body: `{"items":[{"id":2,"if_match":"*"},{"id":99,"if_match":"*"}]}`, status: http.StatusNotFound},
+		{name: "users bulk delete", method: http.MethodDelete, path: "/api/v1/users/bulk", body: `{"items":[{"id":2,"if_match":"*"}]}`, status: http.StatusOK},
+		{name: "invalid user", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"A","email":"not-an-email"}`, status: http.StatusBadRequest},
+
+		{name: "create product", method: http.MethodPost, path: "/api/v1/products", body: productBody, status: http.StatusCreated},
+		{name: "get product", method: http.MethodGet, path: "/api/v1/products/widget", status: http.StatusOK, capture: "product"},
+		{name: "product not modified", method: http.MethodGet, path: "/api/v1/products/widget", header: map[string]string{"If-None-Match": "{product}"}, status: http.StatusNotModified},
+		{name: "update product without If-Match", method: http.MethodPut, path: "/api/v1/products/1", body: productBody, status: http.StatusPreconditionRequired},
+		{name: "products bulk partial", method: http.MethodPost, path: "/api/v1/products/bulk", body: `{"mode":"partial","items":[` + productBody + `]}`, status: http.StatusMultiStatus},
+		{name: "products bulk update", method: http.MethodPut, path: "/api/v1/products/bulk", body: `{"items":[{"id":1,"if_match":"*",` + productBody[1:] + `]}`, status: http.StatusOK},
+		{name: "products bulk update invalid", method: http.MethodPut, path: "/api/v1/products/bulk", body: `{"items":[{"id":1,"if_match":"*","name":"Widget"}]}`, status: http.StatusBadRequest},
+		{name: "products bulk delete partial", method: http.MethodDelete, path: "/api/v1/products/bulk", body: `{"mode":"partial","items":[{"id":2,"if_match":"*"},{"id":99,"if_match":"*"}]}`, status: http.StatusMultiStatus},
+		{name: "categories bulk partial", method: http.MethodPost, path: "/api/v1/categories/bulk", body: `{"mode":"partial","items":[{"name":"golang","status":"1"},{"name":"rust","status":"1"}]}`, status: http.StatusMultiStatus},
+		{name: "categories bulk update", method: http.MethodPut, path: "/api/v1/categories/bulk", body: `{"items":[{"id":5,"name":"Rust","status":"2"}]}`, status: http.StatusOK},
+		{name: "categories bulk delete atomic missing", method: http.MethodDelete, path: "/api/v1/categories/bulk", body: `{"items":[{"id":5},{"id":99}]}`, status: http.StatusNotFound},
+		{name: "categories bulk delete", method: http.MethodDelete, path: "/api/v1/categories/bulk", body: `{"mode":"partial","items":[{"id":5},{"id":5}]}`, status: http.StatusMultiStatus},
+
+		{name: "feed", method: http.MethodGet, path: "/api/v1/news/feed.rss", status: http.StatusOK, capture: "feed"},
+		{name: "feed not modified", method: http.MethodGet, path: "/api/v1/news/feed.rss", header: map[string]string{"If-None-Match": "{feed}"}, status: http.StatusNotModified},
+
+		{name: "v2 create user", method: http.MethodPost, path: "/api/v2/users", body: `{"name":"Dave","email":"dave@example.com"}`, status: http.StatusCreated},
+		{name: "v2 duplicate email", method: http.MethodPost, path: "/api/v2/users", body: `{"name":"Dave","email":"dave@example.com"}`, status: http.StatusConflict},
+		{name: "v2 user not found", method: http.MethodGet, path: "/api/v2/users/00000000-0000-0000-0000-000000000000", status: http.StatusNotFound},
+	}
+
+	for _, s := range steps {
+		req := httptest.NewRequest(s.method, s.path, strings.NewReader(s.body))
+		if s.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for key, value := range s.header {
+			if strings.HasPrefix(value, "{") {
+				value = etags[strings.Trim(value, "{}")]
+			}
+			req.Header.Set(key, value)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != s.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", s.name, w.Code, s.status, w.Body.String())
+		}
+		if s.capture != "" {
+			etags[s.capture] = w.Header().Get("ETag")
+		}
+	}
+}
+
+// Status không có trong spec vẫn phải thành 500, nếu không strict mode không bắt được gì
+func TestStrictRejectsUndeclaredStatus(t *testing.T) {
+	teapot := func(r gin.IRouter) {
+		r.GET("/teapot", func(ctx *gin.Context) { ctx.JSON(http.StatusTeapot, gin.H{"error": "teapot"}) })
+	}
+
+	describe := gin.New()
+	teapot(describe)
+	doc := openapi.NewGenerator(openapi.Info{}).Generate(describe.Routes())
+	validator, err := openapi.NewValidator(doc, openapi.ValidatorOptions{ValidateResponses: true, StrictResponses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(validator.Middleware())
+	teapot(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,26 @@
+package v1handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/utils"
+)
+
+// BulkItemV1Param chọn 1 resource trong API bulk update/delete.
+// IfMatch thay cho header If-Match của API đơn lẻ (ETag lấy từ GET), bắt buộc khi header bắt buộc.
+type BulkItemV1Param struct {
+	ID      int    `json:"id" xml:"id" binding:"required,gt=0"`
+	IfMatch string `json:"if_match" xml:"if_match"`
+}
+
+// checkBulkItem trả về status và phần "error" của phần tử khi if_match không khớp etag, status 0 là hợp lệ
+func checkBulkItem(item BulkItemV1Param, etag string) (int, any) {
+	switch status := utils.Precondition(item.IfMatch, etag); status {
+	case http.StatusPreconditionRequired:
+		return status, gin.H{"if_match": utils.ValidationMessage("if_match", "required", "")}
+	case http.StatusPreconditionFailed:
+		return status, utils.ModifiedMessage
+	}
+	return 0, nil
+}
@@ -0,0 +1,420 @@
+package v1handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/api/adapter"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/service"
+	"mamba.com/route-group/utils"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := utils.RegisterValidators(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+type bulkResponse struct {
+	Error     string `json:"error"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Results   []struct {
+		Index  int             `json:"index"`
+		Status int             `json:"status"`
+		Data   json.RawMessage `json:"data"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"results"`
+}
+
+func (b bulkResponse) statuses() []int {
+	statuses := make([]int, len(b.Results))
+	for i, result := range b.Results {
+		statuses[i] = result.Status
+	}
+	return statuses
+}
+
+// bulkUsers tạo user 1..3 (a@, b@, c@example.com) và engine có route bulk update/delete
+func bulkUsers(t *testing.T) (*gin.Engine, *service.UserService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	users := service.NewUserService(repository.NewInMemoryUserRepository(nil))
+	for _, name := range []string{"a", "b", "c"} {
+		email := name + "@example.com"
+		if _, err := users.Create(context.Background(), service.UserInput{Name: &name, Email: &email}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewUserHandler(users)
+	r := gin.New()
+	r.PUT("/api/v1/users/bulk", handler.PutUsersBulkV1)
+	r.DELETE("/api/v1/users/bulk", handler.DeleteUsersBulkV1)
+	return r, users
+}
+
+func serveBulk(t *testing.T, r *gin.Engine, method, path, body string) (int, bulkResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp bulkResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body: %s: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func userNames(t *testing.T, users *service.UserService) string {
+	t.Helper()
+
+	var names []string
+	for _, user := range users.List(context.Background()) {
+		names = append(names, user.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPutUsersBulk(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		statuses []int
+		names    string
+	}{
+		{
+			name:     "atomic",
+			body:     `{"items":[{"id":1,"if_match":"*","name":"aaa"},{"id":2,"if_match":"*","name":"bbb"}]}`,
+			status:   http.StatusOK,
+			statuses: []int{http.StatusOK, http.StatusOK},
+			names:    "aaa,bbb,c",
+		},
+		{
+			name:     "atomic not found",
+			body:     `{"items":[{"id":1,"if_match":"*","name":"aaa"},{"id":9,"if_match":"*","name":"zzz"}]}`,
+			status:   http.StatusNotFound,
+			statuses: []int{http.StatusFailedDependency, http.StatusNotFound},
+			names:    "a,b,c",
+		},
+		{
+			name:     "atomic invalid item",
+			body:     `{"items":[{"id":1,"if_match":"*","name":"aaa"},{"id":2,"if_match":"*","email":"not-an-email"}]}`,
+			status:   http.StatusBadRequest,
+			statuses: []int{http.StatusFailedDependency, http.StatusBadRequest},
+			names:    "a,b,c",
+		},
+		{
+			name:     "partial",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"\"stale\"","name":"aaa"},{"id":2,"name":"bbb"},{"id":3,"if_match":"*","name":"ccc"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusPreconditionFailed, http.StatusPreconditionRequired, http.StatusOK},
+			names:    "a,b,ccc",
+		},
+		{
+			// Email của user khác trong cùng batch cũng bị từ chối, kể cả khi user đó đổi sang email khác
+			name:     "partial email conflict",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*","email":"b@example.com"},{"id":2,"if_match":"*","email":"x@example.com"},{"id":3,"if_match":"*","email":"x@example.com"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusConflict, http.StatusOK, http.StatusConflict},
+			names:    "a,b,c",
+		},
+		{
+			name:     "partial same user twice",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*","name":"aaa"},{"id":1,"if_match":"*","name":"bbb"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusOK, http.StatusPreconditionFailed},
+			names:    "aaa,b,c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, users := bulkUsers(t)
+
+			status, resp := serveBulk(t, r, http.MethodPut, "/api/v1/users/bulk", tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d, response: %+v", status, tt.status, resp)
+			}
+			if got := resp.statuses(); !slices.Equal(got, tt.statuses) {
+				t.Errorf("item statuses = %v, want %v", got, tt.statuses)
+			}
+			if got := userNames(t, users); got != tt.names {
+				t.Errorf("names after request = %q, want %q", got, tt.names)
+			}
+		})
+	}
+}
+
+func TestPutUsersBulkIfMatch(t *testing.T) {
+	r, users := bulkUsers(t)
+	user, err := users.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, _ := json.Marshal(adapter.UserETag(*user))
+
+	body := `{"items":[{"id":1,"if_match":` + string(etag) + `,"name":"aaa"}]}`
+	if status, resp := serveBulk(t, r, http.MethodPut, "/api/v1/users/bulk", body); status != http.StatusOK {
+		t.Fatalf("status = %d, response: %+v", status, resp)
+	}
+
+	// ETag cũ không còn khớp sau khi user đã được sửa
+	status, resp := serveBulk(t, r, http.MethodPut, "/api/v1/users/bulk", body)
+	if status != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412, response: %+v", status, resp)
+	}
+	if resp.Error == "" {
+		t.Errorf("missing top-level error")
+	}
+}
+
+func TestDeleteUsersBulk(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		statuses []int
+		names    string
+	}{
+		{
+			name:     "atomic",
+			body:     `{"items":[{"id":1,"if_match":"*"},{"id":3,"if_match":"*"}]}`,
+			status:   http.StatusOK,
+			statuses: []int{http.StatusNoContent, http.StatusNoContent},
+			names:    "b",
+		},
+		{
+			name:     "atomic same user twice",
+			body:     `{"items":[{"id":1,"if_match":"*"},{"id":1,"if_match":"*"}]}`,
+			status:   http.StatusNotFound,
+			statuses: []int{http.StatusFailedDependency, http.StatusNotFound},
+			names:    "a,b,c",
+		},
+		{
+			name:     "partial",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*"},{"id":9,"if_match":"*"},{"id":2}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusNoContent, http.StatusNotFound, http.StatusPreconditionRequired},
+			names:    "b,c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, users := bulkUsers(t)
+
+			status, resp := serveBulk(t, r, http.MethodDelete, "/api/v1/users/bulk", tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d, response: %+v", status, tt.status, resp)
+			}
+			if got := resp.statuses(); !slices.Equal(got, tt.statuses) {
+				t.Errorf("item statuses = %v, want %v", got, tt.statuses)
+			}
+			if got := userNames(t, users); got != tt.names {
+				t.Errorf("names after request = %q, want %q", got, tt.names)
+			}
+		})
+	}
+}
+
+func TestCategoriesBulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryCategoryRepository()
+	handler := NewCategoryHandler(repo)
+	r := gin.New()
+	r.PUT("/api/v1/categories/bulk", handler.PutCategoriesBulkV1)
+	r.DELETE("/api/v1/categories/bulk", handler.DeleteCategoriesBulkV1)
+
+	status, resp := serveBulk(t, r, http.MethodPut, "/api/v1/categories/bulk",
+		`{"items":[{"id":1,"name":"PHP 8","status":"2"},{"id":9,"name":"Rust","status":"1"}]}`)
+	if status != http.StatusNotFound || !slices.Equal(resp.statuses(), []int{http.StatusFailedDependency, http.StatusNotFound}) {
+		t.Fatalf("atomic update: status = %d, response: %+v", status, resp)
+	}
+
+	status, resp = serveBulk(t, r, http.MethodPut, "/api/v1/categories/bulk",
+		`{"mode":"partial","items":[{"id":1,"name":"PHP 8","status":"2"},{"id":9,"name":"Rust","status":"1"}]}`)
+	if status != http.StatusMultiStatus || resp.Succeeded != 1 || resp.Failed != 1 {
+		t.Fatalf("partial update: status = %d, response: %+v", status, resp)
+	}
+	var updated models.Category
+	if err := json.Unmarshal(resp.Results[0].Data, &updated); err != nil {
+		t.Fatal(err)
+	}
+	// Slug giữ nguyên để /categories/php vẫn dùng được
+	if updated.Slug != "php" || updated.Name != "PHP 8" || updated.Status != "2" {
+		t.Errorf("updated category = %+v", updated)
+	}
+
+	status, resp = serveBulk(t, r, http.MethodDelete, "/api/v1/categories/bulk", `{"items":[{"id":1},{"id":2}]}`)
+	if status != http.StatusOK || !slices.Equal(resp.statuses(), []int{http.StatusNoContent, http.StatusNoContent}) {
+		t.Fatalf("delete: status = %d, response: %+v", status, resp)
+	}
+	if left := repo.List(context.Background()); len(left) != 1 || left[0].Slug != "golang" {
+		t.Errorf("categories after delete = %+v", left)
+	}
+}
+
+// racingUserRepo sửa user raceID ngay trước lần ghi đầu tiên,
+// như request khác chen vào giữa lúc handler đọc user và lúc ghi
+type racingUserRepo struct {
+	*repository.InMemoryUserRepository
+	raceID int
+	once   sync.Once
+}
+
+func (r *racingUserRepo) race(ctx context.Context) {
+	r.once.Do(func() {
+		user, _ := r.FindByID(ctx, r.raceID)
+		user.Name = "raced"
+		r.InMemoryUserRepository.Update(ctx, user)
+	})
+}
+
+func (r *racingUserRepo) Update(ctx context.Context, user *models.User) error {
+	r.race(ctx)
+	return r.InMemoryUserRepository.Update(ctx, user)
+}
+
+func (r *racingUserRepo) UpdateMany(ctx context.Context, users []*models.User) error {
+	r.race(ctx)
+	return r.InMemoryUserRepository.UpdateMany(ctx, users)
+}
+
+func (r *racingUserRepo) Delete(ctx context.Context, id, version int) error {
+	r.race(ctx)
+	return r.InMemoryUserRepository.Delete(ctx, id, version)
+}
+
+func (r *racingUserRepo) DeleteMany(ctx context.Context, users []*models.User) error {
+	r.race(ctx)
+	return r.InMemoryUserRepository.DeleteMany(ctx, users)
+}
+
+type racingProductRepo struct {
+	*repository.InMemoryProductRepository
+	raceID int
+	once   sync.Once
+}
+
+func (r *racingProductRepo) race(ctx context.Context) {
+	r.once.Do(func() {
+		product, _ := r.FindByID(ctx, r.raceID)
+		r.InMemoryProductRepository.Update(ctx, product)
+	})
+}
+
+func (r *racingProductRepo) Update(ctx context.Context, product *models.Product) error {
+	r.race(ctx)
+	return r.InMemoryProductRepository.Update(ctx, product)
+}
+
+func (r *racingProductRepo) UpdateMany(ctx context.Context, products []*models.Product) error {
+	r.race(ctx)
+	return r.InMemoryProductRepository.UpdateMany(ctx, products)
+}
+
+func (r *racingProductRepo) Delete(ctx context.Context, id, version int) error {
+	r.race(ctx)
+	return r.InMemoryProductRepository.Delete(ctx, id, version)
+}
+
+func (r *racingProductRepo) DeleteMany(ctx context.Context, products []*models.Product) error {
+	r.race(ctx)
+	return r.InMemoryProductRepository.DeleteMany(ctx, products)
+}
+
+// Phần tử 2 bị request khác sửa sau khi đã qua kiểm tra if_match: partial chỉ phần tử đó lỗi,
+// atomic thì không phần tử nào được ghi
+func TestBulkConflictAtWrite(t *testing.T) {
+	const product = `"name":"Widget","price":200000,"product_image":{"image_name":"a","image_link":"a.png"},` +
+		`"tags":["a","b","c","d"],"product_attribute":[{"attribute_name":"c","attribute_value":"r"}],` +
+		`"product_info":{"4b3c2a3e-8a3c-4c9e-9f2e-1b2c3d4e5f60":{"info_key":"k","info_value":"v"}}`
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		statuses []int
+	}{
+		{
+			name: "users update partial", method: http.MethodPut, path: "/api/v1/users/bulk",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*","name":"aaa"},{"id":2,"if_match":"*","name":"bbb"},{"id":3,"if_match":"*","name":"ccc"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusOK, http.StatusPreconditionFailed, http.StatusOK},
+		},
+		{
+			name: "users update atomic", method: http.MethodPut, path: "/api/v1/users/bulk",
+			body:     `{"items":[{"id":1,"if_match":"*","name":"aaa"},{"id":2,"if_match":"*","name":"bbb"},{"id":3,"if_match":"*","name":"ccc"}]}`,
+			status:   http.StatusPreconditionFailed,
+			statuses: []int{http.StatusPreconditionFailed, http.StatusPreconditionFailed, http.StatusPreconditionFailed},
+		},
+		{
+			name: "users delete partial", method: http.MethodDelete, path: "/api/v1/users/bulk",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*"},{"id":2,"if_match":"*"},{"id":3,"if_match":"*"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusNoContent, http.StatusPreconditionFailed, http.StatusNoContent},
+		},
+		{
+			name: "products update partial", method: http.MethodPut, path: "/api/v1/products/bulk",
+			body: `{"mode":"partial","items":[{"id":1,"if_match":"*",` + product + `},{"id":2,"if_match":"*",` + product +
+				`},{"id":3,"if_match":"*",` + product + `}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusOK, http.StatusPreconditionFailed, http.StatusOK},
+		},
+		{
+			name: "products delete partial", method: http.MethodDelete, path: "/api/v1/products/bulk",
+			body:     `{"mode":"partial","items":[{"id":1,"if_match":"*"},{"id":2,"if_match":"*"},{"id":3,"if_match":"*"}]}`,
+			status:   http.StatusMultiStatus,
+			statuses: []int{http.StatusNoContent, http.StatusPreconditionFailed, http.StatusNoContent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userRepo := &racingUserRepo{InMemoryUserRepository: repository.NewInMemoryUserRepository(nil), raceID: 2}
+			productRepo := &racingProductRepo{InMemoryProductRepository: repository.NewInMemoryProductRepository(nil), raceID: 2}
+			for _, name := range []string{"a", "b", "c"} {
+				if err := userRepo.Create(ctx, &models.User{Name: name, Email: name + "@example.com"}); err != nil {
+					t.Fatal(err)
+				}
+				if err := productRepo.Create(ctx, &models.Product{Name: name, Slug: name}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			users := NewUserHandler(service.NewUserService(userRepo))
+			products := NewProductHandler(productRepo, nil, ImportLimits{})
+			r := gin.New()
+			r.PUT("/api/v1/users/bulk", users.PutUsersBulkV1)
+			r.DELETE("/api/v1/users/bulk", users.DeleteUsersBulkV1)
+			r.PUT("/api/v1/products/bulk", products.PutProductsBulkV1)
+			r.DELETE("/api/v1/products/bulk", products.DeleteProductsBulkV1)
+
+			status, resp := serveBulk(t, r, tt.method, tt.path, tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d, response: %+v", status, tt.status, resp)
+			}
+			if got := resp.statuses(); !slices.Equal(got, tt.statuses) {
+				t.Errorf("item statuses = %v, want %v", got, tt.statuses)
+			}
+		})
+	}
+}
@@ -1,13 +1,19 @@
 package v1handler
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/bulk"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
 	"mamba.com/route-group/utils"
 )
 
 type CategoryHandler struct {
+	repo repository.CategoryRepository
 }
 
 type GetCategoryByCategoryV1Param struct {
@@ -15,8 +21,35 @@ type GetCategoryByCategoryV1Param struct {
 }
 
 type PostCategoriesV1Param struct {
-	Name   string `form:"name" binding:"required"`
-	Status string `form:"status" binding:"required,oneof=1 2"`
+	Name   string `form:"name" json:"name" xml:"name" binding:"required"`
+	Status string `form:"status" json:"status" xml:"status" binding:"required,oneof=1 2"`
+}
+
+// PostCategoriesBulkV1Param: mỗi phần tử được validate như PostCategoriesV1Param
+type PostCategoriesBulkV1Param struct {
+	Mode  bulk.Mode               `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PostCategoriesV1Param `json:"items" xml:"items>category" binding:"required,gt=0"`
+}
+
+// CategoryItemV1Param chọn 1 category trong API bulk, category chưa có ETag nên không có if_match
+type CategoryItemV1Param struct {
+	ID int `json:"id" xml:"id" binding:"required,gt=0"`
+}
+
+// PutCategoriesBulkV1Item: slug không đổi theo name để link cũ không bị hỏng
+type PutCategoriesBulkV1Item struct {
+	CategoryItemV1Param
+	PostCategoriesV1Param
+}
+
+type PutCategoriesBulkV1Param struct {
+	Mode  bulk.Mode                 `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PutCategoriesBulkV1Item `json:"items" xml:"items>category" binding:"required,gt=0"`
+}
+
+type DeleteCategoriesBulkV1Param struct {
+	Mode  bulk.Mode             `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []CategoryItemV1Param `json:"items" xml:"items>category" binding:"required,gt=0"`
 }
 
 var validCategory = map[string]bool{
@@ -25,18 +58,18 @@ var validCategory = map[string]bool{
 	"golang": true,
 }
 
-func NewCategoryHandler() *CategoryHandler {
-	return &CategoryHandler{}
+func NewCategoryHandler(repo repository.CategoryRepository) *CategoryHandler {
+	return &CategoryHandler{repo: repo}
 }
 
 func (c *CategoryHandler) GetCategoryByCategoryV1(ctx *gin.Context) {
 	var params GetCategoryByCategoryV1Param
 	if err := ctx.ShouldBindUri(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message":  "Category found",
 		"category": params.Category,
 	})
@@ -56,13 +89,155 @@ func (c *CategoryHandler) GetCategoryByCategoryV1(ctx *gin.Context) {
 func (c *CategoryHandler) PostCategoriesV1(ctx *gin.Context) {
 	var param PostCategoriesV1Param
 	if err := ctx.ShouldBind(&param); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	category := models.Category{
+		Slug:   utils.Slugify(param.Name),
+		Name:   param.Name,
+		Status: param.Status,
+	}
+	c.repo.Create(ctx.Request.Context(), &category)
+
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Post category (V1)",
+		"slug":    category.Slug,
 		"name":    param.Name,
 		"status":  param.Status,
 	})
 }
+
+func (c *CategoryHandler) PostCategoriesBulkV1(ctx *gin.Context) {
+	var params PostCategoriesBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	if !batch.Rejected() {
+		pending := batch.Pending()
+		categories := make([]*models.Category, len(pending))
+		for j, i := range pending {
+			categories[j] = &models.Category{
+				Slug:   utils.Slugify(batch.Items[i].Name),
+				Name:   batch.Items[i].Name,
+				Status: batch.Items[i].Status,
+			}
+		}
+
+		if err := c.repo.CreateMany(ctx.Request.Context(), categories); err != nil {
+			utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		for j, i := range pending {
+			batch.Succeed(i, http.StatusCreated, categories[j])
+		}
+	}
+
+	batch.Render(http.StatusCreated, "Bulk create categories (v1)")
+}
+
+func (c *CategoryHandler) PutCategoriesBulkV1(ctx *gin.Context) {
+	var params PutCategoriesBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending    []int
+		categories []*models.Category
+	)
+	for _, i := range batch.Pending() {
+		item := batch.Items[i]
+		if _, ok := c.repo.FindByID(ctx.Request.Context(), item.ID); !ok {
+			batch.Fail(i, http.StatusNotFound, "Category not found")
+			continue
+		}
+
+		pending = append(pending, i)
+		categories = append(categories, &models.Category{ID: item.ID, Name: item.Name, Status: item.Status})
+	}
+
+	if !batch.Rejected() {
+		errs := repository.WriteBatch(categories, batch.Atomic(),
+			func(categories []*models.Category) error { return c.repo.UpdateMany(ctx.Request.Context(), categories) },
+			func(category *models.Category) error {
+				return c.repo.UpdateMany(ctx.Request.Context(), []*models.Category{category})
+			})
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := categoryError(errs[j])
+				batch.Fail(i, status, errBody)
+			} else {
+				batch.Succeed(i, http.StatusOK, categories[j])
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk update categories (v1)")
+}
+
+func (c *CategoryHandler) DeleteCategoriesBulkV1(ctx *gin.Context) {
+	var params DeleteCategoriesBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending []int
+		ids     []int
+	)
+	for _, i := range batch.Pending() {
+		id := batch.Items[i].ID
+		// ID lặp lại trong batch: lần xoá sau không còn category để xoá
+		if _, ok := c.repo.FindByID(ctx.Request.Context(), id); !ok || slices.Contains(ids, id) {
+			batch.Fail(i, http.StatusNotFound, "Category not found")
+			continue
+		}
+
+		pending = append(pending, i)
+		ids = append(ids, id)
+	}
+
+	if !batch.Rejected() {
+		errs := repository.WriteBatch(ids, batch.Atomic(),
+			func(ids []int) error { return c.repo.DeleteMany(ctx.Request.Context(), ids) },
+			func(id int) error { return c.repo.DeleteMany(ctx.Request.Context(), []int{id}) })
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := categoryError(errs[j])
+				batch.Fail(i, status, errBody)
+			} else {
+				batch.Succeed(i, http.StatusNoContent, nil)
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk delete categories (v1)")
+}
+
+// categoryError trả về status và phần "error" của body cho lỗi từ CategoryRepository
+func categoryError(err error) (int, any) {
+	if errors.Is(err, repository.ErrNotFound) {
+		return http.StatusNotFound, "Category not found"
+	}
+	return http.StatusInternalServerError, "Internal server error"
+}
@@ -2,143 +2,191 @@ package v1handler
 
 import (
 	"fmt"
+	"log/slog"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/cache"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/tasks"
 	"mamba.com/route-group/utils"
 )
 
 type NewsHandler struct {
+	repo      repository.NewsRepository
+	uploadDir string
+	queue     *jobs.Manager
 }
 
 type PostNewsV1Param struct {
-	Title  string `form:"title" binding:"required"`
-	Status string `form:"status" binding:"required,oneof=1 2"`
+	Title    string `form:"title" binding:"required"`
+	Status   string `form:"status" binding:"required,oneof=1 2"`
+	Category string `form:"category" binding:"omitempty,oneof=php python golang"`
+	Content  string `form:"content" binding:"omitempty,max=5000"`
+	// PublishAt hẹn giờ publish tin nháp (status 2), VD: 2026-01-02T08:00:00+07:00
+	PublishAt string `form:"publish_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
 
-func NewNewsHandler() *NewsHandler {
-	return &NewsHandler{}
+type GetNewsBySlugV1Param struct {
+	Slug string `uri:"slug" binding:"slug,max=128"`
 }
 
+func NewNewsHandler(repo repository.NewsRepository, uploadDir string, queue *jobs.Manager) *NewsHandler {
+	return &NewsHandler{repo: repo, uploadDir: uploadDir, queue: queue}
+}
+
+// GetNewsV1 trả về các tin đã publish, mới nhất trước
 func (n *NewsHandler) GetNewsV1(ctx *gin.Context) {
-	slug := ctx.Param("slug")
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Get News (V1)",
+		"news":    n.repo.ListPublished(ctx.Request.Context(), "", 0),
+	})
+}
 
-	if slug == "" {
-		ctx.JSON(http.StatusOK, gin.H{
-			"message": "Get News (V1)",
-			"slug":    "No News",
-		})
-	} else {
-		ctx.JSON(http.StatusOK, gin.H{
-			"message": "Get News (V1)",
-			"slug":    slug,
-		})
+// GetNewsBySlugV1 là trang mà feed và sitemap trỏ tới, tin nháp trả 404 như tin không tồn tại
+func (n *NewsHandler) GetNewsBySlugV1(ctx *gin.Context) {
+	var params GetNewsBySlugV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
 	}
 
+	news, ok := n.repo.FindBySlug(ctx.Request.Context(), params.Slug)
+	if !ok || !news.IsPublished() {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "News not found"})
+		return
+	}
+	cache.Tag(ctx, cache.ResourceTag(events.AggregateNews, news.ID))
+
+	if utils.NotModified(ctx, utils.VersionETag("news", news.ID, news.Version)) {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Get News By Slug (v1)",
+		"news":    news,
+	})
 }
 
 func (n *NewsHandler) PostNewsV1(ctx *gin.Context) {
-	var params PostNewsV1Param
-	if err := ctx.ShouldBind(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+	params, ok := bindNews(ctx)
+	if !ok {
 		return
 	}
 
 	// Lấy thông tin file
 	image, err := ctx.FormFile("image")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": "File is required"})
 		return
 	}
 
 	// Yêu cầu giới hạn file nhỏ hơn 5MB
 	// 1 << 20 = 2^20 = 1048576 = 1MB
 	// 5 << 20 = 5 * 2^20 = 5 * 1048576 = 5MB
-	if image.Size > 5<<20 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is too large (5 MB)"})
+	if maxSize := utils.CurrentUploadPolicy().MaxSize; image.Size > maxSize {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File is too large (%d MB)", maxSize>>20)})
 		return
 	}
 
 	// Tạo Folder
 	// os.ModePerm = 0777 (octal)
 	// Có nghĩa : đọc, ghi, thực thi (read, write, execute) cho tất cả mọi người (owner, group, others)
-	err = os.MkdirAll("./uploads", os.ModePerm)
+	err = os.MkdirAll(n.uploadDir, os.ModePerm)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot create upload folder"})
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot create upload folder"})
 		return
 	}
 
-	dst := fmt.Sprintf("./uploads/%s", filepath.Base(image.Filename))
+	dst := filepath.Join(n.uploadDir, filepath.Base(image.Filename))
 	if err := ctx.SaveUploadedFile(image, dst); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot save file"})
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot save file"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	news, ok := n.saveNews(ctx, params, []models.NewsImage{newsImage(filepath.Base(image.Filename), image.Size)})
+	if !ok {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Post news (V1)",
 		"title":   params.Title,
 		"status":  params.Status,
+		"slug":    news.Slug,
 		"image":   image.Filename,
 		"path":    dst,
 	})
 }
 
 func (n *NewsHandler) PostUploadFileNewsV1(ctx *gin.Context) {
-	var params PostNewsV1Param
-	if err := ctx.ShouldBind(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+	params, ok := bindNews(ctx)
+	if !ok {
 		return
 	}
 
 	// Lấy thông tin file
 	image, err := ctx.FormFile("image")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": "File is required"})
 		return
 	}
 
-	filename, err := utils.ValidateAndSaveFile(image, "./uploads")
+	filename, err := utils.ValidateAndSaveFile(ctx.Request.Context(), image, n.uploadDir)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	news, ok := n.saveNews(ctx, params, []models.NewsImage{newsImage(filename, image.Size)})
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Post news (V1)",
 		"title":   params.Title,
 		"status":  params.Status,
+		"slug":    news.Slug,
 		"image":   filename,
-		"path":    "./upload/" + filename,
+		"path":    filepath.Join(n.uploadDir, filename),
 	})
 }
 
 // Upload nhiều hình và hiển thị lỗi nếu File ảnh ko hợp lệ
 func (n *NewsHandler) PostUploadMultipleFileNewsV1(ctx *gin.Context) {
-	var params PostNewsV1Param
-	if err := ctx.ShouldBind(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+	params, ok := bindNews(ctx)
+	if !ok {
 		return
 	}
 
 	form, err := ctx.MultipartForm()
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
 		return
 	}
 
 	images := form.File["images"]
 	if len(images) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": "No file provided"})
 		return
 	}
 
 	// Báo lỗi khi file hình ảnh ko hợp lệ
 	var successFiles []string
 	var failedFile []map[string]string
+	var newsImages []models.NewsImage
 	for _, image := range images {
-		filename, err := utils.ValidateAndSaveFile(image, "./uploads")
+		filename, err := utils.ValidateAndSaveFile(ctx.Request.Context(), image, n.uploadDir)
 		if err != nil {
 			failedFile = append(failedFile, map[string]string{
 				"filename": filename,
@@ -148,12 +196,19 @@ func (n *NewsHandler) PostUploadMultipleFileNewsV1(ctx *gin.Context) {
 		}
 
 		successFiles = append(successFiles, filename)
+		newsImages = append(newsImages, newsImage(filename, image.Size))
+	}
+
+	news, ok := n.saveNews(ctx, params, newsImages)
+	if !ok {
+		return
 	}
 
 	resp := gin.H{
 		"message":       "Post news (V1)",
 		"title":         params.Title,
 		"status":        params.Status,
+		"slug":          news.Slug,
 		"success_files": successFiles,
 	}
 
@@ -162,5 +217,86 @@ func (n *NewsHandler) PostUploadMultipleFileNewsV1(ctx *gin.Context) {
 		resp["error_files"] = failedFile
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	utils.Render(ctx, http.StatusOK, resp)
+}
+
+// bindNews bind form của các API tạo tin, publish_at phải ở tương lai và chỉ dùng cho tin nháp
+func bindNews(ctx *gin.Context) (PostNewsV1Param, bool) {
+	var params PostNewsV1Param
+	if err := ctx.ShouldBind(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return params, false
+	}
+
+	if params.PublishAt != "" {
+		publishAt, _ := time.Parse(time.RFC3339, params.PublishAt)
+		switch {
+		case params.Status != models.NewsStatusDraft:
+			utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+				"publish_at": "publish_at chỉ dùng cho tin nháp (status = 2)",
+			}})
+			return params, false
+		case !publishAt.After(time.Now()):
+			utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+				"publish_at": "publish_at phải là thời điểm trong tương lai",
+			}})
+			return params, false
+		}
+	}
+
+	return params, true
+}
+
+// saveNews lưu tin và xếp hàng tạo ảnh thu nhỏ, lưu lỗi thì tự render lỗi và trả false
+func (n *NewsHandler) saveNews(ctx *gin.Context, params PostNewsV1Param, images []models.NewsImage) (models.News, bool) {
+	slug := utils.Slugify(params.Title)
+	if slug == "" {
+		slug = "news"
+	}
+
+	news := models.News{
+		Slug:     slug + "-" + uuid.New().String()[:8],
+		Title:    params.Title,
+		Content:  params.Content,
+		Category: params.Category,
+		Status:   params.Status,
+		Images:   images,
+	}
+	if params.PublishAt != "" {
+		publishAt, _ := time.Parse(time.RFC3339, params.PublishAt)
+		publishAt = publishAt.UTC()
+		news.ScheduledAt = &publishAt
+	}
+	if err := n.repo.Create(ctx.Request.Context(), &news); err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return news, false
+	}
+
+	// Ảnh thu nhỏ được tạo ở nền, lỗi xếp hàng không làm hỏng request vì ảnh gốc đã lưu
+	for _, image := range images {
+		if image.MimeType != "image/jpeg" && image.MimeType != "image/png" {
+			continue
+		}
+
+		_, err := n.queue.Enqueue(ctx.Request.Context(), tasks.TypeImageDerivatives, tasks.ImageDerivativesPayload{
+			NewsID:   news.ID,
+			Filename: image.Filename,
+		}, jobs.EnqueueOptions{})
+		if err != nil {
+			logging.From(ctx).Warn("enqueue image derivatives failed",
+				slog.String("filename", image.Filename),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return news, true
+}
+
+func newsImage(filename string, size int64) models.NewsImage {
+	return models.NewsImage{
+		Filename: filename,
+		MimeType: mime.TypeByExtension(filepath.Ext(filename)),
+		Size:     size,
+	}
 }
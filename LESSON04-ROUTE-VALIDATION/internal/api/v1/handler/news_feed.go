@@ -0,0 +1,115 @@
+package v1handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/feed"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/utils"
+)
+
+const newsFeedLimit = 50
+
+type GetNewsFeedV1Param struct {
+	Category string `form:"category" binding:"omitempty,oneof=php python golang"`
+}
+
+func (n *NewsHandler) GetNewsFeedRssV1(ctx *gin.Context) {
+	n.renderFeed(ctx, feed.RSS, feed.ContentTypeRSS)
+}
+
+func (n *NewsHandler) GetNewsFeedAtomV1(ctx *gin.Context) {
+	n.renderFeed(ctx, feed.Atom, feed.ContentTypeAtom)
+}
+
+func (n *NewsHandler) GetNewsFeedJsonV1(ctx *gin.Context) {
+	n.renderFeed(ctx, feed.JSON, feed.ContentTypeJSON)
+}
+
+func (n *NewsHandler) renderFeed(ctx *gin.Context, encode func(feed.Feed) ([]byte, error), contentType string) {
+	var params GetNewsFeedV1Param
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	f := n.buildFeed(ctx, params.Category)
+
+	body, err := encode(f)
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot generate feed"})
+		return
+	}
+
+	if utils.CheckNotModified(ctx, utils.ETag(body), f.Updated) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(http.StatusOK, contentType, body)
+}
+
+func (n *NewsHandler) buildFeed(ctx *gin.Context, category string) feed.Feed {
+	baseURL := requestBaseURL(ctx)
+
+	title := "Mamba News"
+	if category != "" {
+		title = fmt.Sprintf("Mamba News - %s", category)
+	}
+
+	f := feed.Feed{
+		Title:       title,
+		Description: "Tin tức mới nhất từ Mamba",
+		SiteURL:     baseURL + "/api/v1/news",
+		FeedURL:     baseURL + ctx.Request.URL.RequestURI(),
+		Author:      "Mamba",
+		Language:    "vi",
+	}
+
+	for _, news := range n.repo.ListPublished(ctx.Request.Context(), category, newsFeedLimit) {
+		f.Items = append(f.Items, newsFeedItem(baseURL, news))
+		if news.UpdatedAt.After(f.Updated) {
+			f.Updated = news.UpdatedAt
+		}
+	}
+
+	return f
+}
+
+func newsFeedItem(baseURL string, news models.News) feed.Item {
+	link := baseURL + "/api/v1/news/" + news.Slug
+
+	item := feed.Item{
+		ID:        link,
+		Title:     news.Title,
+		Link:      link,
+		Content:   news.Content,
+		Category:  news.Category,
+		Published: news.PublishedAt,
+		Updated:   news.UpdatedAt,
+	}
+
+	for _, image := range news.Images {
+		item.Enclosures = append(item.Enclosures, feed.Enclosure{
+			URL:    baseURL + "/uploads/" + image.Filename,
+			Type:   image.MimeType,
+			Length: image.Size,
+		})
+	}
+
+	return item
+}
+
+func requestBaseURL(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + ctx.Request.Host
+}
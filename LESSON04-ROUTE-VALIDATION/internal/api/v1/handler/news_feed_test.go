@@ -0,0 +1,196 @@
+package v1handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+)
+
+func newsFeedEngine(t *testing.T) (*gin.Engine, *repository.InMemoryNewsRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewInMemoryNewsRepository(nil)
+	published := time.Now().UTC().Add(-time.Hour)
+	for _, news := range []models.News{
+		{Slug: "go-1-25", Title: "Go 1.25", Content: "Go", Category: "golang", Status: models.NewsStatusPublished, PublishedAt: published,
+			Images: []models.NewsImage{{Filename: "cover.jpg", MimeType: "image/jpeg", Size: 2048}}},
+		{Slug: "php-8-4", Title: "PHP 8.4", Content: "PHP", Category: "php", Status: models.NewsStatusPublished, PublishedAt: published.Add(-time.Hour)},
+		{Slug: "go-draft", Title: "Go draft", Content: "Go", Category: "golang", Status: models.NewsStatusDraft},
+	} {
+		if err := repo.Create(context.Background(), &news); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	news := NewNewsHandler(repo, "", nil)
+	r := gin.New()
+	r.GET("/api/v1/news/feed.rss", news.GetNewsFeedRssV1)
+	r.GET("/api/v1/news/feed.atom", news.GetNewsFeedAtomV1)
+	r.GET("/api/v1/news/feed.json", news.GetNewsFeedJsonV1)
+	r.GET("/api/v1/news/:slug", news.GetNewsBySlugV1)
+	return r, repo
+}
+
+func TestNewsFeedCategory(t *testing.T) {
+	r, _ := newsFeedEngine(t)
+
+	tests := []struct {
+		query string
+		title string
+		slugs []string
+	}{
+		{"", "Mamba News", []string{"go-1-25", "php-8-4"}},
+		{"?category=golang", "Mamba News - golang", []string{"go-1-25"}},
+		{"?category=python", "Mamba News - python", nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/news/feed.json"+tt.query, nil)
+		req.Host = "news.example.com"
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, body: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		var doc struct {
+			Title   string `json:"title"`
+			FeedURL string `json:"feed_url"`
+			Items   []struct {
+				URL         string `json:"url"`
+				Attachments []struct {
+					URL string `json:"url"`
+				} `json:"attachments"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc.Title != tt.title {
+			t.Errorf("%q: title = %q, want %q", tt.query, doc.Title, tt.title)
+		}
+		if want := "http://news.example.com/api/v1/news/feed.json" + tt.query; doc.FeedURL != want {
+			t.Errorf("%q: feed_url = %q, want %q", tt.query, doc.FeedURL, want)
+		}
+		if len(doc.Items) != len(tt.slugs) {
+			t.Fatalf("%q: %d items, want %v", tt.query, len(doc.Items), tt.slugs)
+		}
+		for i, slug := range tt.slugs {
+			if want := "http://news.example.com/api/v1/news/" + slug; doc.Items[i].URL != want {
+				t.Errorf("%q: item %d url = %q, want %q", tt.query, i, doc.Items[i].URL, want)
+			}
+		}
+		if tt.slugs != nil && (len(doc.Items[0].Attachments) != 1 || doc.Items[0].Attachments[0].URL != "http://news.example.com/uploads/cover.jpg") {
+			t.Errorf("%q: attachments = %+v", tt.query, doc.Items[0].Attachments)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/news/feed.json?category=rust", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown category: status = %d, want 400", w.Code)
+	}
+}
+
+func TestNewsFeedNotModified(t *testing.T) {
+	r, _ := newsFeedEngine(t)
+
+	for _, path := range []string{"/api/v1/news/feed.rss", "/api/v1/news/feed.atom", "/api/v1/news/feed.json?category=golang"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+		if w.Code != http.StatusOK || etag == "" || lastModified == "" {
+			t.Fatalf("%s: status = %d, ETag = %q, Last-Modified = %q", path, w.Code, etag, lastModified)
+		}
+
+		tests := []struct {
+			name   string
+			header string
+			value  string
+			status int
+		}{
+			{"matching ETag", "If-None-Match", etag, http.StatusNotModified},
+			{"stale ETag", "If-None-Match", `"stale"`, http.StatusOK},
+			{"not modified since", "If-Modified-Since", lastModified, http.StatusNotModified},
+			{"modified since", "If-Modified-Since", time.Now().Add(-24 * time.Hour).UTC().Format(http.TimeFormat), http.StatusOK},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("%s %s: status = %d, want %d", path, tt.name, w.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("%s %s: 304 with body", path, tt.name)
+			}
+		}
+	}
+}
+
+// Link của item trong feed phải mở được đúng tin
+func TestNewsBySlug(t *testing.T) {
+	r, repo := newsFeedEngine(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/news/feed.json", nil))
+	var doc struct {
+		Items []struct {
+			URL   string `json:"url"`
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range doc.Items {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, item.URL, nil))
+		var body struct {
+			News models.News `json:"news"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK || body.News.Title != item.Title {
+			t.Errorf("%s: status = %d, body: %s", item.URL, w.Code, w.Body.String())
+		}
+	}
+
+	for _, path := range []string{"/api/v1/news/go-draft", "/api/v1/news/missing"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/news/go-1-25", nil))
+	etag := w.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/news/go-1-25", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match: status = %d, want 304", w.Code)
+	}
+
+	// Ảnh thu nhỏ làm đổi nội dung tin nên ETag cũ không còn khớp
+	news, _ := repo.FindBySlug(context.Background(), "go-1-25")
+	if err := repo.SetImageDerivatives(context.Background(), news.ID, "cover.jpg", []models.ImageDerivative{{Width: 320, Filename: "cover-320.jpg"}}); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after update: status = %d, ETag = %q (was %q)", w.Code, w.Header().Get("ETag"), etag)
+	}
+}
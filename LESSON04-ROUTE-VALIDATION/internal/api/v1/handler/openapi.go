@@ -0,0 +1,112 @@
+package v1handler
+
+import (
+	"net/http"
+
+	"mamba.com/route-group/internal/api/adapter"
+	"mamba.com/route-group/internal/openapi"
+	"mamba.com/route-group/internal/stream"
+)
+
+// DescribeOpenAPI khai báo struct bind của từng route v1 để sinh OpenAPI spec
+func DescribeOpenAPI(g *openapi.Generator) {
+	g.Describe(
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/users", Summary: "List all user (NDJSON with Accept: application/x-ndjson)", Stream: adapter.UserV1{}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/users/:id", Summary: "Get user by ID", Input: GetUsersByIdV1Param{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/users/admin/:uuid", Summary: "Get user by UUID", Input: GetUsersByUuidV1Param{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/users", Summary: "Create user", Input: PostUsersV1Param{}, Errors: []int{http.StatusConflict}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/users/bulk", Summary: "Create users in bulk (atomic or partial with 207)", Input: PostUsersBulkV1Param{}, Errors: []int{http.StatusConflict}, Responses: []int{http.StatusMultiStatus}},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/api/v1/users/bulk", Summary: "Update users in bulk (atomic or partial with 207)", Input: PutUsersBulkV1Param{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired}, Responses: []int{http.StatusMultiStatus},
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/api/v1/users/bulk", Summary: "Delete users in bulk (atomic or partial with 207)", Input: DeleteUsersBulkV1Param{}, Status: http.StatusOK,
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}, Responses: []int{http.StatusMultiStatus},
+		},
+		openapi.Route{Method: http.MethodPut, Path: "/api/v1/users/:id", Summary: "Update user", Input: putUsersByIdV1Input{}, Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+		openapi.Route{Method: http.MethodDelete, Path: "/api/v1/users/:id", Summary: "Delete user", Input: GetUsersByIdV1Param{}, Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/products", Summary: "Search products", Input: GetProductsV1Param{}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/products/export", Summary: "Export products as CSV, XLSX or NDJSON", Input: GetProductsExportV1Param{}},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/api/v1/products/import", Summary: "Import products from CSV or XLSX in the background",
+			Input: PostProductsImportV1Param{}, Files: []openapi.File{{Name: "file", Required: true}}, Status: http.StatusAccepted,
+			Errors: []int{http.StatusRequestEntityTooLarge},
+		},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/products/import/:id", Summary: "Get product import status", Input: GetProductsImportV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/products/import/:id/report", Summary: "Download row errors of a product import", Input: GetProductsImportReportV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/products/:slug", Summary: "Get product by slug", Input: GetProductsBySlugV1Param{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/products", Summary: "Create product", Input: PostProductsV1Param{}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/products/bulk", Summary: "Create products in bulk (atomic or partial with 207)", Input: PostProductsBulkV1Param{}, Responses: []int{http.StatusMultiStatus}},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/api/v1/products/bulk", Summary: "Update products in bulk (atomic or partial with 207)", Input: PutProductsBulkV1Param{},
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}, Responses: []int{http.StatusMultiStatus},
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/api/v1/products/bulk", Summary: "Delete products in bulk (atomic or partial with 207)", Input: DeleteProductsBulkV1Param{}, Status: http.StatusOK,
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}, Responses: []int{http.StatusMultiStatus},
+		},
+		openapi.Route{Method: http.MethodPut, Path: "/api/v1/products/:id", Summary: "Update product", Input: putProductsByIdV1Input{}, Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+		openapi.Route{Method: http.MethodDelete, Path: "/api/v1/products/:id", Summary: "Delete product", Input: GetProductsByIdV1Param{}, Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/categories/:category", Summary: "Get category", Input: GetCategoryByCategoryV1Param{}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/categories", Summary: "Create category", Input: PostCategoriesV1Param{}, Status: http.StatusOK},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/categories/bulk", Summary: "Create categories in bulk (atomic or partial with 207)", Input: PostCategoriesBulkV1Param{}, Responses: []int{http.StatusMultiStatus}},
+		openapi.Route{Method: http.MethodPut, Path: "/api/v1/categories/bulk", Summary: "Update categories in bulk (atomic or partial with 207)", Input: PutCategoriesBulkV1Param{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusMultiStatus}},
+		openapi.Route{Method: http.MethodDelete, Path: "/api/v1/categories/bulk", Summary: "Delete categories in bulk (atomic or partial with 207)", Input: DeleteCategoriesBulkV1Param{}, Status: http.StatusOK, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusMultiStatus}},
+
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/news", Summary: "Get news", Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/news/:slug", Summary: "Get published news by slug", Input: GetNewsBySlugV1Param{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/news/feed.rss", Summary: "RSS 2.0 feed", Input: GetNewsFeedV1Param{}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/news/feed.atom", Summary: "Atom feed", Input: GetNewsFeedV1Param{}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/news/feed.json", Summary: "JSON Feed", Input: GetNewsFeedV1Param{}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/api/v1/news", Summary: "Create news with image",
+			Input: PostNewsV1Param{}, Files: []openapi.File{{Name: "image", Required: true}}, Status: http.StatusOK,
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/api/v1/news/upload-file", Summary: "Create news with validated image",
+			Input: PostNewsV1Param{}, Files: []openapi.File{{Name: "image", Required: true}}, Status: http.StatusOK,
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/api/v1/news/upload-multiple-file", Summary: "Create news with multiple images",
+			Input: PostNewsV1Param{}, Files: []openapi.File{{Name: "images", Multiple: true, Required: true}}, Status: http.StatusOK,
+		},
+
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/stream", Summary: "Live product price changes and published news (Server-Sent Events)", Input: stream.GetStreamParam{}, Errors: []int{http.StatusServiceUnavailable}},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/api/v1/stream/ws", Summary: "Live updates over WebSocket with subscribe / unsubscribe messages",
+			Input: stream.GetStreamParam{}, Status: http.StatusSwitchingProtocols, Errors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable},
+		},
+
+		openapi.Route{Method: http.MethodGet, Path: "/sitemap.xml", Summary: "Sitemap index", Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/sitemap.xml.gz", Summary: "Gzipped sitemap index", Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodGet, Path: "/sitemaps/:file", Summary: "Sitemap shard, e.g. products-1.xml or products-1.xml.gz", Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/webhooks", Summary: "List webhook subscriptions"},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/webhooks", Summary: "Create webhook subscription (secret is returned only here)", Input: PostWebhooksV1Param{}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/webhooks/:id", Summary: "Get webhook subscription", Input: GetWebhookByIdV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodPatch, Path: "/api/v1/webhooks/:id", Summary: "Update or re-enable webhook subscription", Input: patchWebhookByIdV1Input{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodDelete, Path: "/api/v1/webhooks/:id", Summary: "Delete webhook subscription", Input: GetWebhookByIdV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/webhooks/:id/deliveries", Summary: "Webhook delivery log, newest first", Input: GetWebhookByIdV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v1/webhooks/:id/deliveries/:delivery_id", Summary: "Get webhook delivery with its attempts", Input: GetWebhookDeliveryV1Param{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v1/webhooks/:id/deliveries/:delivery_id/redeliver", Summary: "Send a delivery again", Input: GetWebhookDeliveryV1Param{}, Status: http.StatusAccepted, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+	)
+}
+
+// Gộp struct uri và body của route PUT để generator đọc 1 lần
+type putUsersByIdV1Input struct {
+	GetUsersByIdV1Param
+	PutUsersV1Param
+}
+
+type putProductsByIdV1Input struct {
+	GetProductsByIdV1Param
+	PostProductsV1Param
+}
+
+type patchWebhookByIdV1Input struct {
+	GetWebhookByIdV1Param
+	PatchWebhookByIdV1Param
+}
@@ -1,6 +1,8 @@
 package v1handler
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -8,14 +10,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"mamba.com/route-group/internal/bulk"
+	"mamba.com/route-group/internal/cache"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
 	"mamba.com/route-group/utils"
 )
 
 type ProductHandler struct {
+	repo         repository.ProductRepository
+	importer     *imports.Manager
+	importLimits ImportLimits
 }
 
 type GetProductsBySlugV1Param struct {
-	Slug string `uri:"slug" binding:"slug,min=3,max=5"`
+	// Slug sinh từ Name (tối đa 100 kí tự) cộng hậu tố chống trùng của repository
+	Slug string `uri:"slug" binding:"slug,max=128"`
+}
+
+type GetProductsByIdV1Param struct {
+	ID int `uri:"id" binding:"gt=0"`
 }
 
 type GetProductsV1Param struct {
@@ -26,29 +42,75 @@ type GetProductsV1Param struct {
 }
 
 type ProductImage struct {
-	ImageName string `json:"image_name" binding:"required"`
-	ImageLink string `json:"image_link" binding:"required,file_ext=jpg png gif"`
+	ImageName string `json:"image_name" xml:"image_name" binding:"required"`
+	ImageLink string `json:"image_link" xml:"image_link" binding:"required,file_ext=jpg png gif"`
 }
 
 type ProductAttribute struct {
-	AttributeName  string `json:"attribute_name" binding:"required"`
-	AttributeValue string `json:"attribute_value" binding:"required"`
+	AttributeName  string `json:"attribute_name" xml:"attribute_name" binding:"required"`
+	AttributeValue string `json:"attribute_value" xml:"attribute_value" binding:"required"`
 }
 
 type ProductInfo struct {
-	InfoKey   string `json:"info_key" binding:"required"`
-	InfoValue string `json:"info_value" binding:"required"`
+	InfoKey   string `json:"info_key" xml:"info_key" binding:"required"`
+	InfoValue string `json:"info_value" xml:"info_value" binding:"required"`
+}
+
+// ProductInfoMap giữ nguyên dạng map khi bind JSON/YAML/msgpack,
+// riêng XML thì đọc từ các element con (key là tên element hoặc attribute key)
+type ProductInfoMap map[string]ProductInfo
+
+func (m *ProductInfoMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	result := ProductInfoMap{}
+	err := utils.DecodeXMLMap(d, func(key string, child xml.StartElement) error {
+		var info ProductInfo
+		if err := d.DecodeElement(&info, &child); err != nil {
+			return err
+		}
+		result[key] = info
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*m = result
+	return nil
 }
 
+// PostProductsV1Param bind được từ JSON, XML, YAML và msgpack (xem utils.BindBody)
 type PostProductsV1Param struct {
-	Name             string                 `json:"name" binding:"required,min=3,max=100"`
-	Price            int                    `json:"price" binding:"required,min_int=100000"`
-	Display          *bool                  `json:"display" binding:"omitempty"`
-	ProductImage     ProductImage           `json:"product_image" binding:"required"`
-	Tag              []string               `json:"tags" binding:"required,gt=3,lt=5"`
-	ProductAttribute []ProductAttribute     `json:"product_attribute" binding:"required,gt=0,dive"`
-	ProductInfo      map[string]ProductInfo `json:"product_info" binding:"required,gt=0,dive"`
-	ProductMetadata  map[string]any         `json:"product_metadata" binding:"omitempty"`
+	XMLName          xml.Name           `json:"-" yaml:"-" codec:"-" xml:"product"`
+	Name             string             `json:"name" xml:"name" binding:"required,min=3,max=100"`
+	Price            int                `json:"price" xml:"price" binding:"required,min_int=100000"`
+	Display          *bool              `json:"display" xml:"display" binding:"omitempty"`
+	ProductImage     ProductImage       `json:"product_image" xml:"product_image" binding:"required"`
+	Tag              []string           `json:"tags" xml:"tags" binding:"required,gt=3,lt=5"`
+	ProductAttribute []ProductAttribute `json:"product_attribute" xml:"product_attribute" binding:"required,gt=0,dive"`
+	ProductInfo      ProductInfoMap     `json:"product_info" xml:"product_info" binding:"required,gt=0,dive"`
+	ProductMetadata  utils.XMLMap       `json:"product_metadata" xml:"product_metadata" binding:"omitempty"`
+}
+
+// PostProductsBulkV1Param: mỗi phần tử được validate như PostProductsV1Param
+type PostProductsBulkV1Param struct {
+	Mode  bulk.Mode             `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PostProductsV1Param `json:"items" xml:"items>product" binding:"required,gt=0"`
+}
+
+// PutProductsBulkV1Item là body của PUT /products/:id kèm product cần sửa
+type PutProductsBulkV1Item struct {
+	BulkItemV1Param
+	PostProductsV1Param
+}
+
+type PutProductsBulkV1Param struct {
+	Mode  bulk.Mode               `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PutProductsBulkV1Item `json:"items" xml:"items>product" binding:"required,gt=0"`
+}
+
+type DeleteProductsBulkV1Param struct {
+	Mode  bulk.Mode         `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []BulkItemV1Param `json:"items" xml:"items>product" binding:"required,gt=0"`
 }
 
 var (
@@ -56,8 +118,8 @@ var (
 	searchRegex = regexp.MustCompile(`^[a-zA-Z0-9\s]+$`)
 )
 
-func NewProductHandler() *ProductHandler {
-	return &ProductHandler{}
+func NewProductHandler(repo repository.ProductRepository, importer *imports.Manager, importLimits ImportLimits) *ProductHandler {
+	return &ProductHandler{repo: repo, importer: importer, importLimits: importLimits}
 }
 
 // Product API
@@ -65,7 +127,7 @@ func NewProductHandler() *ProductHandler {
 func (p *ProductHandler) GetProductsV1(ctx *gin.Context) {
 	var params GetProductsV1Param
 	if err := ctx.ShouldBindQuery(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
 		return
 	}
 
@@ -81,7 +143,7 @@ func (p *ProductHandler) GetProductsV1(ctx *gin.Context) {
 		params.Date = time.Now().Format("2006-01-02")
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Get Product (v1)",
 		"search":  params.Search,
 		"limit":   params.Limit,
@@ -99,13 +161,25 @@ func (p *ProductHandler) GetProductsBySlugV1(ctx *gin.Context) {
 
 	var params GetProductsBySlugV1Param
 	if err := ctx.ShouldBindUri(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	product, ok := p.repo.FindBySlug(ctx.Request.Context(), params.Slug)
+	if !ok {
+		renderProductError(ctx, repository.ErrNotFound)
 		return
 	}
+	cache.Tag(ctx, cache.ResourceTag(events.AggregateProduct, product.ID))
 
-	ctx.JSON(http.StatusOK, gin.H{
+	if utils.NotModified(ctx, productETag(product)) {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Get Product By Slug (v1)",
 		"slug":    params.Slug,
+		"product": product,
 	})
 
 	// slug := ctx.Param("slug")
@@ -123,30 +197,21 @@ func (p *ProductHandler) GetProductsBySlugV1(ctx *gin.Context) {
 
 func (p *ProductHandler) PostProductsV1(ctx *gin.Context) {
 
-	var params PostProductsV1Param
-	if err := ctx.ShouldBindJSON(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+	params, ok := bindProduct(ctx)
+	if !ok {
 		return
 	}
 
-	for key := range params.ProductInfo {
-		if _, err := uuid.Parse(key); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": gin.H{
-					"product_info": fmt.Sprintf("Key '%s' trong product_info không phải là UUID hợp lệ", key),
-				},
-			})
-			return
-		}
-	}
-
-	if params.Display == nil {
-		defaultDisplay := true
-		params.Display = &defaultDisplay
+	product := toProductModel(params)
+	if err := p.repo.Create(ctx.Request.Context(), &product); err != nil {
+		renderProductError(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
+	ctx.Header("ETag", productETag(&product))
+	utils.Render(ctx, http.StatusCreated, gin.H{
 		"message":           "Create Product (v1)",
+		"slug":              product.Slug,
 		"name":              params.Name,
 		"price":             params.Price,
 		"display":           params.Display,
@@ -168,10 +233,333 @@ func (p *ProductHandler) PostProductsV1(ctx *gin.Context) {
 	// })
 }
 
+func (p *ProductHandler) PostProductsBulkV1(ctx *gin.Context) {
+	var params PostProductsBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	for _, i := range batch.Pending() {
+		if errBody := checkProduct(&batch.Items[i]); errBody != nil {
+			batch.Fail(i, http.StatusBadRequest, errBody)
+		}
+	}
+
+	if !batch.Rejected() {
+		pending := batch.Pending()
+		products := make([]*models.Product, len(pending))
+		for j, i := range pending {
+			product := toProductModel(batch.Items[i])
+			products[j] = &product
+		}
+
+		errs := repository.WriteBatch(products, batch.Atomic(),
+			func(products []*models.Product) error { return p.repo.CreateMany(ctx.Request.Context(), products) },
+			func(product *models.Product) error { return p.repo.Create(ctx.Request.Context(), product) })
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := productError(errs[j])
+				batch.Fail(i, status, errBody)
+			} else {
+				batch.Succeed(i, http.StatusCreated, products[j])
+			}
+		}
+	}
+
+	batch.Render(http.StatusCreated, "Bulk create products (v1)")
+}
+
+// PutProductsByIdV1 thay toàn bộ product, slug giữ nguyên để link cũ không bị hỏng
 func (p *ProductHandler) PutProductsByIdV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "Update Product By ID (v1)"})
+	var uri GetProductsByIdV1Param
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	params, ok := bindProduct(ctx)
+	if !ok {
+		return
+	}
+
+	current, ok := p.repo.FindByID(ctx.Request.Context(), uri.ID)
+	if !ok {
+		renderProductError(ctx, repository.ErrNotFound)
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, productETag(current)) {
+		return
+	}
+
+	product := toProductModel(params)
+	product.ID = current.ID
+	product.Slug = current.Slug
+	product.Version = current.Version
+	if err := p.repo.Update(ctx.Request.Context(), &product); err != nil {
+		renderProductError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", productETag(&product))
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Update Product By ID (v1)",
+		"product": product,
+	})
 }
 
 func (p *ProductHandler) DeleteProductsByIdV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusNoContent, gin.H{"message": "Delete Product By ID (v1)"})
+	var uri GetProductsByIdV1Param
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	product, ok := p.repo.FindByID(ctx.Request.Context(), uri.ID)
+	if !ok {
+		renderProductError(ctx, repository.ErrNotFound)
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, productETag(product)) {
+		return
+	}
+
+	if err := p.repo.Delete(ctx.Request.Context(), product.ID, product.Version); err != nil {
+		renderProductError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusNoContent, gin.H{"message": "Delete Product By ID (v1)"})
+}
+
+// PutProductsBulkV1 sửa nhiều product như PutProductsByIdV1, slug cũng giữ nguyên
+func (p *ProductHandler) PutProductsBulkV1(ctx *gin.Context) {
+	var params PutProductsBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending  []int
+		products []*models.Product
+	)
+	seen := make(map[int]bool, len(batch.Items))
+	for _, i := range batch.Pending() {
+		item := &batch.Items[i]
+		current, status, errBody := p.bulkProduct(ctx, item.BulkItemV1Param)
+		if status == 0 && seen[current.ID] {
+			// Lần sửa thứ 2 của cùng 1 product dựa trên version mà lần đầu đã làm cũ
+			status, errBody = productError(repository.ErrVersionConflict)
+		}
+		if status == 0 {
+			if invalid := checkProduct(&item.PostProductsV1Param); invalid != nil {
+				status, errBody = http.StatusBadRequest, invalid
+			}
+		}
+		if status != 0 {
+			batch.Fail(i, status, errBody)
+			continue
+		}
+
+		product := toProductModel(item.PostProductsV1Param)
+		product.ID = current.ID
+		product.Slug = current.Slug
+		product.Version = current.Version
+		seen[product.ID] = true
+		pending = append(pending, i)
+		products = append(products, &product)
+	}
+
+	if !batch.Rejected() {
+		errs := repository.WriteBatch(products, batch.Atomic(),
+			func(products []*models.Product) error { return p.repo.UpdateMany(ctx.Request.Context(), products) },
+			func(product *models.Product) error { return p.repo.Update(ctx.Request.Context(), product) })
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := productError(errs[j])
+				batch.Fail(i, status, errBody)
+			} else {
+				batch.Succeed(i, http.StatusOK, products[j])
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk update products (v1)")
+}
+
+func (p *ProductHandler) DeleteProductsBulkV1(ctx *gin.Context) {
+	var params DeleteProductsBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending  []int
+		products []*models.Product
+	)
+	seen := make(map[int]bool, len(batch.Items))
+	for _, i := range batch.Pending() {
+		product, status, errBody := p.bulkProduct(ctx, batch.Items[i])
+		if status == 0 && seen[product.ID] {
+			status, errBody = productError(repository.ErrNotFound)
+		}
+		if status != 0 {
+			batch.Fail(i, status, errBody)
+			continue
+		}
+
+		seen[product.ID] = true
+		pending = append(pending, i)
+		products = append(products, product)
+	}
+
+	if !batch.Rejected() {
+		errs := repository.WriteBatch(products, batch.Atomic(),
+			func(products []*models.Product) error { return p.repo.DeleteMany(ctx.Request.Context(), products) },
+			func(product *models.Product) error {
+				return p.repo.Delete(ctx.Request.Context(), product.ID, product.Version)
+			})
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := productError(errs[j])
+				batch.Fail(i, status, errBody)
+			} else {
+				batch.Succeed(i, http.StatusNoContent, nil)
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk delete products (v1)")
+}
+
+// bulkProduct đọc product của 1 phần tử bulk và kiểm tra if_match, status khác 0 là lỗi của phần tử
+func (p *ProductHandler) bulkProduct(ctx *gin.Context, item BulkItemV1Param) (*models.Product, int, any) {
+	product, ok := p.repo.FindByID(ctx.Request.Context(), item.ID)
+	if !ok {
+		status, errBody := productError(repository.ErrNotFound)
+		return nil, status, errBody
+	}
+
+	status, errBody := checkBulkItem(item, productETag(product))
+	return product, status, errBody
+}
+
+// bindProduct bind và validate body dùng chung cho create và update
+func bindProduct(ctx *gin.Context) (PostProductsV1Param, bool) {
+	var params PostProductsV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return params, false
+	}
+
+	if errBody := checkProduct(&params); errBody != nil {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": errBody})
+		return params, false
+	}
+
+	return params, true
+}
+
+// checkProduct kiểm tra phần binding tag không làm được và điền giá trị mặc định,
+// trả về lỗi cùng định dạng với lỗi validate
+func checkProduct(params *PostProductsV1Param) gin.H {
+	for key := range params.ProductInfo {
+		if _, err := uuid.Parse(key); err != nil {
+			return gin.H{
+				"product_info": fmt.Sprintf("Key '%s' trong product_info không phải là UUID hợp lệ", key),
+			}
+		}
+	}
+
+	if params.Display == nil {
+		defaultDisplay := true
+		params.Display = &defaultDisplay
+	}
+
+	return nil
+}
+
+func productETag(product *models.Product) string {
+	return utils.VersionETag("product", product.ID, product.Version)
+}
+
+func renderProductError(ctx *gin.Context, err error) {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		utils.RenderPreconditionFailed(ctx, "")
+		return
+	}
+
+	status, errBody := productError(err)
+	utils.Render(ctx, status, gin.H{"error": errBody})
+}
+
+// productError trả về status và phần "error" của body cho lỗi từ ProductRepository
+func productError(err error) (int, any) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound, "Product not found"
+	case errors.Is(err, repository.ErrVersionConflict):
+		return http.StatusPreconditionFailed, utils.ModifiedMessage
+	default:
+		return http.StatusInternalServerError, "Internal server error"
+	}
+}
+
+func toProductModel(params PostProductsV1Param) models.Product {
+	slug := utils.Slugify(params.Name)
+	if slug == "" {
+		slug = "product"
+	}
+
+	product := models.Product{
+		Slug:  slug,
+		Name:  params.Name,
+		Price: params.Price,
+		ProductImage: models.ProductImage{
+			ImageName: params.ProductImage.ImageName,
+			ImageLink: params.ProductImage.ImageLink,
+		},
+		Tag:             params.Tag,
+		ProductInfo:     make(map[string]models.ProductInfo, len(params.ProductInfo)),
+		ProductMetadata: params.ProductMetadata,
+	}
+
+	if params.Display != nil {
+		product.Display = *params.Display
+	}
+
+	for _, attr := range params.ProductAttribute {
+		product.ProductAttribute = append(product.ProductAttribute, models.ProductAttribute{
+			AttributeName:  attr.AttributeName,
+			AttributeValue: attr.AttributeValue,
+		})
+	}
+
+	for key, info := range params.ProductInfo {
+		product.ProductInfo[key] = models.ProductInfo{
+			InfoKey:   info.InfoKey,
+			InfoValue: info.InfoValue,
+		}
+	}
+
+	return product
 }
@@ -0,0 +1,472 @@
+package v1handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/spreadsheet"
+	"mamba.com/route-group/utils"
+)
+
+// Cột của file export/import, đặt tên theo field JSON của PostProductsV1Param.
+// Attribute và product_info được trải thành nhiều cột:
+// product_attribute.<attribute_name> và product_info.<uuid>.info_key / .info_value
+const (
+	columnAttributePrefix = "product_attribute."
+	columnInfoPrefix      = "product_info."
+	// listSeparator nối nhiều giá trị trong 1 ô: tags, attribute trùng tên
+	listSeparator = "|"
+)
+
+var productColumns = []string{
+	"id", "slug", "name", "price", "display",
+	"product_image.image_name", "product_image.image_link",
+	"tags", "product_metadata", "version", "created_at", "updated_at",
+}
+
+type GetProductsExportV1Param struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx ndjson"`
+}
+
+// formatNDJSON export mỗi product thành 1 dòng JSON, giữ nguyên cấu trúc lồng nhau
+const formatNDJSON = "ndjson"
+
+type PostProductsImportV1Param struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type GetProductsImportV1Param struct {
+	ID string `uri:"id" binding:"uuid"`
+}
+
+type GetProductsImportReportV1Param struct {
+	ID     string `uri:"id" binding:"uuid"`
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+// ImportLimits giới hạn file import để 1 request không chiếm hết bộ nhớ
+type ImportLimits struct {
+	MaxSize int64
+	MaxRows int
+}
+
+// GetProductsExportV1 stream toàn bộ catalog ra CSV (ghi dần từng dòng), XLSX hoặc NDJSON
+func (p *ProductHandler) GetProductsExportV1(ctx *gin.Context) {
+	var params GetProductsExportV1Param
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+	if params.Format == "" {
+		params.Format = spreadsheet.FormatCSV
+	}
+	if params.Format == formatNDJSON {
+		p.exportNDJSON(ctx)
+		return
+	}
+
+	products := p.repo.List(ctx.Request.Context())
+	attributes, infoKeys := flattenedColumns(products)
+
+	ctx.Header("Content-Type", spreadsheet.ContentType(params.Format))
+	ctx.Header("Content-Disposition", `attachment; filename="products.`+params.Format+`"`)
+	ctx.Status(http.StatusOK)
+
+	w, err := spreadsheet.NewWriter(ctx.Writer, params.Format, "products")
+	if err == nil {
+		err = writeProducts(w, products, attributes, infoKeys)
+	}
+	if err != nil {
+		// Header đã gửi, chỉ còn cách ghi log và để client nhận file bị cắt
+		logging.From(ctx).Warn("product export failed", slog.String("error", err.Error()))
+		ctx.Abort()
+	}
+}
+
+// exportNDJSON đọc product từ repository theo batch và ghi ngay, không cần biết trước
+// danh sách cột như CSV / XLSX nên không phải tải cả catalog vào bộ nhớ
+func (p *ProductHandler) exportNDJSON(ctx *gin.Context) {
+	ctx.Header("Content-Disposition", `attachment; filename="products.ndjson"`)
+	products := p.repo.Scan(ctx.Request.Context(), utils.NDJSONBatchSize)
+	err := utils.RenderNDJSON(ctx, products, func(product models.Product) (any, error) {
+		return product, nil
+	})
+	if err != nil {
+		logging.From(ctx).Warn("product export failed", slog.String("error", err.Error()))
+		ctx.Abort()
+	}
+}
+
+func writeProducts(w spreadsheet.Writer, products []models.Product, attributes, infoKeys []string) error {
+	header := make([]any, 0, len(productColumns)+len(attributes)+2*len(infoKeys))
+	for _, column := range productColumns {
+		header = append(header, column)
+	}
+	for _, name := range attributes {
+		header = append(header, columnAttributePrefix+name)
+	}
+	for _, key := range infoKeys {
+		header = append(header, columnInfoPrefix+key+".info_key", columnInfoPrefix+key+".info_value")
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		metadata := ""
+		if len(product.ProductMetadata) > 0 {
+			data, err := json.Marshal(product.ProductMetadata)
+			if err != nil {
+				return err
+			}
+			metadata = string(data)
+		}
+
+		row := []any{
+			product.ID, product.Slug, product.Name, product.Price, product.Display,
+			product.ProductImage.ImageName, product.ProductImage.ImageLink,
+			strings.Join(product.Tag, listSeparator), metadata, product.Version,
+			product.CreatedAt.Format(time.RFC3339), product.UpdatedAt.Format(time.RFC3339),
+		}
+
+		values := make(map[string][]string)
+		for _, attr := range product.ProductAttribute {
+			values[attr.AttributeName] = append(values[attr.AttributeName], attr.AttributeValue)
+		}
+		for _, name := range attributes {
+			row = append(row, strings.Join(values[name], listSeparator))
+		}
+		for _, key := range infoKeys {
+			info := product.ProductInfo[key]
+			row = append(row, info.InfoKey, info.InfoValue)
+		}
+
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// flattenedColumns trả về tên attribute và key product_info có trong catalog, đã sắp xếp
+func flattenedColumns(products []models.Product) (attributes, infoKeys []string) {
+	seenAttributes := make(map[string]bool)
+	seenKeys := make(map[string]bool)
+	for _, product := range products {
+		for _, attr := range product.ProductAttribute {
+			if !seenAttributes[attr.AttributeName] {
+				seenAttributes[attr.AttributeName] = true
+				attributes = append(attributes, attr.AttributeName)
+			}
+		}
+		for key := range product.ProductInfo {
+			if !seenKeys[key] {
+				seenKeys[key] = true
+				infoKeys = append(infoKeys, key)
+			}
+		}
+	}
+
+	sort.Strings(attributes)
+	sort.Strings(infoKeys)
+	return attributes, infoKeys
+}
+
+// PostProductsImportV1 nhận file CSV/XLSX và import ở nền, trả 202 kèm Location để theo dõi.
+// dry_run=true chỉ validate, không tạo product.
+func (p *ProductHandler) PostProductsImportV1(ctx *gin.Context) {
+	var params PostProductsImportV1Param
+	if err := ctx.ShouldBind(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+			"file": utils.ValidationMessage("file", "required", ""),
+		}})
+		return
+	}
+
+	format, err := spreadsheet.FormatOf(fileHeader.Filename)
+	if err != nil {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+			"file": utils.ValidationMessage("file", "file_ext", "csv xlsx"),
+		}})
+		return
+	}
+
+	if fileHeader.Size > p.importLimits.MaxSize {
+		utils.Render(ctx, http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("File import không được lớn hơn %d bytes", p.importLimits.MaxSize),
+		})
+		return
+	}
+
+	// Đọc hết vào bộ nhớ vì file tạm của multipart bị xoá khi request kết thúc
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot read file"})
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot read file"})
+		return
+	}
+
+	job := p.importer.Start(ctx.Request.Context(), params.DryRun, format, func(jobCtx context.Context, job *imports.Job) error {
+		return p.importProducts(jobCtx, job, data)
+	})
+
+	ctx.Header("Location", ctx.FullPath()+"/"+job.ID())
+	utils.Render(ctx, http.StatusAccepted, gin.H{
+		"message": "Import started",
+		"job":     job.Summary(),
+	})
+}
+
+func (p *ProductHandler) GetProductsImportV1(ctx *gin.Context) {
+	var params GetProductsImportV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	job, ok := p.importer.Get(params.ID)
+	if !ok {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	summary := job.Summary()
+	resp := gin.H{"job": summary}
+	if summary.InvalidRows > 0 {
+		resp["report"] = strings.TrimSuffix(ctx.Request.URL.Path, "/") + "/report"
+	}
+	utils.Render(ctx, http.StatusOK, resp)
+}
+
+// GetProductsImportReportV1 tải file lỗi theo dòng, mặc định cùng định dạng với file đã import
+func (p *ProductHandler) GetProductsImportReportV1(ctx *gin.Context) {
+	var params GetProductsImportReportV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	job, ok := p.importer.Get(params.ID)
+	if !ok {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+	if params.Format == "" {
+		params.Format = job.Format()
+	}
+
+	data, err := spreadsheet.Bytes(params.Format, "errors", job.Report())
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot generate report"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="import-`+job.ID()+`-errors.`+params.Format+`"`)
+	ctx.Data(http.StatusOK, spreadsheet.ContentType(params.Format), data)
+}
+
+func (p *ProductHandler) importProducts(ctx context.Context, job *imports.Job, data []byte) error {
+	rows, err := spreadsheet.Read(bytes.NewReader(data), job.Format())
+	if err != nil {
+		return fmt.Errorf("cannot read %s file: %w", job.Format(), err)
+	}
+	if len(rows) == 0 {
+		return errors.New("file is empty")
+	}
+
+	header := rows[0]
+	rows = rows[1:]
+	if len(rows) > p.importLimits.MaxRows {
+		return fmt.Errorf("file has %d rows, at most %d are allowed", len(rows), p.importLimits.MaxRows)
+	}
+
+	var products []*models.Product
+	total := 0
+	for i, row := range rows {
+		if blankRow(row) {
+			continue
+		}
+		total++
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		params, errs := productFromRow(header, row)
+		if len(errs) == 0 {
+			errs = validateProductRow(&params)
+		}
+
+		// +2: dòng tiêu đề và số dòng của Excel bắt đầu từ 1
+		if len(errs) > 0 {
+			job.Invalid(i+2, errs)
+			continue
+		}
+
+		job.Valid()
+		product := toProductModel(params)
+		products = append(products, &product)
+	}
+	job.SetTotal(total)
+
+	if job.DryRun() || len(products) == 0 {
+		return nil
+	}
+	if err := p.repo.CreateMany(ctx, products); err != nil {
+		return err
+	}
+	job.SetCreated(len(products))
+	return nil
+}
+
+// validateProductRow dùng validator đã đăng ký (min_int, file_ext...) giống khi bind request
+func validateProductRow(params *PostProductsV1Param) map[string]string {
+	if err := binding.Validator.ValidateStruct(params); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			return utils.HandleValidationError(validationErrors)["error"].(map[string]string)
+		}
+		return map[string]string{"row": err.Error()}
+	}
+
+	if errBody := checkProduct(params); errBody != nil {
+		errs := make(map[string]string, len(errBody))
+		for field, message := range errBody {
+			errs[field] = fmt.Sprint(message)
+		}
+		return errs
+	}
+	return nil
+}
+
+// productFromRow chuyển 1 dòng về PostProductsV1Param, cột không biết thì bỏ qua.
+// Lỗi trả về là lỗi chuyển kiểu (price không phải số...), validate được làm sau.
+func productFromRow(header, row []string) (PostProductsV1Param, map[string]string) {
+	var params PostProductsV1Param
+	errs := make(map[string]string)
+	info := make(ProductInfoMap)
+
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		value := ""
+		if i < len(row) {
+			value = strings.TrimSpace(row[i])
+		}
+
+		switch {
+		case column == "name":
+			params.Name = value
+		case column == "price":
+			if value != "" {
+				price, err := strconv.Atoi(value)
+				if err != nil {
+					errs[column] = column + " phải là số nguyên"
+				}
+				params.Price = price
+			}
+		case column == "display":
+			if value != "" {
+				display, err := strconv.ParseBool(value)
+				if err != nil {
+					errs[column] = column + " phải là true hoặc false"
+				}
+				params.Display = &display
+			}
+		case column == "product_image.image_name":
+			params.ProductImage.ImageName = value
+		case column == "product_image.image_link":
+			params.ProductImage.ImageLink = value
+		case column == "tags":
+			params.Tag = splitList(value)
+		case column == "product_metadata":
+			if value != "" {
+				if err := json.Unmarshal([]byte(value), &params.ProductMetadata); err != nil {
+					errs[column] = column + " phải là JSON object"
+				}
+			}
+		case strings.HasPrefix(column, columnAttributePrefix):
+			name := strings.TrimPrefix(column, columnAttributePrefix)
+			for _, v := range splitList(value) {
+				params.ProductAttribute = append(params.ProductAttribute, ProductAttribute{AttributeName: name, AttributeValue: v})
+			}
+		case strings.HasPrefix(column, columnInfoPrefix) && value != "":
+			key, field, ok := cutLast(strings.TrimPrefix(column, columnInfoPrefix), ".")
+			if !ok {
+				continue
+			}
+			entry := info[key]
+			switch field {
+			case "info_key":
+				entry.InfoKey = value
+			case "info_value":
+				entry.InfoValue = value
+			default:
+				continue
+			}
+			info[key] = entry
+		}
+	}
+
+	// Để nil thay vì map rỗng để lỗi là "là bắt buộc" giống khi gửi JSON thiếu field
+	if len(info) > 0 {
+		params.ProductInfo = info
+	}
+	return params, errs
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func blankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
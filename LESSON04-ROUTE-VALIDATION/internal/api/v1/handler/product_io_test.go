@@ -0,0 +1,316 @@
+package v1handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/spreadsheet"
+)
+
+const infoKey = "4b3c2a3e-8a3c-4c9e-9f2e-1b2c3d4e5f60"
+
+// catalog có tên bắt đầu bằng "=" để kiểm tra chống CSV injection khi đọc lại,
+// attribute nhiều giá trị, metadata và display false
+var catalog = []string{
+	`{"name":"=Widget","price":200000,"display":true,"product_image":{"image_name":"w","image_link":"w.png"},` +
+		`"tags":["a","b","c","d"],"product_attribute":[{"attribute_name":"size","attribute_value":"S"},{"attribute_name":"size","attribute_value":"M"}],` +
+		`"product_info":{"` + infoKey + `":{"info_key":"origin","info_value":"VN"}},"product_metadata":{"color":"red"}}`,
+	`{"name":"Gadget","price":350000,"display":false,"product_image":{"image_name":"g","image_link":"g.png"},` +
+		`"tags":["e","f","g","h"],"product_attribute":[{"attribute_name":"color","attribute_value":"blue"}],` +
+		`"product_info":{"` + infoKey + `":{"info_key":"origin","info_value":"JP"}}}`,
+}
+
+func productIOEngine(t *testing.T, seed ...string) *gin.Engine {
+	t.Helper()
+
+	repo := repository.NewInMemoryProductRepository(nil)
+	var products []*models.Product
+	for _, body := range seed {
+		var params PostProductsV1Param
+		if err := json.Unmarshal([]byte(body), &params); err != nil {
+			t.Fatal(err)
+		}
+		product := toProductModel(params)
+		products = append(products, &product)
+	}
+	if len(products) > 0 {
+		if err := repo.CreateMany(context.Background(), products); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	importer := imports.NewManager(time.Hour)
+	t.Cleanup(func() { importer.Close(context.Background()) })
+
+	handler := NewProductHandler(repo, importer, ImportLimits{MaxSize: 1 << 20, MaxRows: 10})
+	r := gin.New()
+	r.GET("/api/v1/products/export", handler.GetProductsExportV1)
+	r.POST("/api/v1/products/import", handler.PostProductsImportV1)
+	r.GET("/api/v1/products/import/:id", handler.GetProductsImportV1)
+	r.GET("/api/v1/products/import/:id/report", handler.GetProductsImportReportV1)
+	return r
+}
+
+func get(t *testing.T, r *gin.Engine, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status = %d, body: %s", path, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func export(t *testing.T, r *gin.Engine, format string) []byte {
+	t.Helper()
+	w := get(t, r, "/api/v1/products/export?format="+format)
+	if got := w.Header().Get("Content-Type"); got != spreadsheet.ContentType(format) {
+		t.Errorf("Content-Type = %q", got)
+	}
+	return w.Body.Bytes()
+}
+
+type importResponse struct {
+	Job    imports.Summary `json:"job"`
+	Report string          `json:"report"`
+}
+
+// importFile upload file rồi chờ job chạy xong
+func importFile(t *testing.T, r *gin.Engine, filename string, data []byte, dryRun bool) importResponse {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if dryRun {
+		form.WriteField("dry_run", "true")
+	}
+	part, _ := form.CreateFormFile("file", filename)
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("import: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	location := w.Header().Get("Location")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var resp importResponse
+		if err := json.Unmarshal(get(t, r, location).Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Job.Status == imports.StatusSucceeded || resp.Job.Status == imports.StatusFailed {
+			return resp
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import job still %s", resp.Job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// withoutTimestamps bỏ created_at / updated_at, 2 cột duy nhất khác nhau giữa 2 lần tạo
+func withoutTimestamps(t *testing.T, data []byte, format string) [][]string {
+	t.Helper()
+	rows, err := spreadsheet.Read(bytes.NewReader(data), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skip := []int{slices.Index(rows[0], "created_at"), slices.Index(rows[0], "updated_at")}
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		for j, value := range row {
+			if !slices.Contains(skip, j) {
+				result[i] = append(result[i], value)
+			}
+		}
+	}
+	return result
+}
+
+func TestProductRoundTrip(t *testing.T) {
+	for _, format := range []string{spreadsheet.FormatCSV, spreadsheet.FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			exported := export(t, productIOEngine(t, catalog...), format)
+
+			target := productIOEngine(t)
+			resp := importFile(t, target, "products."+format, exported, false)
+			if resp.Job.Status != imports.StatusSucceeded || resp.Job.TotalRows != 2 || resp.Job.Created != 2 || resp.Job.InvalidRows != 0 {
+				t.Fatalf("import summary = %+v", resp.Job)
+			}
+
+			want := withoutTimestamps(t, exported, format)
+			got := withoutTimestamps(t, export(t, target, format), format)
+			if !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("re-exported rows differ\ngot:  %q\nwant: %q", got, want)
+			}
+			if name := want[1][slices.Index(want[0], "name")]; name != "=Widget" {
+				t.Errorf("name = %q, want the formula escape removed", name)
+			}
+		})
+	}
+}
+
+const importHeader = "name,price,display,product_image.image_name,product_image.image_link,tags,product_attribute.size," +
+	"product_info." + infoKey + ".info_key,product_info." + infoKey + ".info_value\n"
+
+func TestImportRowErrors(t *testing.T) {
+	file := importHeader +
+		"Widget,200000,true,w,w.png,a|b|c|d,S,origin,VN\n" +
+		"Priced,abc,maybe,p,p.png,a|b|c|d,S,origin,VN\n" +
+		",,,,,,,,\n" +
+		"ab,200000,,x,x.png,a|b,S,origin,VN\n" +
+		"Gadget,350000,,g,g.png,e|f|g|h,M,origin,JP\n"
+
+	t.Run("dry run", func(t *testing.T) {
+		r := productIOEngine(t)
+		resp := importFile(t, r, "products.csv", []byte(file), true)
+		if job := resp.Job; !job.DryRun || job.TotalRows != 4 || job.ValidRows != 2 || job.InvalidRows != 2 || job.Created != 0 {
+			t.Errorf("summary = %+v", job)
+		}
+		if rows := withoutTimestamps(t, export(t, r, spreadsheet.FormatCSV), spreadsheet.FormatCSV); len(rows) != 1 {
+			t.Errorf("dry run created %d products", len(rows)-1)
+		}
+	})
+
+	r := productIOEngine(t)
+	resp := importFile(t, r, "products.csv", []byte(file), false)
+	if job := resp.Job; job.Status != imports.StatusSucceeded || job.Created != 2 || job.InvalidRows != 2 {
+		t.Fatalf("summary = %+v", job)
+	}
+	if !strings.HasSuffix(resp.Report, "/report") {
+		t.Fatalf("report link = %q", resp.Report)
+	}
+
+	// Số dòng tính cả dòng tiêu đề, dòng trống không bị báo lỗi.
+	// Dòng 3 sai kiểu nên chưa được validate, dòng 5 đúng kiểu nhưng không qua validate.
+	// Lỗi validate đặt tên theo field của struct (Tag => tag) giống API JSON.
+	for _, format := range []string{"", spreadsheet.FormatXLSX} {
+		w := get(t, r, resp.Report+"?format="+format)
+		if format == "" {
+			format = spreadsheet.FormatCSV
+		}
+		rows, err := spreadsheet.Read(w.Body, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, row := range rows[1:] {
+			got = append(got, row[0]+":"+row[1])
+		}
+		if want := []string{"3:display", "3:price", "5:name", "5:tag"}; !slices.Equal(got, want) {
+			t.Errorf("%s report = %v, want %v", format, got, want)
+		}
+	}
+}
+
+func TestImportHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		status  imports.Status
+		invalid int
+		created int
+		// errors: field bị báo lỗi ở dòng đầu tiên
+		errors []string
+	}{
+		{
+			name:    "reordered and unknown columns",
+			file:    "notes,tags,price,name,product_image.image_link,product_image.image_name,product_info." + infoKey + ".info_value,product_info." + infoKey + ".info_key,product_attribute.size\nhello,a|b|c|d,200000,Widget,w.png,w,VN,origin,S\n",
+			status:  imports.StatusSucceeded,
+			created: 1,
+		},
+		{
+			name:    "missing required columns",
+			file:    "title,cost\nWidget,200000\nGadget,350000\n",
+			status:  imports.StatusSucceeded,
+			invalid: 2,
+			errors:  []string{"name", "price", "product_attribute", "product_image.image_link", "product_image.image_name", "product_info", "tag"},
+		},
+		{
+			name:    "only header",
+			file:    importHeader,
+			status:  imports.StatusSucceeded,
+			invalid: 0,
+		},
+		{
+			name:   "empty file",
+			file:   "",
+			status: imports.StatusFailed,
+		},
+		{
+			name:   "too many rows",
+			file:   importHeader + strings.Repeat("Widget,200000,true,w,w.png,a|b|c|d,S,origin,VN\n", 11),
+			status: imports.StatusFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := productIOEngine(t)
+			resp := importFile(t, r, "products.csv", []byte(tt.file), false)
+			if job := resp.Job; job.Status != tt.status || job.InvalidRows != tt.invalid || job.Created != tt.created {
+				t.Fatalf("summary = %+v", job)
+			}
+			if tt.status == imports.StatusFailed && resp.Job.Error == "" {
+				t.Error("failed job without error")
+			}
+			if tt.errors == nil {
+				return
+			}
+
+			rows, err := spreadsheet.Read(get(t, r, resp.Report).Body, spreadsheet.FormatCSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, row := range rows[1:] {
+				if row[0] == "2" {
+					fields = append(fields, row[1])
+				}
+			}
+			if !slices.Equal(fields, tt.errors) {
+				t.Errorf("row 2 errors = %v, want %v", fields, tt.errors)
+			}
+		})
+	}
+}
+
+func TestImportFileValidation(t *testing.T) {
+	r := productIOEngine(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "products.txt")
+	part.Write([]byte(importHeader))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"file"`) {
+		t.Errorf("unsupported extension: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/import/4b3c2a3e-8a3c-4c9e-9f2e-1b2c3d4e5f61", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown job: status = %d", w.Code)
+	}
+}
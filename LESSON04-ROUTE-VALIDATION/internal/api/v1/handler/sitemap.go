@@ -0,0 +1,77 @@
+package v1handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/sitemap"
+	"mamba.com/route-group/utils"
+)
+
+type SitemapHandler struct {
+	generator *sitemap.Generator
+}
+
+func NewSitemapHandler(generator *sitemap.Generator) *SitemapHandler {
+	return &SitemapHandler{generator: generator}
+}
+
+// GetSitemapIndexV1 phục vụ /sitemap.xml và /sitemap.xml.gz
+func (s *SitemapHandler) GetSitemapIndexV1(ctx *gin.Context) {
+	body, err := s.generator.Index(ctx.Request.Context(), requestBaseURL(ctx))
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot generate sitemap"})
+		return
+	}
+
+	writeSitemap(ctx, body, strings.HasSuffix(ctx.Request.URL.Path, ".gz"))
+}
+
+// GetSitemapShardV1 phục vụ /sitemaps/:file, VD: products-1.xml hoặc products-1.xml.gz
+func (s *SitemapHandler) GetSitemapShardV1(ctx *gin.Context) {
+	file := ctx.Param("file")
+	gz := strings.HasSuffix(file, ".gz")
+	file = strings.TrimSuffix(file, ".gz")
+
+	if !strings.HasSuffix(file, ".xml") {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	shard, err := s.generator.Shard(ctx.Request.Context(), requestBaseURL(ctx), file)
+	if errors.Is(err, sitemap.ErrShardNotFound) {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	body, err := shard.XML()
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot generate sitemap"})
+		return
+	}
+
+	writeSitemap(ctx, body, gz)
+}
+
+func writeSitemap(ctx *gin.Context, body []byte, gz bool) {
+	contentType := "application/xml; charset=utf-8"
+	if gz {
+		compressed, err := sitemap.Gzip(body)
+		if err != nil {
+			utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot compress sitemap"})
+			return
+		}
+		body = compressed
+		contentType = "application/gzip"
+	}
+
+	if utils.CheckNotModified(ctx, utils.ETag(body), time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(http.StatusOK, contentType, body)
+}
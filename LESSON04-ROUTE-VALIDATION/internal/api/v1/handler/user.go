@@ -1,13 +1,21 @@
 package v1handler
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/api/adapter"
+	"mamba.com/route-group/internal/bulk"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/service"
 	"mamba.com/route-group/utils"
 )
 
 type UserHandler struct {
+	service *service.UserService
 }
 
 type GetUsersByIdV1Param struct {
@@ -18,27 +26,86 @@ type GetUsersByUuidV1Param struct {
 	Uuid string `uri:"uuid" binding:"uuid"`
 }
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+type PostUsersV1Param struct {
+	Name  string `json:"name" xml:"name" binding:"required,min=3,max=100"`
+	Email string `json:"email" xml:"email" binding:"required,email"`
+}
+
+// PostUsersBulkV1Param: mỗi phần tử được validate như PostUsersV1Param
+type PostUsersBulkV1Param struct {
+	Mode  bulk.Mode          `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PostUsersV1Param `json:"items" xml:"items>user" binding:"required,gt=0"`
+}
+
+type PutUsersV1Param struct {
+	Name  string `json:"name" xml:"name" binding:"omitempty,min=3,max=100"`
+	Email string `json:"email" xml:"email" binding:"omitempty,email"`
+}
+
+// PutUsersBulkV1Item là PutUsersV1Param kèm user cần sửa
+type PutUsersBulkV1Item struct {
+	BulkItemV1Param
+	PutUsersV1Param
+}
+
+type PutUsersBulkV1Param struct {
+	Mode  bulk.Mode            `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []PutUsersBulkV1Item `json:"items" xml:"items>user" binding:"required,gt=0"`
+}
+
+type DeleteUsersBulkV1Param struct {
+	Mode  bulk.Mode         `json:"mode" xml:"mode" binding:"omitempty,oneof=atomic partial"`
+	Items []BulkItemV1Param `json:"items" xml:"items>user" binding:"required,gt=0"`
+}
+
+func NewUserHandler(service *service.UserService) *UserHandler {
+	return &UserHandler{service: service}
 }
 
 // User API
 
+// GetUsersV1 trả NDJSON (mỗi dòng 1 user) khi client gửi Accept: application/x-ndjson
 func (u *UserHandler) GetUsersV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "List all user (v1)"})
+	if utils.AcceptsNDJSON(ctx) {
+		users := u.service.Scan(ctx.Request.Context(), utils.NDJSONBatchSize)
+		err := utils.RenderNDJSON(ctx, users, func(user models.User) (any, error) {
+			return adapter.ToUserV1(user), nil
+		})
+		if err != nil {
+			logging.From(ctx).Warn("user list stream failed", slog.String("error", err.Error()))
+			ctx.Abort()
+		}
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "List all user (v1)",
+		"users":   adapter.ToUsersV1(u.service.List(ctx.Request.Context())),
+	})
 }
 
 func (u *UserHandler) GetUsersByIdV1(ctx *gin.Context) {
 
 	var params GetUsersByIdV1Param
 	if err := ctx.ShouldBindUri(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	user, err := u.service.GetByID(ctx.Request.Context(), params.ID)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	if utils.NotModified(ctx, adapter.UserETag(*user)) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Get user by ID (v1)",
 		"user_id": params.ID,
+		"user":    adapter.ToUserV1(*user),
 	})
 
 	// idStr := ctx.Param("id")
@@ -60,14 +127,25 @@ func (u *UserHandler) GetUsersByUuidV1(ctx *gin.Context) {
 
 	var params GetUsersByUuidV1Param
 	if err := ctx.ShouldBindUri(&params); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.HandleValidationError(err))
+		utils.RenderValidationError(ctx, err)
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	user, err := u.service.GetByUUID(ctx.Request.Context(), params.Uuid)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	if utils.NotModified(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
 		"message": "Get user by UUID (v1)",
 		"user_id": params.Uuid,
+		"user":    adapter.ToUserV1(*user),
 	})
 
 	// uuidStr := ctx.Param("uuid")
@@ -86,13 +164,260 @@ func (u *UserHandler) GetUsersByUuidV1(ctx *gin.Context) {
 }
 
 func (u *UserHandler) PostUsersV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusCreated, gin.H{"message": "Create User (v1)"})
+	var params PostUsersV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	user, err := u.service.Create(ctx.Request.Context(), service.UserInput{Name: &params.Name, Email: &params.Email})
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusCreated, gin.H{
+		"message": "Create User (v1)",
+		"user":    adapter.ToUserV1(*user),
+	})
+}
+
+func (u *UserHandler) PostUsersBulkV1(ctx *gin.Context) {
+	var params PostUsersBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	if !batch.Rejected() {
+		pending := batch.Pending()
+		inputs := make([]service.UserInput, len(pending))
+		for j, i := range pending {
+			inputs[j] = service.UserInput{Name: &batch.Items[i].Name, Email: &batch.Items[i].Email}
+		}
+
+		// atomic mà có input lỗi thì users toàn nil, input hợp lệ còn lại được Render đánh dấu 424
+		users, errs := u.service.CreateMany(ctx.Request.Context(), inputs, batch.Atomic())
+		for j, i := range pending {
+			switch {
+			case errs[j] != nil:
+				status, errBody := userError(errs[j])
+				batch.Fail(i, status, errBody)
+			case users[j] != nil:
+				batch.Succeed(i, http.StatusCreated, adapter.ToUserV1(*users[j]))
+			}
+		}
+	}
+
+	batch.Render(http.StatusCreated, "Bulk create users (v1)")
 }
 
 func (u *UserHandler) PutUsersByIdV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "Update User By ID (v1)"})
+	var uri GetUsersByIdV1Param
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	var params PutUsersV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	user, err := u.service.GetByID(ctx.Request.Context(), uri.ID)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	input := service.UserInput{}
+	if params.Name != "" {
+		input.Name = &params.Name
+	}
+	if params.Email != "" {
+		input.Email = &params.Email
+	}
+
+	user, err = u.service.Update(ctx.Request.Context(), user, input)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", adapter.UserETag(*user))
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Update User By ID (v1)",
+		"user":    adapter.ToUserV1(*user),
+	})
+}
+
+func (u *UserHandler) PutUsersBulkV1(ctx *gin.Context) {
+	var params PutUsersBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending []int
+		users   []*models.User
+		inputs  []service.UserInput
+	)
+	for _, i := range batch.Pending() {
+		item := &batch.Items[i]
+		user, status, errBody := u.bulkUser(ctx, item.BulkItemV1Param)
+		if status != 0 {
+			batch.Fail(i, status, errBody)
+			continue
+		}
+
+		input := service.UserInput{}
+		if item.Name != "" {
+			input.Name = &item.Name
+		}
+		if item.Email != "" {
+			input.Email = &item.Email
+		}
+		pending = append(pending, i)
+		users = append(users, user)
+		inputs = append(inputs, input)
+	}
+
+	if !batch.Rejected() {
+		updated, errs := u.service.UpdateMany(ctx.Request.Context(), users, inputs, batch.Atomic())
+		for j, i := range pending {
+			switch {
+			case errs[j] != nil:
+				status, errBody := userError(errs[j])
+				batch.Fail(i, status, errBody)
+			case updated[j] != nil:
+				batch.Succeed(i, http.StatusOK, adapter.ToUserV1(*updated[j]))
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk update users (v1)")
+}
+
+func (u *UserHandler) DeleteUsersBulkV1(ctx *gin.Context) {
+	var params DeleteUsersBulkV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	batch, ok := bulk.New(ctx, params.Mode, params.Items)
+	if !ok {
+		return
+	}
+
+	var (
+		pending []int
+		users   []*models.User
+	)
+	for _, i := range batch.Pending() {
+		user, status, errBody := u.bulkUser(ctx, batch.Items[i])
+		if status != 0 {
+			batch.Fail(i, status, errBody)
+			continue
+		}
+		pending = append(pending, i)
+		users = append(users, user)
+	}
+
+	if !batch.Rejected() {
+		errs := u.service.DeleteMany(ctx.Request.Context(), users, batch.Atomic())
+		for j, i := range pending {
+			if errs[j] != nil {
+				status, errBody := userError(errs[j])
+				batch.Fail(i, status, errBody)
+			}
+		}
+		// atomic có phần tử lỗi thì không user nào bị xoá, Render đánh dấu 424
+		if !batch.Rejected() {
+			for j, i := range pending {
+				if errs[j] == nil {
+					batch.Succeed(i, http.StatusNoContent, nil)
+				}
+			}
+		}
+	}
+
+	batch.Render(http.StatusOK, "Bulk delete users (v1)")
+}
+
+// bulkUser đọc user của 1 phần tử bulk và kiểm tra if_match, status khác 0 là lỗi của phần tử
+func (u *UserHandler) bulkUser(ctx *gin.Context, item BulkItemV1Param) (*models.User, int, any) {
+	user, err := u.service.GetByID(ctx.Request.Context(), item.ID)
+	if err != nil {
+		status, errBody := userError(err)
+		return nil, status, errBody
+	}
+
+	status, errBody := checkBulkItem(item, adapter.UserETag(*user))
+	return user, status, errBody
 }
 
 func (u *UserHandler) DeleteUsersByIdV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusNoContent, gin.H{"message": "Delete User By ID (v1)"})
+	var uri GetUsersByIdV1Param
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	user, err := u.service.GetByID(ctx.Request.Context(), uri.ID)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	if err := u.service.Delete(ctx.Request.Context(), user); err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusNoContent, gin.H{"message": "Delete User By ID (v1)"})
+}
+
+func renderUserError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrUserModified) {
+		utils.RenderPreconditionFailed(ctx, "")
+		return
+	}
+
+	status, errBody := userError(err)
+	utils.Render(ctx, status, gin.H{"error": errBody})
+}
+
+// userError trả về status và phần "error" của body cho lỗi từ UserService
+func userError(err error) (int, any) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound, "User not found"
+	case errors.Is(err, service.ErrEmailExists):
+		return http.StatusConflict, gin.H{"email": "email đã tồn tại"}
+	case errors.Is(err, service.ErrUserModified):
+		return http.StatusPreconditionFailed, utils.ModifiedMessage
+	default:
+		return http.StatusInternalServerError, "Internal server error"
+	}
 }
@@ -0,0 +1,261 @@
+package v1handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/webhooks"
+	"mamba.com/route-group/utils"
+)
+
+type WebhookHandler struct {
+	repo   repository.WebhookRepository
+	events *webhooks.Dispatcher
+}
+
+type GetWebhookByIdV1Param struct {
+	ID string `uri:"id" binding:"uuid"`
+}
+
+type GetWebhookDeliveryV1Param struct {
+	ID         string `uri:"id" binding:"uuid"`
+	DeliveryID string `uri:"delivery_id" binding:"uuid"`
+}
+
+type PostWebhooksV1Param struct {
+	URL         string   `json:"url" xml:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" xml:"events>event" binding:"required,gt=0,dive,oneof=* product.created product.updated product.deleted news.published user.created user.updated user.deleted"`
+	Description string   `json:"description" xml:"description" binding:"omitempty,max=255"`
+	// Secret bỏ trống thì server tự sinh
+	Secret string `json:"secret" xml:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// PatchWebhookByIdV1Param: field nil thì giữ nguyên. active = true bật lại subscription
+// đã bị tắt và xoá số lần lỗi.
+type PatchWebhookByIdV1Param struct {
+	URL         *string   `json:"url" xml:"url" binding:"omitempty,url,max=2048"`
+	Events      *[]string `json:"events" xml:"events>event" binding:"omitempty,gt=0,dive,oneof=* product.created product.updated product.deleted news.published user.created user.updated user.deleted"`
+	Description *string   `json:"description" xml:"description" binding:"omitempty,max=255"`
+	Active      *bool     `json:"active" xml:"active"`
+}
+
+func NewWebhookHandler(repo repository.WebhookRepository, events *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{repo: repo, events: events}
+}
+
+func (w *WebhookHandler) GetWebhooksV1(ctx *gin.Context) {
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message":  "Get Webhooks (v1)",
+		"webhooks": w.repo.ListSubscriptions(ctx.Request.Context()),
+	})
+}
+
+// PostWebhooksV1 trả secret đúng 1 lần, đối tác phải lưu lại để kiểm tra chữ ký
+func (w *WebhookHandler) PostWebhooksV1(ctx *gin.Context) {
+	var params PostWebhooksV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+	if !webhookURL(ctx, params.URL) {
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		URL:         params.URL,
+		Events:      params.Events,
+		Description: params.Description,
+		Secret:      params.Secret,
+		Active:      true,
+	}
+	if sub.Secret == "" {
+		sub.Secret = webhooks.NewSecret()
+	}
+	if err := w.repo.CreateSubscription(ctx.Request.Context(), &sub); err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.Header("Location", ctx.FullPath()+"/"+sub.ID)
+	utils.Render(ctx, http.StatusCreated, gin.H{
+		"message": "Create Webhook (v1)",
+		"webhook": sub,
+		"secret":  sub.Secret,
+	})
+}
+
+func (w *WebhookHandler) GetWebhookByIdV1(ctx *gin.Context) {
+	sub, ok := w.findSubscription(ctx)
+	if !ok {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Get Webhook By ID (v1)",
+		"webhook": sub,
+	})
+}
+
+func (w *WebhookHandler) PatchWebhookByIdV1(ctx *gin.Context) {
+	sub, ok := w.findSubscription(ctx)
+	if !ok {
+		return
+	}
+
+	var params PatchWebhookByIdV1Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	if params.URL != nil {
+		if !webhookURL(ctx, *params.URL) {
+			return
+		}
+		sub.URL = *params.URL
+	}
+	if params.Events != nil {
+		sub.Events = *params.Events
+	}
+	if params.Description != nil {
+		sub.Description = *params.Description
+	}
+	if params.Active != nil {
+		if *params.Active && !sub.Active {
+			sub.FailureCount = 0
+			sub.DisabledAt = nil
+			sub.DisabledReason = ""
+		}
+		sub.Active = *params.Active
+	}
+
+	if err := w.repo.UpdateSubscription(ctx.Request.Context(), sub); err != nil {
+		renderWebhookError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message": "Update Webhook By ID (v1)",
+		"webhook": sub,
+	})
+}
+
+func (w *WebhookHandler) DeleteWebhookByIdV1(ctx *gin.Context) {
+	var params GetWebhookByIdV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	if err := w.repo.DeleteSubscription(ctx.Request.Context(), params.ID); err != nil {
+		renderWebhookError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusNoContent, gin.H{"message": "Delete Webhook By ID (v1)"})
+}
+
+// GetWebhookDeliveriesV1 trả log delivery mới nhất trước
+func (w *WebhookHandler) GetWebhookDeliveriesV1(ctx *gin.Context) {
+	sub, ok := w.findSubscription(ctx)
+	if !ok {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message":    "Get Webhook Deliveries (v1)",
+		"deliveries": w.repo.ListDeliveries(ctx.Request.Context(), sub.ID),
+	})
+}
+
+func (w *WebhookHandler) GetWebhookDeliveryByIdV1(ctx *gin.Context) {
+	delivery, ok := w.findDelivery(ctx)
+	if !ok {
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"message":  "Get Webhook Delivery By ID (v1)",
+		"delivery": delivery,
+	})
+}
+
+// PostWebhookRedeliverV1 gửi lại cùng payload (cùng event id) trong 1 delivery mới
+func (w *WebhookHandler) PostWebhookRedeliverV1(ctx *gin.Context) {
+	delivery, ok := w.findDelivery(ctx)
+	if !ok {
+		return
+	}
+
+	// Subscription bị tắt thì delivery mới cũng fail ngay, phải bật lại trước
+	if sub, ok := w.repo.FindSubscription(ctx.Request.Context(), delivery.SubscriptionID); !ok || !sub.Active {
+		utils.Render(ctx, http.StatusConflict, gin.H{"error": "Webhook is disabled"})
+		return
+	}
+
+	redelivery, err := w.events.Redeliver(ctx.Request.Context(), delivery)
+	if err != nil {
+		renderWebhookError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusAccepted, gin.H{
+		"message":  "Redeliver Webhook (v1)",
+		"delivery": redelivery,
+	})
+}
+
+func (w *WebhookHandler) findSubscription(ctx *gin.Context) (*models.WebhookSubscription, bool) {
+	var params GetWebhookByIdV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return nil, false
+	}
+
+	sub, ok := w.repo.FindSubscription(ctx.Request.Context(), params.ID)
+	if !ok {
+		renderWebhookError(ctx, repository.ErrNotFound)
+		return nil, false
+	}
+	return sub, true
+}
+
+func (w *WebhookHandler) findDelivery(ctx *gin.Context) (*models.WebhookDelivery, bool) {
+	var params GetWebhookDeliveryV1Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return nil, false
+	}
+
+	delivery, ok := w.repo.FindDelivery(ctx.Request.Context(), params.ID, params.DeliveryID)
+	if !ok {
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+		return nil, false
+	}
+	return delivery, true
+}
+
+// webhookURL: tag url nhận cả ftp://, mailto:... nên kiểm tra thêm scheme
+func webhookURL(ctx *gin.Context, raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+			"url": "url phải là địa chỉ http hoặc https",
+		}})
+		return false
+	}
+	return true
+}
+
+func renderWebhookError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	default:
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
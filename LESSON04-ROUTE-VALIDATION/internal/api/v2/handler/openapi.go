@@ -0,0 +1,30 @@
+package v2handler
+
+import (
+	"net/http"
+
+	"mamba.com/route-group/internal/api/adapter"
+	"mamba.com/route-group/internal/openapi"
+)
+
+// DescribeOpenAPI khai báo các route v2 để sinh OpenAPI spec
+func DescribeOpenAPI(g *openapi.Generator) {
+	g.Describe(
+		openapi.Route{Method: http.MethodGet, Path: "/api/v2/users", Summary: "List all user (NDJSON with Accept: application/x-ndjson)", Input: GetUsersV2Param{}, Output: UserListV2Response{}, Stream: adapter.UserV2{}},
+		openapi.Route{Method: http.MethodGet, Path: "/api/v2/users/:uuid", Summary: "Get user by UUID", Input: getUserByUuidV2Input{}, Output: UserV2Response{}, Errors: []int{http.StatusNotFound}, Responses: []int{http.StatusNotModified}},
+		openapi.Route{Method: http.MethodPost, Path: "/api/v2/users", Summary: "Create user", Input: PostUsersV2Param{}, Output: UserV2Response{}, Errors: []int{http.StatusConflict}},
+		openapi.Route{Method: http.MethodPut, Path: "/api/v2/users/:uuid", Summary: "Update user", Input: putUserByUuidV2Input{}, Output: UserV2Response{}, Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+		openapi.Route{Method: http.MethodDelete, Path: "/api/v2/users/:uuid", Summary: "Delete user", Input: GetUsersByUuidV2Param{}, Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}},
+	)
+}
+
+// Các route vừa có path param vừa có query/body, gộp lại để generator đọc 1 lần
+type getUserByUuidV2Input struct {
+	GetUsersByUuidV2Param
+	GetUsersV2Param
+}
+
+type putUserByUuidV2Input struct {
+	GetUsersByUuidV2Param
+	PutUsersV2Param
+}
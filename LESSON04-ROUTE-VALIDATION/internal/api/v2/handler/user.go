@@ -1,36 +1,248 @@
 package v2handler
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/api/adapter"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/service"
+	"mamba.com/route-group/utils"
 )
 
 type UserHandler struct {
+	service *service.UserService
 }
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+type GetUsersByUuidV2Param struct {
+	Uuid string `uri:"uuid" binding:"uuid"`
+}
+
+type GetUsersV2Param struct {
+	Fields string `form:"fields" binding:"omitempty"`
+}
+
+type UserProfileV2Param struct {
+	Bio     string `json:"bio" xml:"bio" binding:"omitempty,max=500"`
+	Phone   string `json:"phone" xml:"phone" binding:"omitempty,min=9,max=15"`
+	Address string `json:"address" xml:"address" binding:"omitempty,max=255"`
+}
+
+type PostUsersV2Param struct {
+	Name    string             `json:"name" xml:"name" binding:"required,min=3,max=100"`
+	Email   string             `json:"email" xml:"email" binding:"required,email"`
+	Profile UserProfileV2Param `json:"profile" xml:"profile"`
+	Avatar  string             `json:"avatar" xml:"avatar" binding:"omitempty,url"`
+}
+
+// PutUsersV2Param: field nào không gửi (nil) thì giữ nguyên
+type PutUsersV2Param struct {
+	Name    *string             `json:"name" xml:"name" binding:"omitempty,min=3,max=100"`
+	Email   *string             `json:"email" xml:"email" binding:"omitempty,email"`
+	Profile *UserProfileV2Param `json:"profile" xml:"profile"`
+	Avatar  *string             `json:"avatar" xml:"avatar" binding:"omitempty,url"`
+}
+
+// UserV2Response là body của các API trả về 1 user
+type UserV2Response struct {
+	Data adapter.UserV2 `json:"data"`
+}
+
+// UserListV2Response là body của GET /api/v2/users
+type UserListV2Response struct {
+	Data []adapter.UserV2 `json:"data"`
+	Meta ListMetaV2       `json:"meta"`
+}
+
+type ListMetaV2 struct {
+	Total int `json:"total"`
+}
+
+func NewUserHandler(service *service.UserService) *UserHandler {
+	return &UserHandler{service: service}
 }
 
 // User API
 
 func (u *UserHandler) GetUsersV2(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "List all user (v1)"})
+	fields, ok := bindFields(ctx)
+	if !ok {
+		return
+	}
+
+	// NDJSON không có meta.total: mỗi dòng là 1 phần tử của data
+	if utils.AcceptsNDJSON(ctx) {
+		users := u.service.Scan(ctx.Request.Context(), utils.NDJSONBatchSize)
+		err := utils.RenderNDJSON(ctx, users, func(user models.User) (any, error) {
+			return adapter.SelectFields(adapter.ToUserV2(user), fields)
+		})
+		if err != nil {
+			logging.From(ctx).Warn("user list stream failed", slog.String("error", err.Error()))
+			ctx.Abort()
+		}
+		return
+	}
+
+	users := u.service.List(ctx.Request.Context())
+	data := make([]any, 0, len(users))
+	for _, user := range users {
+		selected, err := adapter.SelectFields(adapter.ToUserV2(user), fields)
+		if err != nil {
+			utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		data = append(data, selected)
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"data": data,
+		"meta": ListMetaV2{Total: len(users)},
+	})
 }
 
-func (u *UserHandler) GetUsersByIdV2(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "Get User By ID (v1)"})
+func (u *UserHandler) GetUsersByUuidV2(ctx *gin.Context) {
+	fields, ok := bindFields(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := u.findUser(ctx)
+	if !ok {
+		return
+	}
+
+	if utils.NotModified(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	selected, err := adapter.SelectFields(adapter.ToUserV2(*user), fields)
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{"data": selected})
 }
 
 func (u *UserHandler) PostUsersV2(ctx *gin.Context) {
-	ctx.JSON(http.StatusCreated, gin.H{"message": "Create User (v1)"})
+	var params PostUsersV2Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	profile := adapter.UserProfileV2(params.Profile).ToModel()
+	user, err := u.service.Create(ctx.Request.Context(), service.UserInput{
+		Name:    &params.Name,
+		Email:   &params.Email,
+		Profile: &profile,
+		Avatar:  &params.Avatar,
+	})
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	utils.Render(ctx, http.StatusCreated, UserV2Response{Data: adapter.ToUserV2(*user)})
 }
 
-func (u *UserHandler) PutUsersByIdV2(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"message": "Update User By ID (v1)"})
+func (u *UserHandler) PutUsersByUuidV2(ctx *gin.Context) {
+	user, ok := u.findUser(ctx)
+	if !ok {
+		return
+	}
+
+	var params PutUsersV2Param
+	if err := utils.BindBody(ctx, &params); err != nil {
+		utils.RenderBindError(ctx, err)
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	input := service.UserInput{Name: params.Name, Email: params.Email, Avatar: params.Avatar}
+	if params.Profile != nil {
+		profile := adapter.UserProfileV2(*params.Profile).ToModel()
+		input.Profile = &profile
+	}
+
+	user, err := u.service.Update(ctx.Request.Context(), user, input)
+	if err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", adapter.UserETag(*user))
+	utils.Render(ctx, http.StatusOK, UserV2Response{Data: adapter.ToUserV2(*user)})
+}
+
+func (u *UserHandler) DeleteUsersByUuidV2(ctx *gin.Context) {
+	user, ok := u.findUser(ctx)
+	if !ok {
+		return
+	}
+
+	if !utils.CheckPrecondition(ctx, adapter.UserETag(*user)) {
+		return
+	}
+
+	if err := u.service.Delete(ctx.Request.Context(), user); err != nil {
+		renderUserError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (u *UserHandler) findUser(ctx *gin.Context) (*models.User, bool) {
+	var params GetUsersByUuidV2Param
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return nil, false
+	}
+
+	user, err := u.service.GetByUUID(ctx.Request.Context(), params.Uuid)
+	if err != nil {
+		renderUserError(ctx, err)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func bindFields(ctx *gin.Context) ([]string, bool) {
+	var params GetUsersV2Param
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return nil, false
+	}
+
+	fields, err := adapter.ParseFields(params.Fields)
+	if err != nil {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+			"fields": utils.ValidationMessage("fields", "oneof", strings.Join(adapter.UserV2Fields, " ")),
+		}})
+		return nil, false
+	}
+
+	return fields, true
 }
 
-func (u *UserHandler) DeleteUsersByIdV2(ctx *gin.Context) {
-	ctx.JSON(http.StatusNoContent, gin.H{"message": "Delete User By ID (v1)"})
+func renderUserError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserModified):
+		utils.RenderPreconditionFailed(ctx, "")
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, service.ErrEmailExists):
+		utils.Render(ctx, http.StatusConflict, gin.H{"error": gin.H{"email": "email đã tồn tại"}})
+	default:
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
 }
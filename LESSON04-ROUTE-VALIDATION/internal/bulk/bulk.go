@@ -0,0 +1,154 @@
+package bulk
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"mamba.com/route-group/utils"
+)
+
+// Mode của API bulk, rỗng là ModeAtomic
+type Mode string
+
+const (
+	// ModeAtomic: 1 phần tử lỗi thì không ghi phần tử nào
+	ModeAtomic Mode = "atomic"
+	// ModePartial: ghi các phần tử hợp lệ, trả 207 kèm kết quả từng phần tử
+	ModePartial Mode = "partial"
+)
+
+var maxItems atomic.Int64
+
+func init() {
+	SetMaxItems(100)
+}
+
+// SetMaxItems đặt số phần tử tối đa của 1 request bulk, đổi được lúc chạy (config reload)
+func SetMaxItems(n int) {
+	maxItems.Store(int64(n))
+}
+
+// Result là kết quả của 1 phần tử, Error cùng định dạng với "error" của API đơn lẻ
+type Result struct {
+	Index  int `json:"index"`
+	Status int `json:"status"`
+	Data   any `json:"data,omitempty"`
+	Error  any `json:"error,omitempty"`
+}
+
+// Batch giữ kết quả của từng phần tử trong 1 request bulk
+type Batch[T any] struct {
+	ctx     *gin.Context
+	mode    Mode
+	Items   []T
+	results []*Result
+}
+
+// New kiểm tra số phần tử và validate từng phần tử bằng binding tag của struct bind đơn lẻ.
+// Trả về false khi đã render 400 (quá số phần tử cho phép).
+func New[T any](ctx *gin.Context, mode Mode, items []T) (*Batch[T], bool) {
+	if max := int(maxItems.Load()); len(items) > max {
+		utils.Render(ctx, http.StatusBadRequest, gin.H{"error": gin.H{
+			"items": utils.ValidationMessage("items", "lte", strconv.Itoa(max)),
+		}})
+		return nil, false
+	}
+
+	if mode == "" {
+		mode = ModeAtomic
+	}
+
+	b := &Batch[T]{ctx: ctx, mode: mode, Items: items, results: make([]*Result, len(items))}
+	for i := range items {
+		if err := binding.Validator.ValidateStruct(&items[i]); err != nil {
+			b.Fail(i, http.StatusBadRequest, utils.ItemValidationError(ctx, err))
+		}
+	}
+	return b, true
+}
+
+// Fail đánh dấu phần tử i lỗi, VD: lỗi nghiệp vụ mà binding tag không kiểm tra được
+func (b *Batch[T]) Fail(i, status int, err any) {
+	b.results[i] = &Result{Index: i, Status: status, Error: err}
+}
+
+// Succeed đánh dấu phần tử i đã được tạo/sửa/xoá
+func (b *Batch[T]) Succeed(i, status int, data any) {
+	b.results[i] = &Result{Index: i, Status: status, Data: data}
+}
+
+// Pending trả về index các phần tử chưa có kết quả (chưa lỗi)
+func (b *Batch[T]) Pending() []int {
+	var pending []int
+	for i, result := range b.results {
+		if result == nil {
+			pending = append(pending, i)
+		}
+	}
+	return pending
+}
+
+// Atomic cho biết phải huỷ cả request khi có 1 phần tử lỗi
+func (b *Batch[T]) Atomic() bool {
+	return b.mode == ModeAtomic
+}
+
+// Rejected: request atomic đã có phần tử lỗi, không được ghi gì
+func (b *Batch[T]) Rejected() bool {
+	return b.Atomic() && b.failed() > 0
+}
+
+func (b *Batch[T]) failed() int {
+	n := 0
+	for _, result := range b.results {
+		if result != nil && result.Error != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Render trả response của cả request:
+//   - atomic thành công: status (201 khi tạo, 200 khi sửa/xoá)
+//   - atomic bị huỷ: status của phần tử lỗi đầu tiên, các phần tử hợp lệ có status 424
+//   - partial: 207 Multi-Status
+func (b *Batch[T]) Render(status int, message string) {
+	if b.Rejected() {
+		status = 0
+		for i, result := range b.results {
+			if result == nil {
+				b.Fail(i, http.StatusFailedDependency, "Không được ghi vì phần tử khác bị lỗi")
+			} else if status == 0 && result.Status != http.StatusFailedDependency {
+				status = result.Status
+			}
+		}
+
+		utils.Render(b.ctx, status, gin.H{
+			"error":   "Không có phần tử nào được ghi vì có phần tử bị lỗi",
+			"mode":    b.mode,
+			"results": b.results,
+		})
+		return
+	}
+
+	if b.Atomic() {
+		utils.Render(b.ctx, status, gin.H{
+			"message": message,
+			"mode":    b.mode,
+			"results": b.results,
+		})
+		return
+	}
+
+	failed := b.failed()
+	utils.Render(b.ctx, http.StatusMultiStatus, gin.H{
+		"message":   message,
+		"mode":      b.mode,
+		"succeeded": len(b.results) - failed,
+		"failed":    failed,
+		"results":   b.results,
+	})
+}
@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/capture"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/utils"
+)
+
+// HeaderCache cho biết response lấy từ đâu: HIT, STALE (đang làm mới ở nền) hoặc MISS
+const HeaderCache = "X-Cache"
+
+// Thời gian tối đa của 1 lần làm mới entry ở nền
+const revalidateTimeout = 30 * time.Second
+
+const tagsKey = "cache.tags"
+
+type revalidateKey struct{}
+
+// Rule là cấu hình cache của 1 route
+type Rule struct {
+	// Name là nhãn route trong metric
+	Name string
+	// TTL: entry được trả ngay trong TTL, sau đó thêm StaleWhileRevalidate nữa
+	// thì vẫn được trả nhưng request đầu tiên kích hoạt làm mới ở nền
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+	// Vary là các header request làm response khác nhau (VD: Accept), được đưa vào key.
+	// Response có Vary ngoài danh sách này thì không được cache.
+	Vary []string
+	// Tags gắn cho mọi entry của route, handler gắn thêm tag theo resource bằng Tag
+	Tags []string
+}
+
+// Cache là response cache cho route GET đọc nhiều. Chỉ response 200 không có
+// Set-Cookie, Cache-Control: private / no-store mới được lưu.
+// Các request cùng key khi cache chưa có entry chỉ chạy handler 1 lần (singleflight),
+// request còn lại chờ và dùng chung kết quả.
+type Cache struct {
+	store   Store
+	handler http.Handler
+
+	// generation tăng mỗi lần Purge: response tạo ra trước khi purge xong thì không được lưu
+	generation atomic.Uint64
+
+	mu         sync.Mutex
+	flights    map[string]*flight
+	refreshing map[string]bool
+}
+
+type flight struct {
+	done  chan struct{}
+	entry *Entry
+}
+
+// New nhận handler (thường là gin engine) để chạy lại request khi làm mới entry ở nền
+func New(store Store, handler http.Handler) *Cache {
+	return &Cache{
+		store:      store,
+		handler:    handler,
+		flights:    make(map[string]*flight),
+		refreshing: make(map[string]bool),
+	}
+}
+
+// Tag gắn thêm tag cho response của request hiện tại, VD: ResourceTag("product", 1)
+// để entry bị xoá khi product 1 thay đổi dù client tìm theo slug
+func Tag(ctx *gin.Context, tags ...string) {
+	ctx.Set(tagsKey, append(ctx.GetStringSlice(tagsKey), tags...))
+}
+
+// ResourceTag là tag của 1 resource, cùng dạng aggregate:id của domain event
+func ResourceTag(kind string, id any) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+// Middleware cache response GET của route theo rule. Cache nil (đã tắt trong config) thì bỏ qua.
+func (c *Cache) Middleware(rule Rule) gin.HandlerFunc {
+	if c == nil {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			ctx.Next()
+			return
+		}
+
+		key := cacheKey(ctx.Request, rule.Vary)
+		revalidating := ctx.Request.Context().Value(revalidateKey{}) != nil
+
+		if !revalidating {
+			if entry, ok := c.store.Get(key); ok {
+				if time.Now().Before(entry.FreshUntil) {
+					metrics.CacheRequests.Inc(rule.Name, "hit")
+					serve(ctx, entry, "HIT")
+					return
+				}
+				metrics.CacheRequests.Inc(rule.Name, "stale")
+				c.revalidate(ctx.Request, key)
+				serve(ctx, entry, "STALE")
+				return
+			}
+		}
+
+		f, leader := c.join(key)
+		if !leader {
+			select {
+			case <-f.done:
+			case <-ctx.Request.Context().Done():
+				ctx.Abort()
+				return
+			}
+			if f.entry != nil {
+				metrics.CacheRequests.Inc(rule.Name, "coalesced")
+				serve(ctx, f.entry, "HIT")
+				return
+			}
+			// Response của request dẫn đầu không cache được (VD: 404), tự chạy handler
+			ctx.Next()
+			return
+		}
+
+		// Chạy cả khi handler panic để request đang chờ không bị treo
+		defer c.leave(key, f)
+		metrics.CacheRequests.Inc(rule.Name, "miss")
+		ctx.Header(HeaderCache, "MISS")
+		f.entry = c.fill(ctx, key, rule)
+	}
+}
+
+// fill chạy handler, lưu response nếu cache được
+func (c *Cache) fill(ctx *gin.Context, key string, rule Rule) *Entry {
+	generation := c.generation.Load()
+	before := ctx.Writer.Header().Clone()
+	recorder := capture.Start(ctx)
+
+	ctx.Next()
+
+	header := handlerHeaders(before, recorder.Header())
+	if recorder.Status() != http.StatusOK || !cacheable(header, rule.Vary) {
+		c.store.Delete(key)
+		return nil
+	}
+
+	now := time.Now()
+	entry := &Entry{
+		Status:     http.StatusOK,
+		Header:     header,
+		Body:       recorder.Body(),
+		Tags:       append(slices.Clone(rule.Tags), ctx.GetStringSlice(tagsKey)...),
+		StoredAt:   now,
+		FreshUntil: now.Add(rule.TTL),
+		StaleUntil: now.Add(rule.TTL + rule.StaleWhileRevalidate),
+	}
+	if c.generation.Load() != generation {
+		return nil
+	}
+	c.store.Set(key, entry)
+	return entry
+}
+
+func (c *Cache) join(key string) (*flight, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.flights[key]; ok {
+		return f, false
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	return f, true
+}
+
+func (c *Cache) leave(key string, f *flight) {
+	c.mu.Lock()
+	delete(c.flights, key)
+	c.mu.Unlock()
+	close(f.done)
+}
+
+// revalidate chạy lại request ở nền qua handler, mỗi key chỉ 1 lần làm mới cùng lúc.
+// Header điều kiện bị bỏ để handler trả response đầy đủ.
+func (c *Cache) revalidate(req *http.Request, key string) {
+	c.mu.Lock()
+	if c.refreshing[key] || c.flights[key] != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), revalidateKey{}, true), revalidateTimeout)
+	clone := req.Clone(ctx)
+	clone.Header.Del("If-None-Match")
+	clone.Header.Del("If-Modified-Since")
+	// Response làm mới chỉ bị bỏ đi, không cần nén
+	clone.Header.Del("Accept-Encoding")
+
+	go func() {
+		defer cancel()
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("cache revalidation panicked", slog.String("key", key), slog.Any("panic", r))
+			}
+		}()
+
+		c.handler.ServeHTTP(&discardWriter{header: make(http.Header)}, clone)
+	}()
+}
+
+// Purge xoá entry theo tag. Response đang được tạo lúc purge sẽ không được lưu.
+func (c *Cache) Purge(tags ...string) int {
+	c.generation.Add(1)
+	return c.store.Purge(tags...)
+}
+
+// HandleEvent là consumer của event bus: xoá entry của resource thay đổi
+// và entry gắn tag chung của loại resource đó (VD: danh sách tin)
+func (c *Cache) HandleEvent(ctx context.Context, event events.Event) error {
+	c.Purge(event.AggregateType, ResourceTag(event.AggregateType, event.AggregateID))
+	return nil
+}
+
+// Invalidate xoá tags sau khi route ghi trả response thành công,
+// dùng cho dữ liệu không đi qua outbox (VD: category)
+func (c *Cache) Invalidate(tags ...string) gin.HandlerFunc {
+	if c == nil {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if ctx.Writer.Status() < http.StatusBadRequest {
+			c.Purge(tags...)
+		}
+	}
+}
+
+func serve(ctx *gin.Context, entry *Entry, state string) {
+	header := ctx.Writer.Header()
+	for name, values := range entry.Header {
+		header[name] = slices.Clone(values)
+	}
+	header.Set(HeaderCache, state)
+	header.Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+
+	if etag := entry.Header.Get("ETag"); etag != "" && utils.CheckNotModified(ctx, etag, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		ctx.Writer.WriteHeaderNow()
+		ctx.Abort()
+		return
+	}
+
+	ctx.Writer.WriteHeader(entry.Status)
+	ctx.Writer.WriteHeaderNow()
+	ctx.Writer.Write(entry.Body)
+	ctx.Abort()
+}
+
+// cacheKey gồm path, query đã sắp xếp và giá trị các header trong vary
+func cacheKey(req *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(req.URL.Path)
+
+	query := req.URL.Query()
+	for name, values := range query {
+		if len(values) > 1 {
+			query[name] = slices.Sorted(slices.Values(values))
+		}
+	}
+	if encoded := query.Encode(); encoded != "" {
+		b.WriteString("?")
+		b.WriteString(encoded)
+	}
+
+	for _, name := range vary {
+		values := make([]string, 0)
+		for _, value := range req.Header.Values(name) {
+			for part := range strings.SplitSeq(value, ",") {
+				if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+					values = append(values, part)
+				}
+			}
+		}
+		fmt.Fprintf(&b, "\n%s: %s", strings.ToLower(name), strings.Join(values, ","))
+	}
+	return b.String()
+}
+
+// cacheable kiểm tra header response: không lưu response riêng của 1 client
+// hoặc Vary theo header không có trong key. Vary: Accept-Encoding được bỏ qua vì
+// entry lưu bản chưa nén, mỗi lần trả entry middleware nén lại theo request.
+func cacheable(header http.Header, vary []string) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-store", "private", "no-cache":
+			return false
+		}
+	}
+	for _, value := range header.Values("Vary") {
+		for name := range strings.SplitSeq(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || strings.EqualFold(name, "Accept-Encoding") {
+				continue
+			}
+			if name == "*" || !slices.ContainsFunc(vary, func(v string) bool { return strings.EqualFold(v, name) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// handlerHeaders lấy header do handler đặt/đổi, bỏ header của middleware đứng trước
+// (request ID, RateLimit-*...) vì chúng riêng cho từng request
+func handlerHeaders(before, after http.Header) http.Header {
+	return capture.HandlerHeaders(before, after, func(name string) bool { return name == HeaderCache })
+}
+
+// discardWriter nhận response của lần làm mới ở nền, recorder của fill đã giữ body
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header            { return w.header }
+func (w *discardWriter) Write(data []byte) (int, error) { return len(data), nil }
+func (w *discardWriter) WriteHeader(int)                {}
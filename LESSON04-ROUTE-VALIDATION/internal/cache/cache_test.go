@@ -0,0 +1,314 @@
+package cache_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/cache"
+)
+
+// backend đếm số lần handler thật được chạy, body đổi theo version
+type backend struct {
+	calls   atomic.Int32
+	version atomic.Int32
+	// gate khác nil thì handler chờ đến khi gate đóng
+	gate chan struct{}
+}
+
+func newEngine(t *testing.T, rule cache.Rule, b *backend, handler func(*gin.Context)) (*gin.Engine, *cache.Cache) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	c := cache.New(cache.NewMemoryStore(100, 1<<20), r)
+	if handler == nil {
+		handler = func(ctx *gin.Context) {
+			b.calls.Add(1)
+			if b.gate != nil {
+				<-b.gate
+			}
+			cache.Tag(ctx, cache.ResourceTag("item", ctx.Param("id")))
+			version := b.version.Load()
+			ctx.Header("ETag", fmt.Sprintf(`"v%d"`, version))
+			ctx.String(http.StatusOK, "item %s v%d", ctx.Param("id"), version)
+		}
+	}
+	r.GET("/items/:id", c.Middleware(rule), handler)
+	r.POST("/items/:id", c.Invalidate("items"), func(ctx *gin.Context) {
+		if ctx.Query("fail") != "" {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		b.version.Add(1)
+		ctx.Status(http.StatusNoContent)
+	})
+	return r, c
+}
+
+func do(r http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func rule(tags ...string) cache.Rule {
+	return cache.Rule{Name: "items", TTL: time.Minute, Vary: []string{"Accept"}, Tags: tags}
+}
+
+func TestHitMiss(t *testing.T) {
+	b := &backend{}
+	r, _ := newEngine(t, rule(), b, nil)
+
+	steps := []struct {
+		target string
+		header map[string]string
+		status int
+		state  string
+		calls  int32
+	}{
+		{"/items/1", nil, http.StatusOK, "MISS", 1},
+		{"/items/1", nil, http.StatusOK, "HIT", 1},
+		// query sắp xếp lại nên cùng key
+		{"/items/1?b=2&a=1", nil, http.StatusOK, "MISS", 2},
+		{"/items/1?a=1&b=2", nil, http.StatusOK, "HIT", 2},
+		{"/items/2", nil, http.StatusOK, "MISS", 3},
+		{"/items/1", map[string]string{"If-None-Match": `"v0"`}, http.StatusNotModified, "HIT", 3},
+	}
+	for i, step := range steps {
+		rec := do(r, http.MethodGet, step.target, step.header)
+		if rec.Code != step.status || rec.Header().Get(cache.HeaderCache) != step.state {
+			t.Errorf("step %d: status = %d, %s = %q, want %d %q", i, rec.Code, cache.HeaderCache, rec.Header().Get(cache.HeaderCache), step.status, step.state)
+		}
+		if step.status == http.StatusOK && rec.Body.String() == "" {
+			t.Errorf("step %d: empty body", i)
+		}
+		if got := b.calls.Load(); got != step.calls {
+			t.Errorf("step %d: handler calls = %d, want %d", i, got, step.calls)
+		}
+	}
+}
+
+func TestMemoryStoreLRU(t *testing.T) {
+	entry := func(tags ...string) *cache.Entry {
+		return &cache.Entry{Status: http.StatusOK, Body: []byte("x"), Tags: tags, StaleUntil: time.Now().Add(time.Minute)}
+	}
+
+	s := cache.NewMemoryStore(2, 1<<20)
+	s.Set("a", entry())
+	s.Set("b", entry())
+	// a được dùng gần đây hơn b nên b bị đẩy ra khi thêm c
+	s.Get("a")
+	s.Set("c", entry())
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := s.Get(key); ok != want {
+			t.Errorf("Get(%q) = %v, want %v", key, ok, want)
+		}
+	}
+
+	// Giới hạn dung lượng: mỗi entry 1 byte key + 1 byte body
+	s = cache.NewMemoryStore(10, 4)
+	s.Set("a", entry())
+	s.Set("b", entry())
+	s.Set("c", entry())
+	if _, ok := s.Get("a"); ok {
+		t.Error("oldest entry should be evicted when maxBytes is exceeded")
+	}
+	s.Set("d", &cache.Entry{Body: make([]byte, 10), StaleUntil: time.Now().Add(time.Minute)})
+	if _, ok := s.Get("d"); ok {
+		t.Error("entry larger than maxBytes should not be stored")
+	}
+
+	s = cache.NewMemoryStore(10, 1<<20)
+	s.Set("expired", &cache.Entry{StaleUntil: time.Now().Add(-time.Second)})
+	if _, ok := s.Get("expired"); ok {
+		t.Error("entry past StaleUntil should not be returned")
+	}
+
+	s.Set("p1", entry("product:1", "product"))
+	s.Set("p2", entry("product:2", "product"))
+	if n := s.Purge("product:1"); n != 1 {
+		t.Errorf("Purge(product:1) = %d, want 1", n)
+	}
+	if n := s.Purge("product"); n != 1 {
+		t.Errorf("Purge(product) = %d, want 1", n)
+	}
+}
+
+func TestPurgeAfterWrite(t *testing.T) {
+	b := &backend{}
+	r, c := newEngine(t, rule("items"), b, nil)
+
+	state := func(target string) string {
+		t.Helper()
+		return do(r, http.MethodGet, target, nil).Header().Get(cache.HeaderCache)
+	}
+
+	state("/items/1")
+	state("/items/2")
+	if got := state("/items/1"); got != "HIT" {
+		t.Fatalf("X-Cache = %q, want HIT", got)
+	}
+
+	// Ghi lỗi không xoá cache
+	if rec := do(r, http.MethodPost, "/items/1?fail=1", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := state("/items/1"); got != "HIT" {
+		t.Errorf("after failed write X-Cache = %q, want HIT", got)
+	}
+
+	do(r, http.MethodPost, "/items/1", nil)
+	rec := do(r, http.MethodGet, "/items/1", nil)
+	if got := rec.Header().Get(cache.HeaderCache); got != "MISS" || rec.Body.String() != "item 1 v1" {
+		t.Errorf("after write X-Cache = %q, body = %q", got, rec.Body.String())
+	}
+
+	// Purge theo tag của resource chỉ xoá entry đó
+	if got := state("/items/2"); got != "MISS" {
+		t.Fatalf("X-Cache = %q, want MISS", got)
+	}
+	c.Purge(cache.ResourceTag("item", "1"))
+	if got := state("/items/1"); got != "MISS" {
+		t.Errorf("purged entry X-Cache = %q, want MISS", got)
+	}
+	if got := state("/items/2"); got != "HIT" {
+		t.Errorf("other entry X-Cache = %q, want HIT", got)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	b := &backend{}
+	rule := cache.Rule{Name: "items", TTL: 20 * time.Millisecond, StaleWhileRevalidate: time.Minute}
+	r, _ := newEngine(t, rule, b, nil)
+
+	do(r, http.MethodGet, "/items/1", nil)
+	time.Sleep(30 * time.Millisecond)
+
+	// Lần làm mới bị giữ lại để kiểm tra request trong lúc đó vẫn nhận bản cũ
+	b.gate = make(chan struct{})
+	b.version.Add(1)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			rec := do(r, http.MethodGet, "/items/1", nil)
+			if got := rec.Header().Get(cache.HeaderCache); got != "STALE" || rec.Body.String() != "item 1 v0" {
+				t.Errorf("X-Cache = %q, body = %q, want STALE v0", got, rec.Body.String())
+			}
+		})
+	}
+	wg.Wait()
+
+	waitFor(t, func() bool { return b.calls.Load() == 2 })
+	close(b.gate)
+
+	waitFor(t, func() bool {
+		rec := do(r, http.MethodGet, "/items/1", nil)
+		return rec.Header().Get(cache.HeaderCache) == "HIT" && rec.Body.String() == "item 1 v1"
+	})
+	if got := b.calls.Load(); got != 2 {
+		t.Errorf("handler calls = %d, want 2 (1 fill + 1 refresh)", got)
+	}
+}
+
+func TestMissSingleflight(t *testing.T) {
+	b := &backend{gate: make(chan struct{})}
+	r, _ := newEngine(t, rule(), b, nil)
+
+	states := make(chan string, 5)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			states <- do(r, http.MethodGet, "/items/1", nil).Header().Get(cache.HeaderCache)
+		})
+	}
+	waitFor(t, func() bool { return b.calls.Load() == 1 })
+	// Cho các request còn lại kịp vào hàng chờ
+	time.Sleep(20 * time.Millisecond)
+	close(b.gate)
+	wg.Wait()
+	close(states)
+
+	count := map[string]int{}
+	for state := range states {
+		count[state]++
+	}
+	if count["MISS"] != 1 || count["HIT"] != 4 {
+		t.Errorf("X-Cache counts = %v, want 1 MISS and 4 HIT", count)
+	}
+	if got := b.calls.Load(); got != 1 {
+		t.Errorf("handler calls = %d, want 1", got)
+	}
+}
+
+func TestVaryAndPrivate(t *testing.T) {
+	b := &backend{}
+	r, _ := newEngine(t, rule(), b, func(ctx *gin.Context) {
+		b.calls.Add(1)
+		switch ctx.Param("id") {
+		case "auth":
+			ctx.Header("Vary", "Authorization")
+		case "private":
+			ctx.Header("Cache-Control", "private")
+		case "cookie":
+			ctx.SetCookie("session", "1", 0, "/", "", false, true)
+		case "accept":
+			ctx.Header("Vary", "Accept, Accept-Encoding")
+		}
+		ctx.String(http.StatusOK, "%s for %s", ctx.Param("id"), ctx.GetHeader("Authorization")+ctx.GetHeader("Accept"))
+	})
+
+	// Response riêng của client không được lưu, mỗi request đều chạy handler
+	for _, id := range []string{"auth", "private", "cookie"} {
+		b.calls.Store(0)
+		alice := do(r, http.MethodGet, "/items/"+id, map[string]string{"Authorization": "alice"})
+		bob := do(r, http.MethodGet, "/items/"+id, map[string]string{"Authorization": "bob"})
+		if b.calls.Load() != 2 || bob.Header().Get(cache.HeaderCache) != "MISS" {
+			t.Errorf("%s: handler calls = %d, second X-Cache = %q, want 2 MISS", id, b.calls.Load(), bob.Header().Get(cache.HeaderCache))
+		}
+		if alice.Body.String() == bob.Body.String() {
+			t.Errorf("%s: bob received alice's response %q", id, bob.Body.String())
+		}
+	}
+
+	// Accept nằm trong Rule.Vary nên mỗi giá trị có entry riêng
+	b.calls.Store(0)
+	for i, step := range []struct {
+		accept string
+		state  string
+	}{
+		{"application/json", "MISS"},
+		{"application/xml", "MISS"},
+		{"application/json", "HIT"},
+		{"Application/JSON", "HIT"},
+	} {
+		rec := do(r, http.MethodGet, "/items/accept", map[string]string{"Accept": step.accept})
+		if got := rec.Header().Get(cache.HeaderCache); got != step.state {
+			t.Errorf("accept step %d: X-Cache = %q, want %q", i, got, step.state)
+		}
+	}
+	if got := b.calls.Load(); got != 2 {
+		t.Errorf("accept: handler calls = %d, want 2", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/metrics"
+)
+
+// Entry là response đã lưu. Sau FreshUntil entry vẫn được trả (kèm làm mới ở nền) đến StaleUntil.
+type Entry struct {
+	Status     int
+	Header     http.Header
+	Body       []byte
+	Tags       []string
+	StoredAt   time.Time
+	FreshUntil time.Time
+	StaleUntil time.Time
+}
+
+func (e *Entry) size() int {
+	size := len(e.Body)
+	for name, values := range e.Header {
+		size += len(name)
+		for _, value := range values {
+			size += len(value)
+		}
+	}
+	for _, tag := range e.Tags {
+		size += len(tag)
+	}
+	return size
+}
+
+// Store lưu entry theo key và xoá được theo tag. MemoryStore dùng cho 1 instance,
+// store dùng chung (VD: Redis) cần tự giữ index tag -> key.
+type Store interface {
+	// Get không trả entry đã quá StaleUntil
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	Delete(key string)
+	// Purge xoá mọi entry có ít nhất 1 tag trong tags, trả về số entry bị xoá
+	Purge(tags ...string) int
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+	size  int
+}
+
+// MemoryStore là LRU giới hạn theo số entry và tổng dung lượng
+type MemoryStore struct {
+	maxEntries int
+	maxBytes   int
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	tags  map[string]map[string]struct{}
+	bytes int
+}
+
+func NewMemoryStore(maxEntries, maxBytes int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		items:      make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*memoryItem)
+	if time.Now().After(item.entry.StaleUntil) {
+		s.remove(elem, "expired")
+		return nil, false
+	}
+
+	s.lru.MoveToFront(elem)
+	return item.entry, true
+}
+
+// Set bỏ qua entry lớn hơn cả dung lượng của store
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	item := &memoryItem{key: key, entry: entry, size: len(key) + entry.size()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem, "replaced")
+	}
+	if item.size > s.maxBytes {
+		return
+	}
+
+	s.items[key] = s.lru.PushFront(item)
+	s.bytes += item.size
+	for _, tag := range entry.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+
+	for s.lru.Len() > s.maxEntries || s.bytes > s.maxBytes {
+		s.remove(s.lru.Back(), "capacity")
+	}
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem, "deleted")
+	}
+}
+
+func (s *MemoryStore) Purge(tags ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if elem, ok := s.items[key]; ok {
+				s.remove(elem, "purged")
+				purged++
+			}
+		}
+	}
+	return purged
+}
+
+// remove phải được gọi khi đang giữ s.mu
+func (s *MemoryStore) remove(elem *list.Element, reason string) {
+	item := elem.Value.(*memoryItem)
+
+	s.lru.Remove(elem)
+	delete(s.items, item.key)
+	s.bytes -= item.size
+	for _, tag := range item.entry.Tags {
+		delete(s.tags[tag], item.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+
+	if reason != "replaced" {
+		metrics.CacheEvictions.Inc(reason)
+	}
+}
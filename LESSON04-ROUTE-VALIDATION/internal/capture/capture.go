@@ -0,0 +1,54 @@
+package capture
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recorder giữ lại bản sao body trong khi vẫn gửi cho client như bình thường,
+// dùng cho middleware lưu response để trả lại sau (cache, idempotency)
+type Recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Start thay ctx.Writer bằng Recorder, gọi trước ctx.Next
+func Start(ctx *gin.Context) *Recorder {
+	w := &Recorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = w
+	return w
+}
+
+func (w *Recorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *Recorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Body trả về bản sao body đã ghi
+func (w *Recorder) Body() []byte {
+	return bytes.Clone(w.body.Bytes())
+}
+
+// HandlerHeaders lấy header do handler đặt/đổi so với before, bỏ header mà skip trả true.
+// Content-Encoding / Content-Length luôn bị bỏ: middleware nén đặt chúng theo Accept-Encoding
+// của request hiện tại, còn body giữ lại là bản chưa nén.
+func HandlerHeaders(before, after http.Header, skip func(name string) bool) http.Header {
+	header := make(http.Header)
+	for name, values := range after {
+		if name == "Content-Encoding" || name == "Content-Length" || skip(name) {
+			continue
+		}
+		if !slices.Equal(before[name], values) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	return header
+}
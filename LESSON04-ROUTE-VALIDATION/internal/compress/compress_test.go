@@ -0,0 +1,206 @@
+package compress_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"mamba.com/route-group/internal/compress"
+	"mamba.com/route-group/utils"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"br", ""},
+		{"*", compress.EncodingZstd},
+		{"gzip, deflate", compress.EncodingGzip},
+		{"deflate", compress.EncodingDeflate},
+		{"gzip;q=0.8, zstd", compress.EncodingZstd},
+		{"zstd;q=0.5, GZIP;Q=0.9", compress.EncodingGzip},
+		{"gzip;q=0", ""},
+		{"gzip;q=0, deflate", compress.EncodingDeflate},
+		{"*;q=0.5, zstd;q=0", compress.EncodingGzip},
+		{"gzip;q=invalid, deflate;q=0.1", compress.EncodingDeflate},
+	}
+	for _, tt := range tests {
+		if got := compress.Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+const minSize = 1024
+
+var large = strings.Repeat(`{"name":"compressible"}`, 200)
+
+func newEngine(rows iter.Seq[int]) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(compress.Middleware(compress.Options{MinSize: minSize}))
+	r.GET("/large", func(ctx *gin.Context) { ctx.Data(http.StatusOK, "application/json", []byte(large)) })
+	r.GET("/small", func(ctx *gin.Context) { ctx.Data(http.StatusOK, "application/json", []byte(`{"ok":true}`)) })
+	r.GET("/image", func(ctx *gin.Context) { ctx.Data(http.StatusOK, "image/png", []byte(large)) })
+	r.GET("/not-modified", func(ctx *gin.Context) { ctx.Status(http.StatusNotModified) })
+	r.GET("/rows", func(ctx *gin.Context) {
+		utils.RenderNDJSON(ctx, rows, func(i int) (any, error) { return gin.H{"row": i}, nil })
+	})
+	return r
+}
+
+func decode(t *testing.T, encoding string, body io.Reader) io.Reader {
+	t.Helper()
+	var (
+		r   io.Reader
+		err error
+	)
+	switch encoding {
+	case "":
+		return body
+	case compress.EncodingGzip:
+		r, err = gzip.NewReader(body)
+	case compress.EncodingDeflate:
+		r, err = zlib.NewReader(body)
+	case compress.EncodingZstd:
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(body)
+		r = d
+	default:
+		t.Fatalf("unexpected Content-Encoding %q", encoding)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	r := newEngine(nil)
+
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		encoding string
+		vary     bool
+		body     string
+	}{
+		{"zstd preferred", "/large", "gzip, deflate, zstd", compress.EncodingZstd, true, large},
+		{"gzip", "/large", "gzip", compress.EncodingGzip, true, large},
+		{"deflate", "/large", "deflate, gzip;q=0.5", compress.EncodingDeflate, true, large},
+		{"q=0 excluded", "/large", "zstd;q=0, gzip;q=0, deflate;q=0", "", true, large},
+		{"no Accept-Encoding", "/large", "", "", true, large},
+		{"below MinSize", "/small", "gzip", "", true, `{"ok":true}`},
+		{"already compressed type", "/image", "gzip", "", false, large},
+		{"304", "/not-modified", "gzip", "", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			encoding := w.Header().Get("Content-Encoding")
+			if encoding != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", encoding, tt.encoding)
+			}
+			if got := w.Header().Get("Vary") == "Accept-Encoding"; got != tt.vary {
+				t.Errorf("Vary = %q", w.Header().Get("Vary"))
+			}
+			if encoding != "" && w.Body.Len() >= len(tt.body) {
+				t.Errorf("compressed body %d bytes, original %d", w.Body.Len(), len(tt.body))
+			}
+
+			body, err := io.ReadAll(decode(t, encoding, w.Body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.body {
+				t.Errorf("decoded body = %.60q..., want %.60q...", body, tt.body)
+			}
+		})
+	}
+}
+
+// Dòng NDJSON đã flush phải tới client qua encoder trong khi handler vẫn đang chạy
+func TestStreamedNDJSON(t *testing.T) {
+	gate := make(chan struct{})
+	rows := func(yield func(int) bool) {
+		for i := range 150 {
+			// RenderNDJSON flush sau mỗi 100 dòng, giữ handler lại ngay sau lần flush đầu
+			if i == 100 {
+				<-gate
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	srv := httptest.NewServer(newEngine(rows))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/rows", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	// Tự giải nén để kiểm tra Content-Encoding thật
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Encoding") != compress.EncodingGzip || resp.Header.Get("Content-Type") != utils.MIMENDJSON {
+		t.Fatalf("Content-Encoding = %q, Content-Type = %q", resp.Header.Get("Content-Encoding"), resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(decode(t, compress.EncodingGzip, resp.Body))
+	read := func(from, to int) error {
+		for i := from; i < to; i++ {
+			if !lines.Scan() {
+				return fmt.Errorf("line %d: %v", i, lines.Err())
+			}
+			if want := fmt.Sprintf(`{"row":%d}`, i); lines.Text() != want {
+				return fmt.Errorf("line %d = %q, want %q", i, lines.Text(), want)
+			}
+		}
+		return nil
+	}
+
+	done := make(chan error)
+	go func() { done <- read(0, 100) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			close(gate)
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		close(gate)
+		t.Fatal("flushed rows did not reach the client while the handler was running")
+	}
+
+	close(gate)
+	if err := read(100, 150); err != nil {
+		t.Fatal(err)
+	}
+	if lines.Scan() {
+		t.Errorf("unexpected line %q", lines.Text())
+	}
+}
@@ -0,0 +1,89 @@
+package compress
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"mamba.com/route-group/utils"
+)
+
+// Content-Encoding được hỗ trợ, thứ tự là thứ tự ưu tiên khi client chấp nhận nhiều loại với cùng q
+const (
+	EncodingZstd    = "zstd"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+var encodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+
+// Window tối đa trình duyệt chấp nhận cho zstd qua HTTP (RFC 8878), window lớn hơn thì client từ chối giải nén
+const zstdWindowSize = 8 << 20
+
+// encoder là phần chung của gzip.Writer, zlib.Writer và zstd.Encoder để dùng lại qua pool
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Tạo encoder tốn bộ nhớ (zstd cấp phát theo window) nên mỗi loại có 1 pool
+var pools = map[string]*sync.Pool{
+	EncodingZstd: {New: func() any {
+		// Lỗi chỉ xảy ra khi option sai
+		enc, err := zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.SpeedDefault),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithWindowSize(zstdWindowSize))
+		if err != nil {
+			panic(err)
+		}
+		return enc
+	}},
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+	// deflate trong HTTP là định dạng zlib (RFC 1950), không phải deflate thô
+	EncodingDeflate: {New: func() any {
+		return zlib.NewWriter(nil)
+	}},
+}
+
+func acquire(encoding string, w io.Writer) encoder {
+	enc := pools[encoding].Get().(encoder)
+	enc.Reset(w)
+	return enc
+}
+
+func release(encoding string, enc encoder) {
+	enc.Reset(nil)
+	pools[encoding].Put(enc)
+}
+
+// Negotiate chọn encoding theo header Accept-Encoding, "" nếu client không nhận encoding nào
+// (gửi nguyên bản). VD: "gzip;q=0.8, zstd" -> zstd, "*" -> zstd, "gzip;q=0" -> "".
+func Negotiate(acceptEncoding string) string {
+	quality := make(map[string]float64)
+	wildcard := -1.0
+	for _, v := range utils.ParseQualityValues(acceptEncoding) {
+		if v.Value == "*" {
+			wildcard = v.Q
+		} else {
+			quality[v.Value] = v.Q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range encodings {
+		q, ok := quality[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
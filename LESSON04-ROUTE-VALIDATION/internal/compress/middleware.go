@@ -0,0 +1,240 @@
+package compress
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/metrics"
+)
+
+type Options struct {
+	// MinSize: response nhỏ hơn thì gửi nguyên bản, phần header của gzip/zstd
+	// và CPU nén không đáng với vài trăm byte
+	MinSize int
+}
+
+// Content-Type đã nén sẵn (ảnh, video, file zip, xlsx...) nén thêm chỉ tốn CPU.
+// text/event-stream bị bỏ qua vì mỗi kết nối SSE sống lâu sẽ giữ riêng 1 encoder.
+var incompressiblePrefixes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"text/event-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+	"application/vnd.openxmlformats-officedocument.",
+}
+
+// Middleware nén response theo Accept-Encoding (zstd, gzip, deflate). Body được giữ lại
+// đến khi đủ MinSize hoặc handler flush rồi mới quyết định nén dựa trên status và Content-Type,
+// nên handler không cần biết response có được nén hay không.
+// ETag giữ nguyên vì nó định danh version của resource (dùng cho If-Match), không phải byte gửi đi.
+func Middleware(opts Options) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		w := &writer{
+			ResponseWriter: ctx.Writer,
+			encoding:       Negotiate(ctx.GetHeader("Accept-Encoding")),
+			minSize:        opts.MinSize,
+		}
+		ctx.Writer = w
+
+		// Handler panic thì bỏ phần body đang giữ, trả writer gốc để Recovery ghi được 500
+		completed := false
+		defer func() {
+			if !completed {
+				w.abandon()
+				ctx.Writer = w.ResponseWriter
+			}
+		}()
+
+		ctx.Next()
+		completed = true
+		w.finish()
+		ctx.Writer = w.ResponseWriter
+	}
+}
+
+// writer giữ body trong buf cho đến khi start chọn gửi nén (qua enc) hoặc gửi thẳng
+type writer struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf     []byte
+	started bool
+	enc     encoder
+	// in là số byte chưa nén handler đã ghi, chỉ dùng cho metric
+	in int
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if w.started {
+		return w.write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *writer) write(data []byte) (int, error) {
+	if w.enc == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	w.in += len(data)
+	return w.enc.Write(data)
+}
+
+// WriteHeaderNow chỉ gửi header khi đã quyết định nén hay không, nếu không
+// handler ghi header trước rồi mới ghi body (VD: response cache) sẽ luôn bị gửi nguyên bản
+func (w *writer) WriteHeaderNow() {
+	if w.started {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *writer) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush gửi ngay phần body đang giữ: response stream (NDJSON) được nén theo từng đoạn
+// dù chưa đủ MinSize vì không biết trước tổng dung lượng
+func (w *writer) Flush() {
+	if !w.started {
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack (WebSocket) nhận kết nối thô, không nén
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.started = true
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start quyết định nén rồi gửi phần body đang giữ. enough là false khi handler đã xong
+// mà body vẫn chưa tới MinSize.
+func (w *writer) start(enough bool) error {
+	w.started = true
+	header := w.Header()
+	// Không có Content-Type thì net/http sẽ đoán theo byte đầu tiên, nhưng bỏ qua khi đã có
+	// Content-Encoding nên phải đoán trước trên dữ liệu chưa nén
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if w.eligible() {
+		addVary(header, "Accept-Encoding")
+		if enough && w.encoding != "" {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			w.enc = acquire(w.encoding, w.ResponseWriter)
+		}
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.write(buf)
+	return err
+}
+
+// eligible kiểm tra response có thể nén, chưa tính dung lượng và encoding client nhận
+func (w *writer) eligible() bool {
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent,
+		status == http.StatusPartialContent, status == http.StatusNotModified:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "image/svg+xml") {
+		return true
+	}
+	for _, prefix := range incompressiblePrefixes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish chạy sau handler: gửi phần body còn giữ và đóng encoder để ghi footer
+func (w *writer) finish() {
+	if !w.started {
+		w.start(false)
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	if w.enc == nil {
+		return
+	}
+
+	// encoder ghi thẳng vào ResponseWriter nên Size sau Close là số byte đã nén gửi đi
+	if err := w.enc.Close(); err == nil {
+		metrics.CompressedResponses.Inc(w.encoding)
+		metrics.CompressionBytes.Add(float64(w.in), w.encoding, "in")
+		metrics.CompressionBytes.Add(float64(w.ResponseWriter.Size()), w.encoding, "out")
+	}
+	release(w.encoding, w.enc)
+	w.enc = nil
+}
+
+func (w *writer) abandon() {
+	w.buf = nil
+	if w.enc != nil {
+		release(w.encoding, w.enc)
+		w.enc = nil
+	}
+}
+
+// addVary thêm name vào Vary nếu chưa có, handler có thể đã đặt Vary: Accept
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for part := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
@@ -0,0 +1,293 @@
+package config
+
+import "time"
+
+// Config là cấu hình của API server. Mỗi field lá có thể đặt từ file (yaml/toml/json),
+// biến môi trường MAMBA_<env> (hoặc MAMBA_<env>_FILE để đọc secret từ file) và flag -<flag>.
+// Rule validate dùng tag binding giống request.
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server" json:"server"`
+	Log         LogConfig         `yaml:"log" toml:"log" json:"log"`
+	Upload      UploadConfig      `yaml:"upload" toml:"upload" json:"upload"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing" json:"tracing"`
+	Health      HealthConfig      `yaml:"health" toml:"health" json:"health"`
+	OpenAPI     OpenAPIConfig     `yaml:"openapi" toml:"openapi" json:"openapi"`
+	Versioning  VersioningConfig  `yaml:"versioning" toml:"versioning" json:"versioning"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit" json:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency" json:"idempotency"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency" toml:"concurrency" json:"concurrency"`
+	Bulk        BulkConfig        `yaml:"bulk" toml:"bulk" json:"bulk"`
+	Import      ImportConfig      `yaml:"import" toml:"import" json:"import"`
+	Jobs        JobsConfig        `yaml:"jobs" toml:"jobs" json:"jobs"`
+	Events      EventsConfig      `yaml:"events" toml:"events" json:"events"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks" json:"webhooks"`
+	Stream      StreamConfig      `yaml:"stream" toml:"stream" json:"stream"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache" json:"cache"`
+	Compression CompressionConfig `yaml:"compression" toml:"compression" json:"compression"`
+}
+
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr" json:"addr" env:"SERVER_ADDR" flag:"addr" usage:"listen address" binding:"required,hostname_port"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" json:"read_timeout" env:"SERVER_READ_TIMEOUT" flag:"read-timeout" usage:"max time to read a request including the body (uploads)" binding:"gt=0"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" json:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" flag:"read-header-timeout" usage:"max time to read request headers" binding:"gt=0"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" json:"write_timeout" env:"SERVER_WRITE_TIMEOUT" flag:"write-timeout" usage:"max time from end of headers to end of response" binding:"gt=0"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" json:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout" usage:"keep-alive idle timeout" binding:"gt=0"`
+	MaxHeaderBytes    int      `yaml:"max_header_bytes" toml:"max_header_bytes" json:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" flag:"max-header-bytes" usage:"max size of request headers" binding:"gt=0"`
+	ShutdownTimeout   Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" json:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"deadline for draining requests and stopping workers on SIGINT/SIGTERM" binding:"gt=0"`
+	DrainDelay        Duration `yaml:"drain_delay" toml:"drain_delay" json:"drain_delay" env:"SERVER_DRAIN_DELAY" flag:"drain-delay" usage:"wait after /readyz starts failing before closing the listener" binding:"gte=0"`
+	// TrustedProxies rỗng: không tin X-Forwarded-For, IP client là IP kết nối để rate limit theo IP không bị giả mạo
+	TrustedProxies []string  `yaml:"trusted_proxies" toml:"trusted_proxies" json:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" flag:"trusted-proxies" usage:"comma separated proxy IPs/CIDRs allowed to set X-Forwarded-For" binding:"dive,cidr|ip"`
+	H2C            bool      `yaml:"h2c" toml:"h2c" json:"h2c" env:"SERVER_H2C" flag:"h2c" usage:"accept unencrypted HTTP/2 (h2c) for internal traffic; ignored when TLS is enabled"`
+	TLS            TLSConfig `yaml:"tls" toml:"tls" json:"tls"`
+}
+
+// TLSConfig bật HTTPS khi có CertFile. Cert và key được đọc lại khi file đổi, không cần SIGHUP.
+type TLSConfig struct {
+	CertFile       string   `yaml:"cert_file" toml:"cert_file" json:"cert_file" env:"SERVER_TLS_CERT_FILE" flag:"tls-cert" usage:"PEM certificate (chain); enables HTTPS" binding:"required_with=KeyFile"`
+	KeyFile        string   `yaml:"key_file" toml:"key_file" json:"key_file" env:"SERVER_TLS_KEY_FILE" flag:"tls-key" usage:"PEM private key for -tls-cert" binding:"required_with=CertFile"`
+	ClientCAFile   string   `yaml:"client_ca_file" toml:"client_ca_file" json:"client_ca_file" env:"SERVER_TLS_CLIENT_CA_FILE" flag:"tls-client-ca" usage:"PEM CA bundle; /admin then requires a client certificate signed by it (mTLS)" binding:"excluded_without=CertFile"`
+	ReloadInterval Duration `yaml:"reload_interval" toml:"reload_interval" json:"reload_interval" env:"SERVER_TLS_RELOAD_INTERVAL" flag:"tls-reload-interval" usage:"how often certificate files are checked for changes" binding:"gt=0"`
+	HTTP3          bool     `yaml:"http3" toml:"http3" json:"http3" env:"SERVER_TLS_HTTP3" flag:"http3" usage:"experimental: also serve HTTP/3 over QUIC (UDP) on the same port" binding:"excluded_without=CertFile"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format" json:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log output format: json or text" binding:"oneof=json text"`
+	// Level đổi được bằng SIGHUP
+	Level string `yaml:"level" toml:"level" json:"level" env:"LOG_LEVEL" flag:"log-level" usage:"log level: debug, info, warn or error" binding:"oneof=debug info warn error"`
+}
+
+type UploadConfig struct {
+	Dir string `yaml:"dir" toml:"dir" json:"dir" env:"UPLOAD_DIR" flag:"upload-dir" usage:"directory for uploaded files, served at /uploads" binding:"required"`
+	// MaxSize, AllowedExts, AllowedMimeTypes đổi được bằng SIGHUP
+	MaxSize          ByteSize `yaml:"max_size" toml:"max_size" json:"max_size" env:"UPLOAD_MAX_SIZE" flag:"upload-max-size" usage:"max size of one uploaded file, e.g. 5MB" binding:"gt=0"`
+	AllowedExts      []string `yaml:"allowed_exts" toml:"allowed_exts" json:"allowed_exts" env:"UPLOAD_ALLOWED_EXTS" flag:"upload-allowed-exts" usage:"comma separated file extensions accepted for upload" binding:"required,dive,startswith=."`
+	AllowedMimeTypes []string `yaml:"allowed_mime_types" toml:"allowed_mime_types" json:"allowed_mime_types" env:"UPLOAD_ALLOWED_MIME_TYPES" flag:"upload-allowed-mime-types" usage:"comma separated MIME types accepted for upload" binding:"required,dive,contains=/"`
+}
+
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" toml:"exporter" json:"exporter" env:"TRACING_EXPORTER" flag:"trace-exporter" usage:"span exporter: none, stdout, file or otlp" binding:"oneof=none stdout file otlp"`
+	File         string `yaml:"file" toml:"file" json:"file" env:"TRACING_FILE" flag:"trace-file" usage:"file written by -trace-exporter=file" binding:"required_if=Exporter file"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" json:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" flag:"trace-otlp-endpoint" usage:"OTLP/HTTP endpoint for -trace-exporter=otlp" binding:"required_if=Exporter otlp,omitempty,url"`
+	// OTLPHeaders thường chứa token của backend, nên đặt bằng MAMBA_TRACING_OTLP_HEADERS_FILE
+	OTLPHeaders map[string]string `yaml:"otlp_headers" toml:"otlp_headers" json:"otlp_headers" env:"TRACING_OTLP_HEADERS"`
+	SampleRatio float64           `yaml:"sample_ratio" toml:"sample_ratio" json:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"trace-sample-ratio" usage:"fraction of new traces to sample (0..1)" binding:"gte=0,lte=1"`
+	ServiceName string            `yaml:"service_name" toml:"service_name" json:"service_name" env:"TRACING_SERVICE_NAME" binding:"required"`
+}
+
+type HealthConfig struct {
+	CacheTTL      Duration `yaml:"cache_ttl" toml:"cache_ttl" json:"cache_ttl" env:"HEALTH_CACHE_TTL" flag:"health-cache-ttl" usage:"reuse dependency check results for this long" binding:"gte=0"`
+	MinFreeDiskMB uint64   `yaml:"min_free_disk_mb" toml:"min_free_disk_mb" json:"min_free_disk_mb" env:"HEALTH_MIN_FREE_DISK_MB" flag:"health-min-free-disk-mb" usage:"readiness fails when the upload volume has less free space (MB)"`
+}
+
+type OpenAPIConfig struct {
+	Spec              string `yaml:"spec" toml:"spec" json:"spec" env:"OPENAPI_SPEC" flag:"openapi-spec" usage:"validate requests against this OpenAPI spec (.json, .yaml)"`
+	ValidateResponses bool   `yaml:"validate_responses" toml:"validate_responses" json:"validate_responses" env:"OPENAPI_VALIDATE_RESPONSES" flag:"openapi-validate-responses" usage:"also validate responses against -openapi-spec"`
+	Strict            bool   `yaml:"strict" toml:"strict" json:"strict" env:"OPENAPI_STRICT" flag:"openapi-strict" usage:"replace responses that violate -openapi-spec with 500"`
+}
+
+// RateLimitConfig: quota của mỗi nhóm route viết dạng "100/1m", 0 là tắt
+type RateLimitConfig struct {
+	Store     string `yaml:"store" toml:"store" json:"store" env:"RATELIMIT_STORE" flag:"ratelimit-store" usage:"where rate limit counters live: memory (per instance) or redis (shared)" binding:"oneof=memory redis"`
+	RedisAddr string `yaml:"redis_addr" toml:"redis_addr" json:"redis_addr" env:"RATELIMIT_REDIS_ADDR" flag:"ratelimit-redis-addr" usage:"Redis-protocol server for -ratelimit-store=redis" binding:"required_if=Store redis,omitempty,hostname_port"`
+	// RedisPassword nên đặt bằng MAMBA_RATELIMIT_REDIS_PASSWORD_FILE
+	RedisPassword string `yaml:"redis_password" toml:"redis_password" json:"redis_password" env:"RATELIMIT_REDIS_PASSWORD"`
+	RedisDB       int    `yaml:"redis_db" toml:"redis_db" json:"redis_db" env:"RATELIMIT_REDIS_DB" flag:"ratelimit-redis-db" usage:"Redis database number" binding:"gte=0"`
+	// API, Upload, Search, Admin đổi được bằng SIGHUP
+	API    Rate `yaml:"api" toml:"api" json:"api" env:"RATELIMIT_API" flag:"ratelimit-api" usage:"requests per API key (or IP) for /api, e.g. 300/1m"`
+	Upload Rate `yaml:"upload" toml:"upload" json:"upload" env:"RATELIMIT_UPLOAD" flag:"ratelimit-upload" usage:"requests per API key (or IP) for news create/upload endpoints"`
+	Search Rate `yaml:"search" toml:"search" json:"search" env:"RATELIMIT_SEARCH" flag:"ratelimit-search" usage:"requests per API key (or IP) for product listing/search"`
+	Admin  Rate `yaml:"admin" toml:"admin" json:"admin" env:"RATELIMIT_ADMIN" flag:"ratelimit-admin" usage:"requests per client certificate (or IP) for /admin"`
+}
+
+type IdempotencyConfig struct {
+	TTL         Duration `yaml:"ttl" toml:"ttl" json:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"how long responses are kept for replay to requests with the same Idempotency-Key" binding:"gt=0"`
+	WaitTimeout Duration `yaml:"wait_timeout" toml:"wait_timeout" json:"wait_timeout" env:"IDEMPOTENCY_WAIT_TIMEOUT" flag:"idempotency-wait" usage:"how long a retry waits for the original request still in flight before 409" binding:"gte=0"`
+}
+
+// ConcurrencyConfig: optimistic concurrency của PUT/DELETE users, products theo ETag / If-Match
+type ConcurrencyConfig struct {
+	// RequireIfMatch đổi được bằng SIGHUP
+	RequireIfMatch bool `yaml:"require_if_match" toml:"require_if_match" json:"require_if_match" env:"CONCURRENCY_REQUIRE_IF_MATCH" flag:"require-if-match" usage:"reject updates and deletes without If-Match with 428"`
+}
+
+type BulkConfig struct {
+	// MaxItems đổi được bằng SIGHUP
+	MaxItems int `yaml:"max_items" toml:"max_items" json:"max_items" env:"BULK_MAX_ITEMS" flag:"bulk-max-items" usage:"max items in one request to a /bulk endpoint" binding:"gt=0"`
+}
+
+// ImportConfig giới hạn file import products (CSV/XLSX), job được giữ trong bộ nhớ đến hết TTL
+type ImportConfig struct {
+	MaxSize ByteSize `yaml:"max_size" toml:"max_size" json:"max_size" env:"IMPORT_MAX_SIZE" flag:"import-max-size" usage:"max size of a product import file, e.g. 10MB" binding:"gt=0"`
+	MaxRows int      `yaml:"max_rows" toml:"max_rows" json:"max_rows" env:"IMPORT_MAX_ROWS" flag:"import-max-rows" usage:"max data rows in a product import file" binding:"gt=0"`
+	TTL     Duration `yaml:"ttl" toml:"ttl" json:"ttl" env:"IMPORT_TTL" flag:"import-ttl" usage:"how long finished import jobs and their error reports are kept" binding:"gt=0"`
+}
+
+// JobsConfig: hàng đợi job chạy nền lưu trong SQLite (ảnh thu nhỏ, publish tin theo lịch)
+type JobsConfig struct {
+	DB                  string   `yaml:"db" toml:"db" json:"db" env:"JOBS_DB" flag:"jobs-db" usage:"SQLite file of the background job queue" binding:"required"`
+	PollInterval        Duration `yaml:"poll_interval" toml:"poll_interval" json:"poll_interval" env:"JOBS_POLL_INTERVAL" flag:"jobs-poll-interval" usage:"how often idle workers look for due jobs" binding:"gt=0"`
+	MaxAttempts         int      `yaml:"max_attempts" toml:"max_attempts" json:"max_attempts" env:"JOBS_MAX_ATTEMPTS" flag:"jobs-max-attempts" usage:"attempts before a failing job moves to the dead letter" binding:"gt=0"`
+	RetryBase           Duration `yaml:"retry_base" toml:"retry_base" json:"retry_base" env:"JOBS_RETRY_BASE" flag:"jobs-retry-base" usage:"delay before the first retry, doubled on each attempt" binding:"gt=0"`
+	RetryMax            Duration `yaml:"retry_max" toml:"retry_max" json:"retry_max" env:"JOBS_RETRY_MAX" flag:"jobs-retry-max" usage:"max delay between retries" binding:"gt=0"`
+	Timeout             Duration `yaml:"timeout" toml:"timeout" json:"timeout" env:"JOBS_TIMEOUT" flag:"jobs-timeout" usage:"default time limit of one job run" binding:"gt=0"`
+	Retention           Duration `yaml:"retention" toml:"retention" json:"retention" env:"JOBS_RETENTION" flag:"jobs-retention" usage:"how long succeeded and cancelled jobs are kept; dead jobs are kept until retried" binding:"gt=0"`
+	DefaultConcurrency  int      `yaml:"default_concurrency" toml:"default_concurrency" json:"default_concurrency" env:"JOBS_DEFAULT_CONCURRENCY" flag:"jobs-default-concurrency" usage:"jobs run at once on the default queue" binding:"gt=0"`
+	MediaConcurrency    int      `yaml:"media_concurrency" toml:"media_concurrency" json:"media_concurrency" env:"JOBS_MEDIA_CONCURRENCY" flag:"jobs-media-concurrency" usage:"jobs run at once on the media queue (image derivatives)" binding:"gt=0"`
+	NewsPublishSchedule string   `yaml:"news_publish_schedule" toml:"news_publish_schedule" json:"news_publish_schedule" env:"JOBS_NEWS_PUBLISH_SCHEDULE" flag:"jobs-news-publish-schedule" usage:"cron spec for publishing scheduled news" binding:"required,cron"`
+}
+
+// EventsConfig: outbox chứa domain event và relay chuyển chúng cho consumer (webhook...)
+type EventsConfig struct {
+	DB           string   `yaml:"db" toml:"db" json:"db" env:"EVENTS_DB" flag:"events-db" usage:"SQLite file of the event outbox and consumer offsets" binding:"required"`
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval" json:"poll_interval" env:"EVENTS_POLL_INTERVAL" flag:"events-poll-interval" usage:"how often idle consumers read the outbox" binding:"gt=0"`
+	BatchSize    int      `yaml:"batch_size" toml:"batch_size" json:"batch_size" env:"EVENTS_BATCH_SIZE" flag:"events-batch-size" usage:"events read from the outbox at once" binding:"gt=0"`
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts" json:"max_attempts" env:"EVENTS_MAX_ATTEMPTS" flag:"events-max-attempts" usage:"handler attempts before a consumer skips an event" binding:"gt=0"`
+	RetryBase    Duration `yaml:"retry_base" toml:"retry_base" json:"retry_base" env:"EVENTS_RETRY_BASE" flag:"events-retry-base" usage:"delay before the first handler retry, doubled on each attempt" binding:"gt=0"`
+	RetryMax     Duration `yaml:"retry_max" toml:"retry_max" json:"retry_max" env:"EVENTS_RETRY_MAX" flag:"events-retry-max" usage:"max delay between handler retries" binding:"gt=0"`
+	Retention    Duration `yaml:"retention" toml:"retention" json:"retention" env:"EVENTS_RETENTION" flag:"events-retention" usage:"how long events handled by every consumer are kept" binding:"gt=0"`
+}
+
+// WebhooksConfig: gửi event cho endpoint của đối tác qua queue webhooks của JobsConfig
+type WebhooksConfig struct {
+	Timeout      Duration `yaml:"timeout" toml:"timeout" json:"timeout" env:"WEBHOOKS_TIMEOUT" flag:"webhooks-timeout" usage:"time limit of one delivery attempt" binding:"gt=0"`
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts" json:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" flag:"webhooks-max-attempts" usage:"attempts before a delivery is marked failed" binding:"gt=0"`
+	DisableAfter int      `yaml:"disable_after" toml:"disable_after" json:"disable_after" env:"WEBHOOKS_DISABLE_AFTER" flag:"webhooks-disable-after" usage:"consecutive failed attempts before a subscription is disabled" binding:"gt=0"`
+	Concurrency  int      `yaml:"concurrency" toml:"concurrency" json:"concurrency" env:"WEBHOOKS_CONCURRENCY" flag:"webhooks-concurrency" usage:"deliveries sent at once" binding:"gt=0"`
+	AllowPrivate bool     `yaml:"allow_private" toml:"allow_private" json:"allow_private" env:"WEBHOOKS_ALLOW_PRIVATE" flag:"webhooks-allow-private" usage:"allow delivering to loopback and private network addresses (development only)"`
+}
+
+// StreamConfig: /api/v1/stream đẩy thay đổi giá sản phẩm và tin mới qua SSE / WebSocket
+type StreamConfig struct {
+	History      int      `yaml:"history" toml:"history" json:"history" env:"STREAM_HISTORY" flag:"stream-history" usage:"recent messages kept for Last-Event-ID resume" binding:"gt=0"`
+	ClientBuffer int      `yaml:"client_buffer" toml:"client_buffer" json:"client_buffer" env:"STREAM_CLIENT_BUFFER" flag:"stream-client-buffer" usage:"messages queued per client before a slow client is disconnected" binding:"gt=0"`
+	Heartbeat    Duration `yaml:"heartbeat" toml:"heartbeat" json:"heartbeat" env:"STREAM_HEARTBEAT" flag:"stream-heartbeat" usage:"interval of SSE keep-alive comments and WebSocket pings" binding:"gt=0"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" json:"write_timeout" env:"STREAM_WRITE_TIMEOUT" flag:"stream-write-timeout" usage:"max time to write one message to a stream client" binding:"gt=0"`
+	// APIKeys: key (header X-API-Key hoặc query api_key) được mở WebSocket stream, rỗng thì /stream/ws bị tắt.
+	// Nên đặt bằng MAMBA_STREAM_API_KEYS_FILE, cách nhau bởi dấu phẩy.
+	APIKeys []string `yaml:"api_keys" toml:"api_keys" json:"api_keys" env:"STREAM_API_KEYS"`
+}
+
+// CacheConfig: response cache trong bộ nhớ cho các route GET theo slug
+type CacheConfig struct {
+	Enabled              bool     `yaml:"enabled" toml:"enabled" json:"enabled" env:"CACHE_ENABLED" flag:"cache-enabled" usage:"cache responses of read-heavy GET routes"`
+	MaxEntries           int      `yaml:"max_entries" toml:"max_entries" json:"max_entries" env:"CACHE_MAX_ENTRIES" flag:"cache-max-entries" usage:"max cached responses before least recently used ones are evicted" binding:"gt=0"`
+	MaxSize              ByteSize `yaml:"max_size" toml:"max_size" json:"max_size" env:"CACHE_MAX_SIZE" flag:"cache-max-size" usage:"max total size of cached responses, e.g. 64MB" binding:"gt=0"`
+	TTL                  Duration `yaml:"ttl" toml:"ttl" json:"ttl" env:"CACHE_TTL" flag:"cache-ttl" usage:"how long a cached response is served without revalidation" binding:"gt=0"`
+	StaleWhileRevalidate Duration `yaml:"stale_while_revalidate" toml:"stale_while_revalidate" json:"stale_while_revalidate" env:"CACHE_STALE_WHILE_REVALIDATE" flag:"cache-stale-while-revalidate" usage:"how long an expired response is still served while it is refreshed in the background" binding:"gte=0"`
+}
+
+// CompressionConfig: nén response theo Accept-Encoding (zstd, gzip, deflate)
+type CompressionConfig struct {
+	Enabled bool     `yaml:"enabled" toml:"enabled" json:"enabled" env:"COMPRESSION_ENABLED" flag:"compression-enabled" usage:"compress responses with zstd, gzip or deflate when the client accepts it"`
+	MinSize ByteSize `yaml:"min_size" toml:"min_size" json:"min_size" env:"COMPRESSION_MIN_SIZE" flag:"compression-min-size" usage:"responses smaller than this are sent uncompressed, e.g. 1KB" binding:"gte=0"`
+}
+
+type VersioningConfig struct {
+	File string `yaml:"file" toml:"file" json:"file" env:"VERSIONING_FILE" flag:"versions-config" usage:"API version lifecycle config (.json, .yaml); defaults to v1 deprecated in favour of v2"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(60 * time.Second),
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   Duration(30 * time.Second),
+			DrainDelay:        Duration(5 * time.Second),
+			TLS: TLSConfig{
+				ReloadInterval: Duration(time.Minute),
+			},
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Upload: UploadConfig{
+			Dir:              "./uploads",
+			MaxSize:          5 << 20,
+			AllowedExts:      []string{".jpg", ".jpeg", ".png"},
+			AllowedMimeTypes: []string{"image/jpeg", "image/png"},
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			File:         "traces.jsonl",
+			OTLPEndpoint: "http://localhost:4318",
+			SampleRatio:  1,
+			ServiceName:  "mamba-api",
+		},
+		Health: HealthConfig{
+			CacheTTL:      Duration(2 * time.Second),
+			MinFreeDiskMB: 100,
+		},
+		RateLimit: RateLimitConfig{
+			Store:     "memory",
+			RedisAddr: "localhost:6379",
+			API:       Rate{Requests: 300, Period: time.Minute},
+			Upload:    Rate{Requests: 20, Period: time.Minute},
+			Search:    Rate{Requests: 60, Period: time.Minute},
+			Admin:     Rate{Requests: 60, Period: time.Minute},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         Duration(24 * time.Hour),
+			WaitTimeout: Duration(10 * time.Second),
+		},
+		Concurrency: ConcurrencyConfig{
+			RequireIfMatch: true,
+		},
+		Bulk: BulkConfig{
+			MaxItems: 100,
+		},
+		Import: ImportConfig{
+			MaxSize: 10 << 20,
+			MaxRows: 10000,
+			TTL:     Duration(24 * time.Hour),
+		},
+		Jobs: JobsConfig{
+			DB:                  "./data/jobs.db",
+			PollInterval:        Duration(time.Second),
+			MaxAttempts:         5,
+			RetryBase:           Duration(5 * time.Second),
+			RetryMax:            Duration(10 * time.Minute),
+			Timeout:             Duration(5 * time.Minute),
+			Retention:           Duration(7 * 24 * time.Hour),
+			DefaultConcurrency:  4,
+			MediaConcurrency:    2,
+			NewsPublishSchedule: "* * * * *",
+		},
+		Events: EventsConfig{
+			DB:           "./data/events.db",
+			PollInterval: Duration(time.Second),
+			BatchSize:    100,
+			MaxAttempts:  10,
+			RetryBase:    Duration(time.Second),
+			RetryMax:     Duration(time.Minute),
+			Retention:    Duration(7 * 24 * time.Hour),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:      Duration(10 * time.Second),
+			MaxAttempts:  8,
+			DisableAfter: 20,
+			Concurrency:  4,
+		},
+		Stream: StreamConfig{
+			History:      1000,
+			ClientBuffer: 64,
+			Heartbeat:    Duration(15 * time.Second),
+			WriteTimeout: Duration(10 * time.Second),
+		},
+		Cache: CacheConfig{
+			Enabled:              true,
+			MaxEntries:           10000,
+			MaxSize:              64 << 20,
+			TTL:                  Duration(30 * time.Second),
+			StaleWhileRevalidate: Duration(time.Minute),
+		},
+		Compression: CompressionConfig{
+			Enabled: true,
+			MinSize: 1 << 10,
+		},
+	}
+}
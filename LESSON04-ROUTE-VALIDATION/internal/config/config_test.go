@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"mamba.com/route-group/utils"
+)
+
+func TestMain(m *testing.M) {
+	if err := utils.RegisterValidators(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  addr: \":9000\"\nlog:\n  level: warn\n  format: text\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		addr   string
+		level  string
+		format string
+	}{
+		{"defaults", nil, nil, ":8080", "info", "json"},
+		{"file", []string{"-config", file}, nil, ":9000", "warn", "text"},
+		{"file from env", nil, map[string]string{"MAMBA_CONFIG": file}, ":9000", "warn", "text"},
+		{"env over file", []string{"-config", file}, map[string]string{"MAMBA_LOG_LEVEL": "error", "MAMBA_SERVER_ADDR": ":9100"}, ":9100", "error", "text"},
+		{"flag over env", []string{"-config", file, "-addr", ":9200"}, map[string]string{"MAMBA_SERVER_ADDR": ":9100"}, ":9200", "warn", "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg, err := NewLoader("test", tt.args).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Addr != tt.addr || cfg.Log.Level != tt.level || cfg.Log.Format != tt.format {
+				t.Errorf("addr = %q, level = %q, format = %q, want %q %q %q",
+					cfg.Server.Addr, cfg.Log.Level, cfg.Log.Format, tt.addr, tt.level, tt.format)
+			}
+			// Field không đặt ở đâu giữ giá trị mặc định
+			if cfg.Upload.Dir != Default().Upload.Dir {
+				t.Errorf("upload.dir = %q", cfg.Upload.Dir)
+			}
+		})
+	}
+}
+
+func TestFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"config.toml", "[log]\nlevel = \"debug\"\n", false},
+		{"config.json", `{"log": {"level": "debug"}}`, false},
+		{"config.yml", "log:\n  level: debug\n", false},
+		{"config.yaml", "log:\n  levle: debug\n", true},
+		{"config.ini", "level=debug", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewLoader("test", []string{"-config", writeFile(t, tt.name, tt.content)}).Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil || cfg.Log.Level != "debug" {
+				t.Errorf("Load = %v, %v", cfg, err)
+			}
+		})
+	}
+}
+
+func TestSecretFile(t *testing.T) {
+	secret := writeFile(t, "redis_password", "from-file\n")
+
+	t.Setenv("MAMBA_RATELIMIT_REDIS_PASSWORD_FILE", secret)
+	cfg, err := NewLoader("test", nil).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RateLimit.RedisPassword != "from-file" {
+		t.Errorf("password = %q, want content of the file without trailing newline", cfg.RateLimit.RedisPassword)
+	}
+
+	// Biến môi trường đặt trực tiếp được ưu tiên hơn _FILE
+	t.Setenv("MAMBA_RATELIMIT_REDIS_PASSWORD", "from-env")
+	if cfg, err := NewLoader("test", nil).Load(); err != nil || cfg.RateLimit.RedisPassword != "from-env" {
+		t.Errorf("password = %v, %v, want from-env", cfg, err)
+	}
+
+	os.Unsetenv("MAMBA_RATELIMIT_REDIS_PASSWORD")
+	t.Setenv("MAMBA_RATELIMIT_REDIS_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := NewLoader("test", nil).Load(); err == nil {
+		t.Error("missing secret file should fail")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Setenv("MAMBA_LOG_LEVEL", "verbose")
+	_, err := NewLoader("test", nil).Load()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want ValidationError", err)
+	}
+	if _, ok := validationErr.Fields["log.level"]; !ok {
+		t.Errorf("fields = %v, want log.level", validationErr.Fields)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  addr: \":9000\"\nlog:\n  level: info\n")
+	loader := NewLoader("test", []string{"-config", path})
+	current, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Đổi cả phần cần restart lẫn phần đổi được lúc chạy
+	write("server:\n  addr: \":9100\"\nlog:\n  level: debug\nbulk:\n  max_items: 10\n")
+	next, err := loader.reload(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Log.Level != "debug" || next.Bulk.MaxItems != 10 {
+		t.Errorf("reloadable fields not applied: level = %q, bulk.max_items = %d", next.Log.Level, next.Bulk.MaxItems)
+	}
+	if next.Server.Addr != ":9000" {
+		t.Errorf("server.addr = %q, want the running value :9000 until restart", next.Server.Addr)
+	}
+
+	reloaded, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed := RestartRequired(next, reloaded); !slices.Equal(changed, []string{"server"}) {
+		t.Errorf("RestartRequired = %v, want [server]", changed)
+	}
+	if changed := RestartRequired(current, next); len(changed) != 0 {
+		t.Errorf("effective config changed restart-only sections %v", changed)
+	}
+
+	// File lỗi thì giữ config cũ
+	write("log:\n  level: loud\n")
+	if _, err := loader.reload(next); err == nil {
+		t.Error("invalid config should not be reloaded")
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"mamba.com/route-group/utils"
+)
+
+const EnvPrefix = "MAMBA_"
+
+// Loader đọc Config theo thứ tự ưu tiên tăng dần: giá trị mặc định, file (-config
+// hoặc MAMBA_CONFIG), biến môi trường, flag. Load gọi lại được (SIGHUP) với cùng flag.
+type Loader struct {
+	fs         *flag.FlagSet
+	args       []string
+	configPath *string
+	flags      map[string]string
+}
+
+func NewLoader(name string, args []string) *Loader {
+	l := &Loader{
+		fs:    flag.NewFlagSet(name, flag.ExitOnError),
+		args:  args,
+		flags: make(map[string]string),
+	}
+	l.configPath = l.fs.String("config", "", "config file (.yaml, .yml, .toml, .json), also "+EnvPrefix+"CONFIG")
+
+	defaults := Default()
+	walk(reflect.ValueOf(&defaults).Elem(), "", func(f leaf) {
+		if f.flag == "" {
+			return
+		}
+		l.fs.Var(&flagValue{loader: l, name: f.flag, value: f.value}, f.flag, f.usage)
+	})
+
+	return l
+}
+
+// FlagSet để main khai báo thêm flag không thuộc Config (VD: -openapi-out) trước khi Load
+func (l *Loader) FlagSet() *flag.FlagSet {
+	return l.fs
+}
+
+func (l *Loader) Load() (*Config, error) {
+	if !l.fs.Parsed() {
+		if err := l.fs.Parse(l.args); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := Default()
+
+	path := *l.configPath
+	if path == "" {
+		path = os.Getenv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := decodeFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(f leaf) {
+		if f.env == "" {
+			return
+		}
+		value, ok, err := lookupEnv(EnvPrefix + f.env)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if ok {
+			if err := setValue(f.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s%s: %w", EnvPrefix, f.env, err))
+			}
+		}
+	})
+
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(f leaf) {
+		value, ok := l.flags[f.flag]
+		if f.flag == "" || !ok {
+			return
+		}
+		if err := setValue(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.flag, err))
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if err := validate(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, cfg, yaml.DisallowUnknownField())
+	case ".toml":
+		decoder := toml.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	case ".json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("config: unsupported file type %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: cannot parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// lookupEnv đọc NAME, không có thì đọc nội dung file ở NAME_FILE (Docker/Kubernetes secret)
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+
+	path, ok := os.LookupEnv(name + "_FILE")
+	if !ok {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// ValidationError liệt kê field sai theo đường dẫn trong file, VD: upload.max_size
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, message := range e.Fields {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+// validate dùng chung validator (và các rule tự định nghĩa) với request của gin
+func validate(cfg *Config) error {
+	err := binding.Validator.ValidateStruct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields, _ := utils.HandleValidationError(validationErrors)["error"].(map[string]string)
+	return &ValidationError{Fields: fields}
+}
+
+type leaf struct {
+	value reflect.Value
+	env   string
+	flag  string
+	usage string
+}
+
+// walk gọi fn cho mọi field lá (không phải struct) của v
+func walk(v reflect.Value, prefix string, fn func(leaf)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		// Struct tự parse từ chuỗi (VD: Rate) là field lá
+		if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+			walk(value, prefix+field.Name+".", fn)
+			continue
+		}
+
+		fn(leaf{
+			value: value,
+			env:   field.Tag.Get("env"),
+			flag:  field.Tag.Get("flag"),
+			usage: field.Tag.Get("usage"),
+		})
+	}
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setValue gán chuỗi từ env/flag: list và map viết dạng "a,b" và "k=v,k2=v2"
+func setValue(v reflect.Value, raw string) error {
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// flagValue chỉ ghi lại chuỗi người dùng truyền, được gán vào Config sau file và env
+type flagValue struct {
+	loader *Loader
+	name   string
+	value  reflect.Value
+}
+
+func (f *flagValue) String() string {
+	if f == nil || !f.value.IsValid() {
+		return ""
+	}
+	return formatValue(f.value)
+}
+
+func (f *flagValue) Set(raw string) error {
+	// Kiểm tra ngay để lỗi cú pháp hiện cùng usage của flag
+	probe := reflect.New(f.value.Type()).Elem()
+	if err := setValue(probe, raw); err != nil {
+		return err
+	}
+	f.loader.flags[f.name] = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.value.IsValid() && f.value.Kind() == reflect.Bool
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+// copyReloadable chép các field đổi được lúc chạy từ src sang dst: log.level, upload.max_size,
+// upload.allowed_exts, upload.allowed_mime_types, quota rate_limit.api, rate_limit.upload,
+// rate_limit.search, rate_limit.admin, concurrency.require_if_match và bulk.max_items
+func copyReloadable(dst, src *Config) {
+	dst.Log.Level = src.Log.Level
+	dst.Upload.MaxSize = src.Upload.MaxSize
+	dst.Upload.AllowedExts = src.Upload.AllowedExts
+	dst.Upload.AllowedMimeTypes = src.Upload.AllowedMimeTypes
+	dst.RateLimit.API = src.RateLimit.API
+	dst.RateLimit.Upload = src.RateLimit.Upload
+	dst.RateLimit.Search = src.RateLimit.Search
+	dst.RateLimit.Admin = src.RateLimit.Admin
+	dst.Concurrency.RequireIfMatch = src.Concurrency.RequireIfMatch
+	dst.Bulk.MaxItems = src.Bulk.MaxItems
+}
+
+// RestartRequired trả về các nhóm cấu hình đã đổi nhưng chỉ có hiệu lực sau khi restart
+// (mọi field ngoài copyReloadable)
+func RestartRequired(current, next *Config) []string {
+	a, b := *current, *next
+	copyReloadable(&b, &a)
+
+	var changed []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := range va.NumField() {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, reflect.TypeFor[Config]().Field(i).Tag.Get("yaml"))
+		}
+	}
+	return changed
+}
+
+// reload đọc lại config và trả về config hiệu lực: phần đổi được lúc chạy lấy từ config mới,
+// phần cần restart giữ như current để config đang dùng khớp với server đang chạy
+func (l *Loader) reload(current *Config) (*Config, error) {
+	next, err := l.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if changed := RestartRequired(current, next); len(changed) > 0 {
+		slog.Warn("config changes need a restart to take effect", slog.Any("sections", changed))
+	}
+
+	effective := *current
+	copyReloadable(&effective, next)
+	return &effective, nil
+}
+
+// Watch đọc lại config mỗi lần nhận SIGHUP. Config mới không hợp lệ thì giữ config cũ.
+// apply được gọi tuần tự với config chỉ đổi phần đổi được lúc chạy.
+func (l *Loader) Watch(ctx context.Context, current *Config, apply func(*Config)) {
+	reloads := notifyReload()
+
+	go func() {
+		defer stopNotifyReload(reloads)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloads:
+			}
+
+			next, err := l.reload(current)
+			if err != nil {
+				slog.Error("config reload failed, keeping current config", slog.String("error", err.Error()))
+				continue
+			}
+
+			apply(next)
+			current = next
+			slog.Info("config reloaded")
+		}
+	}()
+}
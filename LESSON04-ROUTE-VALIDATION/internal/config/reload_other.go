@@ -0,0 +1,12 @@
+//go:build !unix
+
+package config
+
+import "os"
+
+// Không có SIGHUP, Watch không bao giờ reload
+func notifyReload() chan os.Signal {
+	return make(chan os.Signal)
+}
+
+func stopNotifyReload(ch chan os.Signal) {}
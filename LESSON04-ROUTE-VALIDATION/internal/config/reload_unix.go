@@ -0,0 +1,19 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func notifyReload() chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	return ch
+}
+
+func stopNotifyReload(ch chan os.Signal) {
+	signal.Stop(ch)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration đọc được dạng "5s", "1m30s" từ file, env và flag
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ByteSize đọc được số byte hoặc dạng "512KB", "5MB", "1GB" (hệ 1024)
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+func (b ByteSize) String() string {
+	for _, unit := range byteUnits {
+		if b >= ByteSize(unit.size) && int64(b)%unit.size == 0 {
+			return fmt.Sprintf("%d%s", int64(b)/unit.size, unit.suffix)
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	value := strings.ToUpper(strings.ReplaceAll(string(text), " ", ""))
+
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.size
+			value = strings.TrimSuffix(value, unit.suffix)
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid byte size %q", text)
+	}
+	*b = ByteSize(n * multiplier)
+	return nil
+}
+
+// Rate đọc được dạng "100/1m", "10/s"; "0" hoặc để trống là không giới hạn
+type Rate struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (r Rate) String() string {
+	if r.Requests == 0 {
+		return "0"
+	}
+	period := r.Period.String()
+	switch r.Period {
+	case time.Second:
+		period = "s"
+	case time.Minute:
+		period = "m"
+	case time.Hour:
+		period = "h"
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, period)
+}
+
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalText(text []byte) error {
+	value := strings.ReplaceAll(string(text), " ", "")
+	if value == "" || value == "0" {
+		*r = Rate{}
+		return nil
+	}
+
+	count, period, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return fmt.Errorf("invalid rate %q, want e.g. 100/1m", text)
+	}
+	// "10/s" nghĩa là "10/1s"
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid rate %q, want e.g. 100/1m", text)
+	}
+
+	*r = Rate{Requests: n, Period: d}
+	return nil
+}
@@ -0,0 +1,53 @@
+package durable
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open mở (hoặc tạo) file SQLite tại path rồi tạo schema.
+// SQLite chỉ cho 1 writer, dùng 1 connection để ghi đồng thời không bị "database is locked".
+func Open(path, schema string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(NORMAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return db, nil
+}
+
+// Millis là dạng lưu thời gian trong DB: unix millisecond
+func Millis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// Time đọc lại thời gian đã lưu bằng Millis, luôn ở UTC
+func Time(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+// Backoff là thời gian chờ trước lần thử thứ attempt + 1: tăng gấp đôi từ base tới limit,
+// có jitter để các lần lỗi cùng lúc không retry cùng lúc
+func Backoff(attempt int, base, limit time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	delay = min(delay, limit)
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,55 @@
+package durable_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mamba.com/route-group/internal/durable"
+)
+
+func TestBackoff(t *testing.T) {
+	base, limit := 100*time.Millisecond, time.Second
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		for range 100 {
+			// Jitter nằm trong nửa trên của delay đầy đủ
+			if got := durable.Backoff(tt.attempt, base, limit); got < tt.full/2 || got > tt.full {
+				t.Fatalf("Backoff(%d) = %v, want in [%v, %v]", tt.attempt, got, tt.full/2, tt.full)
+			}
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	at := time.Date(2026, 3, 1, 10, 30, 0, 123_456_789, time.FixedZone("ICT", 7*3600))
+	got := durable.Time(durable.Millis(at))
+	if !got.Equal(at.Truncate(time.Millisecond)) || got.Location() != time.UTC {
+		t.Errorf("round trip = %v, want %v in UTC", got, at)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "data.db")
+	db, err := durable.Open(path, `CREATE TABLE IF NOT EXISTS items (id INTEGER PRIMARY KEY)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO items DEFAULT VALUES`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := durable.Open(filepath.Join(t.TempDir(), "bad.db"), `NOT SQL`); err == nil {
+		t.Error("invalid schema should fail")
+	}
+}
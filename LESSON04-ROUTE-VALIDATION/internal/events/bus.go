@@ -0,0 +1,250 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/durable"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/tracing"
+)
+
+// Handler xử lý 1 event. Lỗi trả về làm event được gửi lại, nên handler phải chịu được
+// việc nhận trùng (VD: bỏ qua event.ID đã xử lý).
+type Handler func(ctx context.Context, event Event) error
+
+type Options struct {
+	// PollInterval: khoảng nghỉ giữa 2 lần đọc outbox khi đã hết event. Append đánh thức relay ngay.
+	PollInterval time.Duration
+	// BatchSize: số event đọc mỗi lần
+	BatchSize int
+	// MaxAttempts: số lần gọi handler cho 1 event, hết lượt thì ghi log và bỏ qua event để consumer không bị kẹt
+	MaxAttempts int
+	// Lần thử thứ n chờ khoảng RetryBase * 2^(n-1), tối đa RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Retention: event cũ hơn chừng này và mọi consumer đã xử lý thì bị xoá khỏi outbox
+	Retention time.Duration
+	// Heartbeat được gọi mỗi vòng lặp của relay
+	Heartbeat func()
+}
+
+type consumer struct {
+	name    string
+	handle  Handler
+	wake    <-chan struct{}
+	current int64
+}
+
+// Bus là relay đọc outbox và chuyển event cho các consumer trong process.
+// Mỗi consumer có offset riêng và xử lý event tuần tự theo thứ tự ghi, nên event của cùng
+// 1 aggregate luôn đến đúng thứ tự. Offset chỉ được ghi sau khi handler thành công
+// (at-least-once): process dừng giữa chừng thì event được gửi lại sau khi khởi động.
+type Bus struct {
+	outbox *Outbox
+	opts   Options
+
+	mu        sync.Mutex
+	consumers []*consumer
+	started   bool
+
+	// ctx dừng vòng lặp đọc outbox, handleCtx huỷ handler đang chạy khi hết thời gian shutdown
+	ctx          context.Context
+	cancel       context.CancelFunc
+	handleCtx    context.Context
+	handleCancel context.CancelFunc
+	loops        sync.WaitGroup
+}
+
+func NewBus(outbox *Outbox, opts Options) *Bus {
+	b := &Bus{outbox: outbox, opts: opts}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	b.handleCtx, b.handleCancel = context.WithCancel(context.Background())
+	return b
+}
+
+// Subscribe đăng ký consumer, phải gọi trước Start. Tên consumer là khoá của offset
+// nên không được đổi giữa các lần deploy.
+func (b *Bus) Subscribe(name string, handle Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		panic(fmt.Sprintf("events: consumer %q subscribed after Start", name))
+	}
+	for _, c := range b.consumers {
+		if c.name == name {
+			panic(fmt.Sprintf("events: consumer %q subscribed twice", name))
+		}
+	}
+	b.consumers = append(b.consumers, &consumer{name: name, handle: handle, wake: b.outbox.notify()})
+}
+
+// Start đọc offset của từng consumer rồi chạy relay cho chúng
+func (b *Bus) Start(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, c := range b.consumers {
+		position, err := b.outbox.Register(ctx, c.name)
+		if err != nil {
+			return fmt.Errorf("load offset of consumer %q: %w", c.name, err)
+		}
+		c.current = position
+	}
+	b.started = true
+
+	for _, c := range b.consumers {
+		b.loops.Go(func() { b.relay(c) })
+	}
+	b.loops.Go(b.prune)
+	return nil
+}
+
+// Close ngừng đọc event mới và chờ handler đang chạy xong. Hết ctx thì huỷ chúng,
+// event đang xử lý dở chưa được commit nên sẽ được gửi lại lần sau.
+func (b *Bus) Close(ctx context.Context) error {
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.loops.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		b.handleCancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Offsets trả về offset của các consumer
+func (b *Bus) Offsets(ctx context.Context) ([]ConsumerOffset, error) {
+	return b.outbox.Offsets(ctx)
+}
+
+func (b *Bus) relay(c *consumer) {
+	ticker := time.NewTicker(b.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if b.opts.Heartbeat != nil {
+			b.opts.Heartbeat()
+		}
+
+		events, err := b.outbox.Read(b.ctx, c.current, b.opts.BatchSize)
+		if err != nil && b.ctx.Err() == nil {
+			slog.Error("read outbox failed", slog.String("consumer", c.name), slog.String("error", err.Error()))
+		}
+
+		for _, event := range events {
+			if !b.deliver(c, event) {
+				return
+			}
+			if err := b.outbox.Commit(b.handleCtx, c.name, event.Position); err != nil {
+				slog.Error("commit consumer offset failed",
+					slog.String("consumer", c.name),
+					slog.Int64("position", event.Position),
+					slog.String("error", err.Error()),
+				)
+			}
+			c.current = event.Position
+		}
+
+		// Batch đầy thì đọc tiếp ngay, còn lại thì chờ event mới
+		if len(events) == b.opts.BatchSize && err == nil {
+			continue
+		}
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-c.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver gọi handler đến khi thành công hoặc hết lượt thử.
+// Trả về false khi bus đang dừng và event chưa được xử lý xong.
+func (b *Bus) deliver(c *consumer, event Event) bool {
+	logger := slog.Default().With(
+		slog.String("consumer", c.name),
+		slog.String("event_id", event.ID),
+		slog.String("event_type", event.Type),
+		slog.Int64("position", event.Position),
+	)
+
+	for attempt := 1; ; attempt++ {
+		err := b.handle(logging.WithLogger(b.handleCtx, logger), c, event, attempt)
+		if err == nil {
+			metrics.EventsHandled.Inc(c.name, "succeeded")
+			return true
+		}
+		if b.ctx.Err() != nil {
+			return false
+		}
+		if attempt >= b.opts.MaxAttempts {
+			metrics.EventsHandled.Inc(c.name, "skipped")
+			logger.Error("event skipped after failed attempts", slog.Int("attempts", attempt), slog.String("error", err.Error()))
+			return true
+		}
+
+		metrics.EventsHandled.Inc(c.name, "retried")
+		logger.Warn("event handler failed, retrying", slog.Int("attempt", attempt), slog.String("error", err.Error()))
+		select {
+		case <-b.ctx.Done():
+			return false
+		case <-time.After(durable.Backoff(attempt, b.opts.RetryBase, b.opts.RetryMax)):
+		}
+	}
+}
+
+func (b *Bus) handle(ctx context.Context, c *consumer, event Event, attempt int) (err error) {
+	ctx, span := tracing.Start(ctx, "events."+c.name,
+		tracing.String("event.id", event.ID),
+		tracing.String("event.type", event.Type),
+		tracing.String("event.aggregate", event.AggregateType+":"+event.AggregateID),
+		tracing.Int("event.attempt", attempt),
+	)
+	defer span.End()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		span.RecordError(err)
+	}()
+
+	return c.handle(ctx, event)
+}
+
+// prune xoá event đã được mọi consumer xử lý, mỗi giờ 1 lần
+func (b *Bus) prune() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := b.outbox.Prune(b.ctx, time.Now().Add(-b.opts.Retention))
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("prune outbox failed", slog.String("error", err.Error()))
+			continue
+		}
+		if deleted > 0 {
+			slog.Info("pruned outbox", slog.Int64("deleted", deleted))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Loại event, dùng cả làm tên event của webhook
+const (
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+	ProductDeleted = "product.deleted"
+	NewsPublished  = "news.published"
+	UserCreated    = "user.created"
+	UserUpdated    = "user.updated"
+	UserDeleted    = "user.deleted"
+)
+
+const (
+	AggregateProduct = "product"
+	AggregateNews    = "news"
+	AggregateUser    = "user"
+)
+
+// Event là 1 thay đổi của domain đã được ghi vào outbox.
+// Position do outbox cấp, tăng dần theo thứ tự ghi.
+type Event struct {
+	Position      int64           `json:"position"`
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Data          json.RawMessage `json:"data"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// New tạo event với Data là bản JSON của data tại thời điểm gọi
+func New(eventType, aggregateType, aggregateID string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Data:          raw,
+		OccurredAt:    time.Now().UTC(),
+	}, nil
+}
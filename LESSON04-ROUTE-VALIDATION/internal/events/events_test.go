@@ -0,0 +1,236 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+)
+
+func openOutbox(t *testing.T) *events.Outbox {
+	t.Helper()
+	outbox, err := events.OpenOutbox(filepath.Join(t.TempDir(), "outbox.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { outbox.Close() })
+	return outbox
+}
+
+func newBus(outbox *events.Outbox, maxAttempts int) *events.Bus {
+	return events.NewBus(outbox, events.Options{
+		PollInterval: 5 * time.Millisecond,
+		BatchSize:    10,
+		MaxAttempts:  maxAttempts,
+		RetryBase:    time.Millisecond,
+		RetryMax:     2 * time.Millisecond,
+		Retention:    time.Hour,
+	})
+}
+
+func start(t *testing.T, bus *events.Bus) {
+	t.Helper()
+	if err := bus.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bus.Close(context.Background()) })
+}
+
+func appendEvents(t *testing.T, outbox *events.Outbox, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		event, err := events.New(events.ProductUpdated, events.AggregateProduct, id, map[string]string{"id": id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := outbox.Append(context.Background(), &event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// recorder ghi lại aggregate id theo thứ tự handler được gọi
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+	// fail trả về số lần còn phải trả lỗi cho aggregate id, -1 là lỗi mãi
+	fail map[string]int
+}
+
+func (r *recorder) handle(ctx context.Context, event events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, event.AggregateID)
+	switch n := r.fail[event.AggregateID]; {
+	case n < 0:
+		return errors.New("handler always fails")
+	case n > 0:
+		r.fail[event.AggregateID] = n - 1
+		return errors.New("handler fails for now")
+	}
+	return nil
+}
+
+func (r *recorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+func TestConsumerOffsets(t *testing.T) {
+	outbox := openOutbox(t)
+	// Event ghi trước khi consumer đăng ký lần đầu không được gửi cho consumer đó
+	appendEvents(t, outbox, "0")
+
+	fast, slow := &recorder{}, &recorder{fail: map[string]int{"2": 1}}
+	bus := newBus(outbox, 3)
+	bus.Subscribe("fast", fast.handle)
+	bus.Subscribe("slow", slow.handle)
+	start(t, bus)
+
+	appendEvents(t, outbox, "1", "2", "3")
+	waitFor(t, func() bool { return len(fast.snapshot()) == 3 && len(slow.snapshot()) == 4 })
+
+	if got := fast.snapshot(); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("fast received %v", got)
+	}
+	if got := slow.snapshot(); !slices.Equal(got, []string{"1", "2", "2", "3"}) {
+		t.Errorf("slow received %v", got)
+	}
+
+	waitFor(t, func() bool {
+		offsets, err := bus.Offsets(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(offsets) == 2 && offsets[0].Position == 4 && offsets[0].Lag == 0 && offsets[1].Position == 4
+	})
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bus mới tiếp tục từ offset đã lưu, consumer mới bắt đầu ở cuối outbox
+	appendEvents(t, outbox, "4")
+	resumed, late := &recorder{}, &recorder{}
+	bus = newBus(outbox, 3)
+	bus.Subscribe("fast", resumed.handle)
+	bus.Subscribe("late", late.handle)
+	start(t, bus)
+	appendEvents(t, outbox, "5")
+
+	waitFor(t, func() bool { return len(resumed.snapshot()) == 2 && len(late.snapshot()) == 1 })
+	if got := resumed.snapshot(); !slices.Equal(got, []string{"4", "5"}) {
+		t.Errorf("resumed consumer received %v", got)
+	}
+	if got := late.snapshot(); !slices.Equal(got, []string{"5"}) {
+		t.Errorf("late consumer received %v", got)
+	}
+}
+
+func TestRedeliveryAndSkip(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		fail        int
+		want        []string
+	}{
+		{"redelivered until success", 5, 2, []string{"1", "1", "1", "2"}},
+		{"skipped after max attempts", 3, -1, []string{"1", "1", "1", "2"}},
+		{"single attempt", 1, -1, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := openOutbox(t)
+			handler := &recorder{fail: map[string]int{"1": tt.fail}}
+			bus := newBus(outbox, tt.maxAttempts)
+			bus.Subscribe("consumer", handler.handle)
+			start(t, bus)
+
+			appendEvents(t, outbox, "1", "2")
+			waitFor(t, func() bool { return len(handler.snapshot()) >= len(tt.want) })
+			// Cho relay thời gian để lộ lần gọi thừa nếu có
+			time.Sleep(20 * time.Millisecond)
+			if got := handler.snapshot(); !slices.Equal(got, tt.want) {
+				t.Errorf("handler calls = %v, want %v", got, tt.want)
+			}
+
+			waitFor(t, func() bool {
+				offsets, _ := bus.Offsets(context.Background())
+				return len(offsets) == 1 && offsets[0].Position == 2
+			})
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	outbox := openOutbox(t)
+	for _, consumer := range []string{"a", "b"} {
+		if _, err := outbox.Register(ctx, consumer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, id := range []string{"1", "2", "3"} {
+		event, err := events.New(events.NewsPublished, events.AggregateNews, id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.OccurredAt = old
+		if err := outbox.Append(ctx, &event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendEvents(t, outbox, "4")
+
+	// Consumer chậm nhất mới xử lý đến position 2
+	if err := outbox.Commit(ctx, "a", 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Commit(ctx, "b", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Commit(ctx, "missing", 1); !errors.Is(err, events.ErrUnknownConsumer) {
+		t.Errorf("commit unknown consumer: err = %v", err)
+	}
+
+	deleted, err := outbox.Prune(ctx, time.Now().Add(-time.Hour))
+	if err != nil || deleted != 2 {
+		t.Fatalf("Prune = %d, %v, want 2", deleted, err)
+	}
+
+	if err := outbox.Commit(ctx, "b", 4); err != nil {
+		t.Fatal(err)
+	}
+	// Event 4 mới ghi, chưa quá retention nên được giữ
+	deleted, err = outbox.Prune(ctx, time.Now().Add(-time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("second Prune = %d, %v, want 1", deleted, err)
+	}
+
+	remaining, err := outbox.Read(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].Position != 4 {
+		t.Errorf("remaining events = %+v, want only position 4", remaining)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
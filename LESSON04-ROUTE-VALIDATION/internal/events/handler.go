@@ -0,0 +1,51 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/utils"
+)
+
+type GetEventsParam struct {
+	After int64 `form:"after" binding:"omitempty,gte=0"`
+	Limit int   `form:"limit" binding:"omitempty,gte=1,lte=200"`
+}
+
+// GetEvents đọc outbox từ sau position after, dùng để xem event consumer đang kẹt
+func (b *Bus) GetEvents(ctx *gin.Context) {
+	var params GetEventsParam
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = 50
+	}
+
+	events, err := b.outbox.Read(ctx.Request.Context(), params.After, params.Limit)
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot read events"})
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"events": events,
+		"after":  params.After,
+		"limit":  params.Limit,
+	})
+}
+
+// GetConsumers trả về offset và số event còn chờ của từng consumer
+func (b *Bus) GetConsumers(ctx *gin.Context) {
+	offsets, err := b.Offsets(ctx.Request.Context())
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot read consumer offsets"})
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{"consumers": offsets})
+}
@@ -0,0 +1,235 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/durable"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/tracing"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS outbox (
+	position       INTEGER PRIMARY KEY AUTOINCREMENT,
+	id             TEXT    NOT NULL UNIQUE,
+	type           TEXT    NOT NULL,
+	aggregate_type TEXT    NOT NULL,
+	aggregate_id   TEXT    NOT NULL,
+	data           BLOB    NOT NULL,
+	occurred_at    INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS consumer_offsets (
+	consumer   TEXT    PRIMARY KEY,
+	position   INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+`
+
+const eventColumns = `position, id, type, aggregate_type, aggregate_id, data, occurred_at`
+
+var ErrUnknownConsumer = errors.New("unknown event consumer")
+
+// Outbox lưu event trong SQLite, cùng vị trí đã xử lý (offset) của từng consumer
+type Outbox struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	waiters []chan struct{}
+}
+
+// ConsumerOffset là vị trí consumer đã xử lý xong, Lag là số event còn chờ
+type ConsumerOffset struct {
+	Consumer  string    `json:"consumer"`
+	Position  int64     `json:"position"`
+	Lag       int64     `json:"lag"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OpenOutbox mở (hoặc tạo) file SQLite tại path
+func OpenOutbox(path string) (*Outbox, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := durable.Open(path, schema)
+	if err != nil {
+		return nil, fmt.Errorf("events outbox: %w", err)
+	}
+	return &Outbox{db: db}, nil
+}
+
+func (o *Outbox) Close() error {
+	return o.db.Close()
+}
+
+// Ping cho health check readiness
+func (o *Outbox) Ping(ctx context.Context) error {
+	return o.db.PingContext(ctx)
+}
+
+// Append ghi các event trong 1 transaction: hoặc tất cả được ghi, hoặc không event nào.
+// Repository gọi Append khi đang giữ lock, sau khi áp dụng thay đổi, Append lỗi
+// thì repository undo thay đổi đó.
+func (o *Outbox) Append(ctx context.Context, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "Outbox.Append", tracing.Int("events.count", len(events)))
+	defer span.End()
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO outbox (id, type, aggregate_type, aggregate_id, data, occurred_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			RETURNING position`,
+			event.ID, event.Type, event.AggregateType, event.AggregateID, []byte(event.Data), durable.Millis(event.OccurredAt),
+		).Scan(&event.Position)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	for _, event := range events {
+		metrics.EventsAppended.Inc(event.Type)
+	}
+	o.wake()
+	return nil
+}
+
+// notify trả về channel nhận tín hiệu mỗi khi có event mới, để relay không phải chờ tới lượt poll
+func (o *Outbox) notify() <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	o.waiters = append(o.waiters, ch)
+	return ch
+}
+
+func (o *Outbox) wake() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, ch := range o.waiters {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Read trả về tối đa limit event có position > after, theo thứ tự ghi
+func (o *Outbox) Read(ctx context.Context, after int64, limit int) ([]Event, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT `+eventColumns+` FROM outbox WHERE position > ? ORDER BY position LIMIT ?`,
+		after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var data []byte
+		var occurredAt int64
+		if err := rows.Scan(&event.Position, &event.ID, &event.Type, &event.AggregateType, &event.AggregateID, &data, &occurredAt); err != nil {
+			return nil, err
+		}
+		event.Data = data
+		event.OccurredAt = durable.Time(occurredAt)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Register tạo offset cho consumer mới tại cuối outbox: consumer thêm sau không nhận lại event cũ.
+// Consumer đã có offset thì giữ nguyên để tiếp tục từ chỗ đã dừng.
+func (o *Outbox) Register(ctx context.Context, consumer string) (int64, error) {
+	_, err := o.db.ExecContext(ctx, `
+		INSERT INTO consumer_offsets (consumer, position, updated_at)
+		VALUES (?, (SELECT COALESCE(MAX(position), 0) FROM outbox), ?)
+		ON CONFLICT (consumer) DO NOTHING`,
+		consumer, durable.Millis(time.Now()),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var position int64
+	err = o.db.QueryRowContext(ctx, `SELECT position FROM consumer_offsets WHERE consumer = ?`, consumer).Scan(&position)
+	return position, err
+}
+
+// Commit ghi vị trí consumer đã xử lý xong
+func (o *Outbox) Commit(ctx context.Context, consumer string, position int64) error {
+	result, err := o.db.ExecContext(ctx, `
+		UPDATE consumer_offsets SET position = ?, updated_at = ? WHERE consumer = ?`,
+		position, durable.Millis(time.Now()), consumer,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUnknownConsumer
+	}
+	return nil
+}
+
+// Offsets trả về offset và độ trễ của mọi consumer, dùng cho trang admin
+func (o *Outbox) Offsets(ctx context.Context) ([]ConsumerOffset, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT c.consumer, c.position, c.updated_at,
+			(SELECT COUNT(*) FROM outbox WHERE position > c.position)
+		FROM consumer_offsets c ORDER BY c.consumer`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offsets := []ConsumerOffset{}
+	for rows.Next() {
+		var offset ConsumerOffset
+		var updatedAt int64
+		if err := rows.Scan(&offset.Consumer, &offset.Position, &updatedAt, &offset.Lag); err != nil {
+			return nil, err
+		}
+		offset.UpdatedAt = durable.Time(updatedAt)
+		offsets = append(offsets, offset)
+	}
+	return offsets, rows.Err()
+}
+
+// Prune xoá event cũ hơn before mà mọi consumer đã xử lý xong
+func (o *Outbox) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := o.db.ExecContext(ctx, `
+		DELETE FROM outbox
+		WHERE occurred_at < ? AND position <= (SELECT COALESCE(MIN(position), 0) FROM consumer_offsets)`,
+		durable.Millis(before),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
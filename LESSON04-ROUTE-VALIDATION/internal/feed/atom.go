@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type atomDocument struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Links     []atomLink    `xml:"link"`
+	Category  *atomCategory `xml:"category"`
+	Summary   string        `xml:"summary,omitempty"`
+}
+
+// Atom tạo Atom 1.0 (RFC 4287). Mỗi hình được đưa vào một link rel="enclosure".
+func Atom(f Feed) ([]byte, error) {
+	doc := atomDocument{
+		ID:      f.FeedURL,
+		Title:   f.Title,
+		Updated: atomTime(f.Updated),
+		Author:  atomAuthor{Name: f.Author},
+		Links: []atomLink{
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.SiteURL, Rel: "alternate"},
+		},
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Updated:   atomTime(item.Updated),
+			Published: atomTime(item.Published),
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate"}},
+			Summary:   item.Content,
+		}
+
+		if item.Category != "" {
+			entry.Category = &atomCategory{Term: item.Category}
+		}
+
+		for _, e := range item.Enclosures {
+			entry.Links = append(entry.Links, atomLink{
+				Href:   e.URL,
+				Rel:    "enclosure",
+				Type:   e.Type,
+				Length: e.Length,
+			})
+		}
+
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalXML(doc)
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
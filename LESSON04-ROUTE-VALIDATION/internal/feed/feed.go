@@ -0,0 +1,38 @@
+package feed
+
+import "time"
+
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+// Feed là dạng trung gian, dùng chung cho cả RSS, Atom và JSON Feed.
+type Feed struct {
+	Title       string
+	Description string
+	SiteURL     string
+	FeedURL     string
+	Author      string
+	Language    string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID         string
+	Title      string
+	Link       string
+	Content    string
+	Category   string
+	Published  time.Time
+	Updated    time.Time
+	Enclosures []Enclosure
+}
+
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
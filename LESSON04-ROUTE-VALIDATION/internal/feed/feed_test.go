@@ -0,0 +1,297 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "ghi lại file golden trong testdata")
+
+func testFeed() Feed {
+	published := time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC)
+	updated := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	return Feed{
+		Title:       "Mamba News - golang",
+		Description: "Tin tức mới nhất từ Mamba",
+		SiteURL:     "https://example.com/api/v1/news",
+		FeedURL:     "https://example.com/api/v1/news/feed.rss?category=golang",
+		Author:      "Mamba",
+		Language:    "vi",
+		Updated:     updated,
+		Items: []Item{
+			{
+				ID:        "https://example.com/api/v1/news/go-1-25",
+				Title:     "Go 1.25 & generics",
+				Link:      "https://example.com/api/v1/news/go-1-25",
+				Content:   "Bản phát hành <mới>",
+				Category:  "golang",
+				Published: published,
+				Updated:   updated,
+				Enclosures: []Enclosure{
+					{URL: "https://example.com/uploads/cover.jpg", Type: "image/jpeg", Length: 2048},
+					{URL: "https://example.com/uploads/chart.png", Type: "image/png", Length: 512},
+				},
+			},
+			{
+				ID:        "https://example.com/api/v1/news/gin-tips",
+				Title:     "Gin tips",
+				Link:      "https://example.com/api/v1/news/gin-tips",
+				Content:   "Không có hình",
+				Category:  "golang",
+				Published: published.Add(-24 * time.Hour),
+				Updated:   published.Add(-24 * time.Hour),
+			},
+		},
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		golden   string
+		encode   func(Feed) ([]byte, error)
+		validate func(*testing.T, []byte)
+	}{
+		{"rss.xml", RSS, validateRSS},
+		{"atom.xml", Atom, validateAtom},
+		{"feed.json", JSON, validateJSONFeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.encode(testFeed())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.validate(t, got)
+
+			path := filepath.Join("testdata", tt.golden+".golden")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s (chạy go test -update để cập nhật):\n%s", path, got)
+			}
+		})
+	}
+}
+
+// Các validate* kiểm tra những phần bắt buộc của từng spec, bổ sung cho golden
+// vì golden chỉ bắt được thay đổi chứ không biết output cũ có đúng spec hay không.
+
+func validateRSS(t *testing.T, data []byte) {
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title       string `xml:"title"`
+			Description string `xml:"description"`
+			// <link> và <atom:link> cùng tên local nên phải tách theo namespace
+			Links []struct {
+				XMLName xml.Name
+				Href    string `xml:"href,attr"`
+				Rel     string `xml:"rel,attr"`
+				Value   string `xml:",chardata"`
+			} `xml:"link"`
+			Items []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+				GUID        string `xml:"guid"`
+				Enclosures  []struct {
+					URL    string `xml:"url,attr"`
+					Length string `xml:"length,attr"`
+					Type   string `xml:"type,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "2.0" {
+		t.Errorf("version = %q", doc.Version)
+	}
+	c := doc.Channel
+	var link, self string
+	for _, l := range c.Links {
+		switch {
+		case l.XMLName.Space == "":
+			link = l.Value
+		case l.XMLName.Space == "http://www.w3.org/2005/Atom" && l.Rel == "self":
+			self = l.Href
+		}
+	}
+	if c.Title == "" || !absoluteURL(link) || c.Description == "" {
+		t.Error("channel requires title, link and description")
+	}
+	if !absoluteURL(self) {
+		t.Error("channel has no atom:link rel=self")
+	}
+	for i, item := range c.Items {
+		if item.Title == "" && item.Description == "" {
+			t.Errorf("item %d requires title or description", i)
+		}
+		if _, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil {
+			t.Errorf("item %d pubDate: %v", i, err)
+		}
+		if item.GUID == "" {
+			t.Errorf("item %d has no guid", i)
+		}
+		if len(item.Enclosures) > 1 {
+			t.Errorf("item %d has %d enclosures, RSS allows one", i, len(item.Enclosures))
+		}
+		for _, e := range item.Enclosures {
+			if !absoluteURL(e.URL) || e.Length == "" || e.Type == "" {
+				t.Errorf("item %d enclosure requires absolute url, length and type: %+v", i, e)
+			}
+		}
+	}
+	if len(c.Items) == 0 || len(c.Items[0].Enclosures) != 1 {
+		t.Error("first item should keep its first image as enclosure")
+	}
+}
+
+func validateAtom(t *testing.T, data []byte) {
+	var doc struct {
+		XMLName xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string         `xml:"id"`
+		Title   string         `xml:"title"`
+		Updated string         `xml:"updated"`
+		Author  string         `xml:"author>name"`
+		Links   []atomTestLink `xml:"link"`
+		Entries []struct {
+			ID      string         `xml:"id"`
+			Title   string         `xml:"title"`
+			Updated string         `xml:"updated"`
+			Links   []atomTestLink `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.ID == "" || doc.Title == "" {
+		t.Error("feed requires id and title")
+	}
+	if _, err := time.Parse(time.RFC3339, doc.Updated); err != nil {
+		t.Errorf("feed updated: %v", err)
+	}
+	// Không có author ở feed thì mỗi entry phải có author (RFC 4287 4.1.1)
+	if doc.Author == "" {
+		t.Error("feed has no author")
+	}
+	if !hasRel(doc.Links, "self") {
+		t.Error("feed has no link rel=self")
+	}
+
+	enclosures := 0
+	for i, entry := range doc.Entries {
+		if entry.ID == "" || entry.Title == "" {
+			t.Errorf("entry %d requires id and title", i)
+		}
+		if _, err := time.Parse(time.RFC3339, entry.Updated); err != nil {
+			t.Errorf("entry %d updated: %v", i, err)
+		}
+		if !hasRel(entry.Links, "alternate") {
+			t.Errorf("entry %d has no link rel=alternate", i)
+		}
+		for _, l := range entry.Links {
+			if l.Rel != "enclosure" {
+				continue
+			}
+			enclosures++
+			if !absoluteURL(l.Href) || l.Type == "" || l.Length == "" {
+				t.Errorf("entry %d enclosure requires absolute href, type and length: %+v", i, l)
+			}
+		}
+	}
+	if enclosures != 2 {
+		t.Errorf("enclosures = %d, want every image of the first entry", enclosures)
+	}
+}
+
+type atomTestLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+func hasRel(links []atomTestLink, rel string) bool {
+	for _, l := range links {
+		if l.Rel == rel {
+			return true
+		}
+	}
+	return false
+}
+
+func validateJSONFeed(t *testing.T, data []byte) {
+	var doc struct {
+		Version string `json:"version"`
+		Title   string `json:"title"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			ID            string  `json:"id"`
+			ContentText   *string `json:"content_text"`
+			ContentHTML   *string `json:"content_html"`
+			DatePublished string  `json:"date_published"`
+			Attachments   []struct {
+				URL      string `json:"url"`
+				MimeType string `json:"mime_type"`
+			} `json:"attachments"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %q", doc.Version)
+	}
+	if doc.Title == "" || !absoluteURL(doc.FeedURL) {
+		t.Error("feed requires title and an absolute feed_url")
+	}
+
+	attachments := 0
+	for i, item := range doc.Items {
+		if item.ID == "" {
+			t.Errorf("item %d has no id", i)
+		}
+		if item.ContentText == nil && item.ContentHTML == nil {
+			t.Errorf("item %d requires content_text or content_html", i)
+		}
+		if _, err := time.Parse(time.RFC3339, item.DatePublished); err != nil {
+			t.Errorf("item %d date_published: %v", i, err)
+		}
+		for _, a := range item.Attachments {
+			attachments++
+			if !absoluteURL(a.URL) || a.MimeType == "" {
+				t.Errorf("item %d attachment requires url and mime_type: %+v", i, a)
+			}
+		}
+	}
+	if attachments != 2 {
+		t.Errorf("attachments = %d, want 2", attachments)
+	}
+}
+
+func absoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
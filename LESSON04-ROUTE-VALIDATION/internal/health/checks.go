@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck ping lần lượt từng target, lỗi nếu có target không ping được
+func PingCheck(targets map[string]Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+		names := make([]string, 0, len(targets))
+		for name := range targets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		details := make(map[string]any, len(targets))
+		var failed []string
+		for _, name := range names {
+			start := time.Now()
+			err := targets[name].Ping(ctx)
+
+			detail := map[string]any{"latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				detail["error"] = err.Error()
+				failed = append(failed, name)
+			}
+			details[name] = detail
+		}
+
+		if len(failed) > 0 {
+			return details, fmt.Errorf("ping failed: %s", strings.Join(failed, ", "))
+		}
+		return details, nil
+	})
+}
+
+// StorageCheck kiểm tra dir ghi được (tạo rồi xoá 1 file tạm) và dung lượng trống
+// không dưới minFreeBytes. minFreeBytes = 0 thì bỏ qua kiểm tra dung lượng.
+func StorageCheck(dir string, minFreeBytes uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+		details := map[string]any{"path": dir}
+
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return details, fmt.Errorf("cannot create %s: %w", dir, err)
+		}
+
+		file, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return details, fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		file.Close()
+		os.Remove(file.Name())
+
+		free, err := diskFree(dir)
+		if errors.Is(err, errDiskFreeUnsupported) {
+			return details, nil
+		}
+		if err != nil {
+			return details, err
+		}
+
+		details["free_bytes"] = free
+		details["min_free_bytes"] = minFreeBytes
+		if free < minFreeBytes {
+			return details, fmt.Errorf("free disk space %d bytes is below %d", free, minFreeBytes)
+		}
+		return details, nil
+	})
+}
+
+var errDiskFreeUnsupported = errors.New("disk free space is not supported on this platform")
+
+// Heartbeats theo dõi background worker: worker gọi Beat định kỳ,
+// check lỗi khi có worker quá maxAge chưa gọi
+type Heartbeats struct {
+	mu      sync.Mutex
+	workers map[string]*heartbeat
+}
+
+type heartbeat struct {
+	maxAge time.Duration
+	last   time.Time
+}
+
+func NewHeartbeats() *Heartbeats {
+	return &Heartbeats{workers: make(map[string]*heartbeat)}
+}
+
+// Register khai báo worker, tính như vừa beat để worker có thời gian khởi động
+func (h *Heartbeats) Register(name string, maxAge time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.workers[name] = &heartbeat{maxAge: maxAge, last: time.Now()}
+}
+
+// Unregister dùng khi worker dừng có chủ đích, để không bị coi là treo
+func (h *Heartbeats) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.workers, name)
+}
+
+func (h *Heartbeats) Beat(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if worker, ok := h.workers[name]; ok {
+		worker.last = time.Now()
+	}
+}
+
+func (h *Heartbeats) Check(ctx context.Context) (map[string]any, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	details := make(map[string]any, len(h.workers))
+	var stale []string
+	for name, worker := range h.workers {
+		age := time.Since(worker.last)
+		details[name] = map[string]any{
+			"last_beat": worker.last.UTC(),
+			"stale":     age > worker.maxAge,
+		}
+		if age > worker.maxAge {
+			stale = append(stale, name)
+		}
+	}
+
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return details, fmt.Errorf("workers not responding: %s", strings.Join(stale, ", "))
+	}
+	return details, nil
+}
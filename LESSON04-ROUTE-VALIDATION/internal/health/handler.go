@@ -0,0 +1,28 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHealthz: liveness, chỉ chạy các check Liveness
+func (r *Registry) GetHealthz(ctx *gin.Context) {
+	r.render(ctx, r.Run(ctx.Request.Context(), Liveness))
+}
+
+// GetReadyz: readiness, chạy toàn bộ check
+func (r *Registry) GetReadyz(ctx *gin.Context) {
+	r.render(ctx, r.Run(ctx.Request.Context(), Readiness))
+}
+
+func (r *Registry) render(ctx *gin.Context, report Report) {
+	// Probe phải luôn thấy kết quả mới nhất của registry, không qua cache HTTP
+	ctx.Header("Cache-Control", "no-store")
+
+	code := http.StatusOK
+	if report.Status != StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	ctx.JSON(code, report)
+}
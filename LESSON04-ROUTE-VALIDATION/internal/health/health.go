@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Checker trả về details để hiển thị (có thể nil) và lỗi nếu dependency không dùng được
+type Checker interface {
+	Check(ctx context.Context) (map[string]any, error)
+}
+
+type CheckerFunc func(ctx context.Context) (map[string]any, error)
+
+func (f CheckerFunc) Check(ctx context.Context) (map[string]any, error) {
+	return f(ctx)
+}
+
+type Kind int
+
+const (
+	// Readiness: lỗi thì ngừng nhận traffic (/readyz trả 503) nhưng không restart process
+	Readiness Kind = iota
+	// Liveness: lỗi nghĩa là process hỏng, cần restart. Cũng được tính vào /readyz
+	Liveness
+)
+
+type Result struct {
+	Status    Status         `json:"status"`
+	LatencyMs float64        `json:"latency_ms"`
+	CheckedAt time.Time      `json:"checked_at"`
+	Cached    bool           `json:"cached"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status   Status            `json:"status"`
+	Draining bool              `json:"draining,omitempty"`
+	Checks   map[string]Result `json:"checks"`
+}
+
+type Options struct {
+	// CacheTTL: kết quả được dùng lại trong khoảng này để probe dày
+	// (nhiều replica, nhiều load balancer) không dồn tải lên dependency
+	CacheTTL time.Duration
+	// Timeout cho mỗi check
+	Timeout time.Duration
+}
+
+type check struct {
+	name    string
+	kind    Kind
+	checker Checker
+
+	// mu giữ trong lúc chạy check để các probe đồng thời chờ chung 1 lần chạy
+	mu     sync.Mutex
+	result Result
+}
+
+type Registry struct {
+	opts     Options
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+func NewRegistry(opts Options) *Registry {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	return &Registry{opts: opts}
+}
+
+func (r *Registry) Register(name string, kind Kind, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, &check{name: name, kind: kind, checker: checker})
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].name < r.checks[j].name })
+}
+
+// SetDraining được gọi khi bắt đầu tắt server: readiness báo down để load balancer
+// ngừng gửi request mới, liveness vẫn up để process không bị kill giữa chừng
+func (r *Registry) SetDraining(draining bool) {
+	r.draining.Store(draining)
+}
+
+// Run chạy song song các check thuộc kind (Readiness gồm cả Liveness)
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if kind == Readiness || c.kind == Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	if kind == Readiness && r.draining.Load() {
+		report.Status = StatusDown
+		report.Draining = true
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < r.opts.CacheTTL {
+		cached := c.result
+		cached.Cached = true
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := c.checker.Check(ctx)
+
+	result := Result{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	c.result = result
+	return result
+}
@@ -0,0 +1,201 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/health"
+)
+
+// counter đếm số lần check thật sự chạy
+type counter struct {
+	calls atomic.Int32
+	err   error
+	delay time.Duration
+}
+
+func (c *counter) Check(ctx context.Context) (map[string]any, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	return nil, c.err
+}
+
+func get(r *gin.Engine, path string) (int, health.Report, http.Header) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report health.Report
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report, w.Header()
+}
+
+func checkNames(report health.Report) []string {
+	var names []string
+	for name := range report.Checks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestLivenessReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := health.NewRegistry(health.Options{})
+	registry.Register("workers", health.Liveness, &counter{})
+	registry.Register("database", health.Readiness, &counter{err: errors.New("connection refused")})
+
+	r := gin.New()
+	r.GET("/healthz", registry.GetHealthz)
+	r.GET("/readyz", registry.GetReadyz)
+
+	// Dependency lỗi không làm process bị restart
+	code, report, header := get(r, "/healthz")
+	if code != http.StatusOK || report.Status != health.StatusUp || !slices.Equal(checkNames(report), []string{"workers"}) {
+		t.Errorf("healthz = %d %+v", code, report)
+	}
+	if header.Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q", header.Get("Cache-Control"))
+	}
+
+	code, report, _ = get(r, "/readyz")
+	if code != http.StatusServiceUnavailable || !slices.Equal(checkNames(report), []string{"database", "workers"}) {
+		t.Fatalf("readyz = %d %+v", code, report)
+	}
+	if db := report.Checks["database"]; db.Status != health.StatusDown || db.Error != "connection refused" {
+		t.Errorf("database = %+v", db)
+	}
+
+	registry.SetDraining(true)
+	if code, report, _ := get(r, "/healthz"); code != http.StatusOK || report.Draining {
+		t.Errorf("healthz while draining = %d %+v", code, report)
+	}
+	if code, report, _ := get(r, "/readyz"); code != http.StatusServiceUnavailable || !report.Draining {
+		t.Errorf("readyz while draining = %d %+v", code, report)
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reused within TTL", func(t *testing.T) {
+		c := &counter{delay: 20 * time.Millisecond}
+		registry := health.NewRegistry(health.Options{CacheTTL: time.Hour})
+		registry.Register("db", health.Readiness, c)
+
+		// Probe đồng thời chờ chung 1 lần chạy
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() { registry.Run(ctx, health.Readiness) })
+		}
+		wg.Wait()
+		report := registry.Run(ctx, health.Readiness)
+
+		if n := c.calls.Load(); n != 1 {
+			t.Errorf("check ran %d times, want 1", n)
+		}
+		if result := report.Checks["db"]; !result.Cached || result.LatencyMs < 20 {
+			t.Errorf("result = %+v, want the cached first run", result)
+		}
+	})
+
+	t.Run("no TTL", func(t *testing.T) {
+		c := &counter{}
+		registry := health.NewRegistry(health.Options{})
+		registry.Register("db", health.Readiness, c)
+		for range 3 {
+			if registry.Run(ctx, health.Readiness).Checks["db"].Cached {
+				t.Error("result cached without CacheTTL")
+			}
+		}
+		if n := c.calls.Load(); n != 3 {
+			t.Errorf("check ran %d times, want 3", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		registry := health.NewRegistry(health.Options{Timeout: 10 * time.Millisecond})
+		registry.Register("slow", health.Liveness, health.CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+		report := registry.Run(ctx, health.Liveness)
+		if report.Status != health.StatusDown || report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("report = %+v", report)
+		}
+	})
+}
+
+type pinger struct{ err error }
+
+func (p pinger) Ping(ctx context.Context) error { return p.err }
+
+func TestPingCheck(t *testing.T) {
+	check := health.PingCheck(map[string]health.Pinger{
+		"users":    pinger{},
+		"products": pinger{err: errors.New("closed")},
+		"news":     pinger{err: errors.New("closed")},
+	})
+	details, err := check.Check(context.Background())
+	if err == nil || err.Error() != "ping failed: news, products" {
+		t.Errorf("err = %v", err)
+	}
+	if users, ok := details["users"].(map[string]any); !ok || users["error"] != nil {
+		t.Errorf("users = %v", details["users"])
+	}
+	if products, _ := details["products"].(map[string]any); products["error"] != "closed" {
+		t.Errorf("products = %v", details["products"])
+	}
+}
+
+func TestHeartbeats(t *testing.T) {
+	ctx := context.Background()
+	workers := health.NewHeartbeats()
+	workers.Register("jobs", 20*time.Millisecond)
+	workers.Register("events", time.Hour)
+
+	if _, err := workers.Check(ctx); err != nil {
+		t.Errorf("worker just registered: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := workers.Check(ctx); err == nil || !strings.Contains(err.Error(), "jobs") || strings.Contains(err.Error(), "events") {
+		t.Errorf("err = %v, want only jobs stale", err)
+	}
+
+	workers.Beat("jobs")
+	if _, err := workers.Check(ctx); err != nil {
+		t.Errorf("after beat: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	workers.Unregister("jobs")
+	if details, err := workers.Check(ctx); err != nil || details["jobs"] != nil {
+		t.Errorf("after unregister: %v, %v", details, err)
+	}
+}
+
+func TestStorageCheck(t *testing.T) {
+	dir := t.TempDir()
+	details, err := health.StorageCheck(dir, 0).Check(context.Background())
+	if err != nil || details["path"] != dir {
+		t.Fatalf("StorageCheck = %v, %v", details, err)
+	}
+	if _, ok := details["free_bytes"]; !ok {
+		t.Skip("disk free space is not supported on this platform")
+	}
+
+	if _, err := health.StorageCheck(dir, math.MaxUint64).Check(context.Background()); err == nil {
+		t.Error("expected an error when free space is below the minimum")
+	}
+}
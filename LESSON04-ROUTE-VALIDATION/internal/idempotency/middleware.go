@@ -0,0 +1,263 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/capture"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/utils"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderReplayed đánh dấu response được trả lại từ lần gọi trước
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// multipartMemory giống MaxMultipartMemory mặc định của gin
+	multipartMemory = 32 << 20
+)
+
+type Options struct {
+	// TTL là thời gian giữ response để trả lại cho retry
+	TTL time.Duration
+	// WaitTimeout: retry đến khi request gốc còn đang chạy thì chờ tối đa chừng này, quá thì 409
+	WaitTimeout time.Duration
+	// Scope tách key của các client khác nhau (VD: theo API key), nil thì mọi client dùng chung
+	Scope func(ctx *gin.Context) string
+}
+
+// Middleware hỗ trợ header Idempotency-Key cho POST và PATCH:
+//   - lần đầu: chạy handler, lưu response (trừ 5xx) cùng fingerprint của request
+//   - retry cùng key, cùng request: trả lại response đã lưu, kèm Idempotent-Replayed: true
+//   - cùng key nhưng request khác: 422
+//   - request gốc chưa xong: chờ tối đa WaitTimeout rồi 409
+func Middleware(store *Store, opts Options) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(HeaderIdempotencyKey)
+		if key == "" || (ctx.Request.Method != http.MethodPost && ctx.Request.Method != http.MethodPatch) {
+			ctx.Next()
+			return
+		}
+
+		if !validKey(key) {
+			abort(ctx, http.StatusBadRequest, "Idempotency-Key must be 1-255 printable ASCII characters")
+			return
+		}
+
+		fingerprint, err := fingerprint(ctx.Request)
+		if err != nil {
+			abort(ctx, http.StatusBadRequest, "Cannot read request body")
+			return
+		}
+
+		if opts.Scope != nil {
+			key = opts.Scope(ctx) + "|" + key
+		}
+
+		deadline := time.NewTimer(opts.WaitTimeout)
+		defer deadline.Stop()
+
+		for {
+			e, created := store.begin(key, fingerprint, opts.TTL)
+			if created {
+				execute(ctx, store, key, e, opts.TTL)
+				return
+			}
+
+			if e.fingerprint != fingerprint {
+				metrics.IdempotencyRequests.Inc("mismatch")
+				abort(ctx, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				return
+			}
+
+			if !wait(ctx, e, deadline.C) {
+				if ctx.Request.Context().Err() != nil {
+					ctx.Abort()
+					return
+				}
+				metrics.IdempotencyRequests.Inc("conflict")
+				ctx.Header("Retry-After", "1")
+				abort(ctx, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+				return
+			}
+
+			if response := store.result(e); response != nil {
+				metrics.IdempotencyRequests.Inc("replayed")
+				replay(ctx, response)
+				return
+			}
+			// Request gốc lỗi và đã nhả key: request này thử giữ key và chạy lại
+		}
+	}
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func abort(ctx *gin.Context, code int, message string) {
+	utils.Render(ctx, code, gin.H{"error": message})
+	ctx.Abort()
+}
+
+// wait trả về true khi request gốc đã xong
+func wait(ctx *gin.Context, e *entry, deadline <-chan time.Time) bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+	}
+
+	select {
+	case <-e.done:
+		return true
+	case <-deadline:
+		return false
+	case <-ctx.Request.Context().Done():
+		return false
+	}
+}
+
+func execute(ctx *gin.Context, store *Store, key string, e *entry, ttl time.Duration) {
+	before := ctx.Writer.Header().Clone()
+	recorder := capture.Start(ctx)
+
+	completed := false
+	// Chạy cả khi handler panic, để retry không bị 409 mãi đến hết TTL
+	defer func() {
+		if !completed {
+			store.release(key, e)
+		}
+	}()
+
+	ctx.Next()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		return
+	}
+
+	store.complete(e, &Response{
+		Status: status,
+		Header: handlerHeaders(before, recorder.Header()),
+		Body:   recorder.Body(),
+	}, ttl)
+	completed = true
+	metrics.IdempotencyRequests.Inc("stored")
+}
+
+func replay(ctx *gin.Context, response *Response) {
+	header := ctx.Writer.Header()
+	for name, values := range response.Header {
+		header[name] = slices.Clone(values)
+	}
+	header.Set(HeaderReplayed, "true")
+
+	ctx.Writer.WriteHeader(response.Status)
+	ctx.Writer.WriteHeaderNow()
+	ctx.Writer.Write(response.Body)
+	ctx.Abort()
+}
+
+// handlerHeaders lấy header do handler đặt/đổi, bỏ header riêng của từng request
+// như RateLimit-* của limiter đứng sau middleware này
+func handlerHeaders(before, after http.Header) http.Header {
+	return capture.HandlerHeaders(before, after, func(name string) bool {
+		return strings.HasPrefix(name, "Ratelimit-") || name == "Retry-After"
+	})
+}
+
+// fingerprint băm method, path, query và body. Multipart được băm theo field và nội dung file
+// vì boundary thay đổi mỗi lần client gửi lại.
+func fingerprint(req *http.Request) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s?%s\n", req.Method, req.URL.Path, req.URL.RawQuery)
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	fmt.Fprintf(h, "%s\n", mediaType)
+
+	switch {
+	case mediaType == gin.MIMEMultipartPOSTForm:
+		// Form đã parse (VD: bởi openapi validator) thì dùng lại, handler cũng đọc từ đây
+		if req.MultipartForm == nil {
+			if err := req.ParseMultipartForm(multipartMemory); err != nil {
+				return "", err
+			}
+		}
+		if err := hashForm(h, req.MultipartForm); err != nil {
+			return "", err
+		}
+	case mediaType == gin.MIMEPOSTForm:
+		// Giống multipart: openapi validator gọi ParseForm trước thì body đã bị đọc hết
+		if req.PostForm == nil {
+			if err := req.ParseForm(); err != nil {
+				return "", err
+			}
+		}
+		io.WriteString(h, req.PostForm.Encode())
+	case req.Body != nil:
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashForm(h hash.Hash, form *multipart.Form) error {
+	for _, name := range sortedKeys(form.Value) {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(h, "value %q=%q\n", name, value)
+		}
+	}
+
+	for _, name := range sortedKeys(form.File) {
+		for _, fileHeader := range form.File[name] {
+			fmt.Fprintf(h, "file %q=%q %d\n", name, fileHeader.Filename, fileHeader.Size)
+
+			file, err := fileHeader.Open()
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,243 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Form urlencoded đã được middleware đứng trước (openapi validator) parse thì body rỗng,
+// fingerprint phải lấy từ PostForm để 2 request khác nhau không bị coi là 1
+func TestFingerprintParsedForm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		ctx.Request.ParseForm()
+		ctx.Next()
+	})
+	r.Use(Middleware(NewStore(), Options{TTL: time.Minute, WaitTimeout: time.Second}))
+	r.POST("/categories", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"name": ctx.PostForm("name")})
+	})
+
+	send := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(HeaderIdempotencyKey, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("name=golang"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d", w.Code)
+	}
+	if w := send("name=golang"); w.Header().Get(HeaderReplayed) != "true" {
+		t.Fatalf("same form should be replayed, status = %d", w.Code)
+	}
+	if w := send("name=python"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different form: status = %d, want 422", w.Code)
+	}
+}
+
+// engine trả về 201 kèm số lần handler đã chạy, fail quyết định lần chạy thứ n lỗi thế nào
+func engine(opts Options, fail func(call int) int, gate <-chan struct{}) (*gin.Engine, *atomic.Int32) {
+	gin.SetMode(gin.TestMode)
+	calls := &atomic.Int32{}
+
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(ctx *gin.Context, err any) {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(Middleware(NewStore(), opts))
+	handler := func(ctx *gin.Context) {
+		call := int(calls.Add(1))
+		if gate != nil {
+			<-gate
+		}
+		if fail != nil {
+			switch status := fail(call); status {
+			case 0:
+			case -1:
+				panic("handler failed")
+			default:
+				ctx.Status(status)
+				return
+			}
+		}
+		ctx.Header("Location", "/orders/"+strconv.Itoa(call))
+		ctx.JSON(http.StatusCreated, gin.H{"call": call})
+	}
+	r.POST("/orders", handler)
+	r.GET("/orders", handler)
+	return r, calls
+}
+
+func send(r http.Handler, method, key, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReplay(t *testing.T) {
+	r, calls := engine(Options{
+		TTL:         time.Minute,
+		WaitTimeout: time.Second,
+		Scope:       func(ctx *gin.Context) string { return ctx.GetHeader("X-API-Key") },
+	}, nil, nil)
+
+	first := send(r, http.MethodPost, "a", `{"item":1}`)
+	if first.Code != http.StatusCreated || first.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("first: status = %d, replayed = %q", first.Code, first.Header().Get(HeaderReplayed))
+	}
+
+	steps := []struct {
+		name     string
+		method   string
+		key      string
+		body     string
+		header   []string
+		status   int
+		replayed bool
+		calls    int32
+	}{
+		{"same request", http.MethodPost, "a", `{"item":1}`, nil, http.StatusCreated, true, 1},
+		{"different body", http.MethodPost, "a", `{"item":2}`, nil, http.StatusUnprocessableEntity, false, 1},
+		{"other key", http.MethodPost, "b", `{"item":1}`, nil, http.StatusCreated, false, 2},
+		{"other client", http.MethodPost, "a", `{"item":1}`, []string{"X-API-Key", "other"}, http.StatusCreated, false, 3},
+		{"no key", http.MethodPost, "", `{"item":1}`, nil, http.StatusCreated, false, 4},
+		{"GET ignores key", http.MethodGet, "a", "", nil, http.StatusCreated, false, 5},
+		{"invalid key", http.MethodPost, "bad\nkey", `{"item":1}`, nil, http.StatusBadRequest, false, 5},
+	}
+	for _, step := range steps {
+		w := send(r, step.method, step.key, step.body, step.header...)
+		if w.Code != step.status || (w.Header().Get(HeaderReplayed) == "true") != step.replayed {
+			t.Errorf("%s: status = %d, replayed = %q", step.name, w.Code, w.Header().Get(HeaderReplayed))
+		}
+		if got := calls.Load(); got != step.calls {
+			t.Errorf("%s: handler calls = %d, want %d", step.name, got, step.calls)
+		}
+		if step.replayed && (w.Body.String() != first.Body.String() || w.Header().Get("Location") != "/orders/1") {
+			t.Errorf("%s: replayed body %q, Location %q", step.name, w.Body.String(), w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	gate := make(chan struct{})
+	r, calls := engine(Options{TTL: time.Minute, WaitTimeout: 200 * time.Millisecond}, nil, gate)
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send(r, http.MethodPost, "a", `{}`) }()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Request gốc chưa xong trong WaitTimeout: 409
+	conflict := send(r, http.MethodPost, "a", `{}`)
+	if conflict.Code != http.StatusConflict || conflict.Header().Get("Retry-After") != "1" {
+		t.Errorf("status = %d, Retry-After = %q, want 409 with Retry-After", conflict.Code, conflict.Header().Get("Retry-After"))
+	}
+
+	// Request gốc xong trong lúc chờ: nhận response đã lưu
+	waiting := make(chan *httptest.ResponseRecorder)
+	go func() { waiting <- send(r, http.MethodPost, "a", `{}`) }()
+	time.Sleep(20 * time.Millisecond)
+	close(gate)
+
+	original := <-first
+	replayed := <-waiting
+	if replayed.Code != http.StatusCreated || replayed.Header().Get(HeaderReplayed) != "true" || replayed.Body.String() != original.Body.String() {
+		t.Errorf("waiting request: status = %d, replayed = %q, body = %q", replayed.Code, replayed.Header().Get(HeaderReplayed), replayed.Body.String())
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler calls = %d, want 1", got)
+	}
+}
+
+func TestReleaseOnFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"5xx", http.StatusServiceUnavailable},
+		{"panic", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, calls := engine(Options{TTL: time.Minute, WaitTimeout: time.Second}, func(call int) int {
+				if call == 1 {
+					return tt.status
+				}
+				return 0
+			}, nil)
+
+			if w := send(r, http.MethodPost, "a", `{}`); w.Code < http.StatusInternalServerError {
+				t.Fatalf("first: status = %d, want 5xx", w.Code)
+			}
+			retry := send(r, http.MethodPost, "a", `{}`)
+			if retry.Code != http.StatusCreated || retry.Header().Get(HeaderReplayed) != "" || calls.Load() != 2 {
+				t.Errorf("retry: status = %d, replayed = %q, calls = %d, want handler run again", retry.Code, retry.Header().Get(HeaderReplayed), calls.Load())
+			}
+			if w := send(r, http.MethodPost, "a", `{}`); w.Header().Get(HeaderReplayed) != "true" {
+				t.Errorf("successful retry should be stored, status = %d", w.Code)
+			}
+		})
+	}
+
+	// 4xx được lưu như response thành công
+	r, calls := engine(Options{TTL: time.Minute, WaitTimeout: time.Second}, func(int) int { return http.StatusNotFound }, nil)
+	send(r, http.MethodPost, "a", `{}`)
+	if w := send(r, http.MethodPost, "a", `{}`); w.Code != http.StatusNotFound || w.Header().Get(HeaderReplayed) != "true" || calls.Load() != 1 {
+		t.Errorf("4xx retry: status = %d, replayed = %q, calls = %d", w.Code, w.Header().Get(HeaderReplayed), calls.Load())
+	}
+}
+
+func TestStoreBeginTTL(t *testing.T) {
+	s := NewStore()
+	ttl := 20 * time.Millisecond
+
+	e, created := s.begin("a", "fp1", ttl)
+	if !created {
+		t.Fatal("first begin should create the entry")
+	}
+	if again, created := s.begin("a", "fp2", ttl); created || again != e {
+		t.Fatal("begin while the key is held should return the existing entry")
+	}
+	s.complete(e, &Response{Status: http.StatusCreated}, ttl)
+	if _, created := s.begin("a", "fp1", ttl); created {
+		t.Fatal("completed key should be kept until TTL")
+	}
+
+	time.Sleep(2 * ttl)
+	next, created := s.begin("a", "fp2", ttl)
+	if !created || next.fingerprint != "fp2" || s.result(next) != nil {
+		t.Fatal("expired key should be usable as new")
+	}
+
+	// Lần quét định kỳ xoá entry hết hạn của key không còn được dùng
+	s.begin("b", "fp", ttl)
+	time.Sleep(2 * ttl)
+	s.swept = time.Now().Add(-sweepInterval)
+	s.begin("c", "fp", ttl)
+	if _, ok := s.entries["b"]; ok {
+		t.Error("expired entry should be swept")
+	}
+	if len(s.entries) != 1 {
+		t.Errorf("entries = %d, want only c", len(s.entries))
+	}
+}
@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response là response đầu tiên của 1 key, được trả lại nguyên vẹn cho các lần retry
+type Response struct {
+	Status int
+	// Header chỉ gồm header do handler đặt, không gồm header của từng request (X-Request-ID, RateLimit-*...)
+	Header http.Header
+	Body   []byte
+}
+
+type entry struct {
+	fingerprint string
+	// done đóng khi request gốc xong: có response (complete) hoặc bị huỷ (release)
+	done     chan struct{}
+	response *Response
+	expires  time.Time
+}
+
+// Store giữ response theo key trong bộ nhớ, hết TTL thì key được dùng lại như mới
+type Store struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	swept   time.Time
+}
+
+func NewStore() *Store {
+	return &Store{entries: make(map[string]*entry)}
+}
+
+const sweepInterval = time.Minute
+
+// begin giữ key cho request mới (created = true), key đang được giữ thì trả về entry hiện có
+func (s *Store) begin(key, fingerprint string, ttl time.Duration) (e *entry, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.swept) >= sweepInterval {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.swept = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e, false
+	}
+
+	e = &entry{
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+		expires:     now.Add(ttl),
+	}
+	s.entries[key] = e
+	return e, true
+}
+
+func (s *Store) complete(e *entry, response *Response, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.response = response
+	e.expires = time.Now().Add(ttl)
+	close(e.done)
+}
+
+// release bỏ key khi request gốc lỗi (5xx, panic) để lần retry được xử lý lại từ đầu
+func (s *Store) release(key string, e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[key] == e {
+		delete(s.entries, key)
+	}
+	close(e.done)
+}
+
+func (s *Store) result(e *entry) *Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return e.response
+}
@@ -0,0 +1,245 @@
+package imports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/tracing"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// RowError là lỗi của 1 dòng, Errors cùng định dạng với lỗi validate: field => thông báo
+type RowError struct {
+	Row    int
+	Errors map[string]string
+}
+
+// Summary là trạng thái của job trả về cho client
+type Summary struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	DryRun      bool       `json:"dry_run"`
+	Format      string     `json:"format"`
+	TotalRows   int        `json:"total_rows"`
+	ValidRows   int        `json:"valid_rows"`
+	InvalidRows int        `json:"invalid_rows"`
+	Created     int        `json:"created"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Job là 1 lần import chạy nền. Hàm import báo tiến độ qua SetTotal, Valid, Invalid, SetCreated.
+type Job struct {
+	mu      sync.Mutex
+	summary Summary
+	errors  []RowError
+}
+
+func (j *Job) ID() string {
+	return j.summary.ID
+}
+
+func (j *Job) DryRun() bool {
+	return j.summary.DryRun
+}
+
+func (j *Job) Format() string {
+	return j.summary.Format
+}
+
+func (j *Job) SetTotal(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.TotalRows = n
+}
+
+func (j *Job) Valid() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.ValidRows++
+}
+
+// Invalid ghi lỗi của dòng row (tính cả dòng tiêu đề, giống số dòng client thấy trong Excel)
+func (j *Job) Invalid(row int, errors map[string]string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.InvalidRows++
+	j.errors = append(j.errors, RowError{Row: row, Errors: errors})
+}
+
+func (j *Job) SetCreated(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.Created = n
+}
+
+func (j *Job) Summary() Summary {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.summary
+}
+
+// Report trả về lỗi của các dòng, mỗi lỗi 1 dòng: số dòng, field, thông báo
+func (j *Job) Report() [][]any {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	rows := [][]any{{"row", "field", "error"}}
+	for _, rowError := range j.errors {
+		fields := make([]string, 0, len(rowError.Errors))
+		for field := range rowError.Errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			rows = append(rows, []any{rowError.Row, field, rowError.Errors[field]})
+		}
+	}
+	return rows
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now().UTC()
+	j.summary.FinishedAt = &now
+	j.summary.Status = StatusSucceeded
+	if err != nil {
+		j.summary.Status = StatusFailed
+		j.summary.Error = err.Error()
+	}
+}
+
+func (j *Job) expired(now time.Time, ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.summary.FinishedAt != nil && now.Sub(*j.summary.FinishedAt) > ttl
+}
+
+// RunFunc đọc và import dữ liệu, lỗi trả về làm cả job failed (khác với lỗi của từng dòng)
+type RunFunc func(ctx context.Context, job *Job) error
+
+// Manager chạy job import trong goroutine riêng và giữ kết quả trong bộ nhớ đến hết TTL
+type Manager struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewManager(ttl time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ttl: ttl, jobs: make(map[string]*Job), ctx: ctx, cancel: cancel}
+}
+
+// Start tạo job và chạy run ở nền. ctx chỉ dùng để giữ trace và logger của request,
+// job không bị huỷ khi request kết thúc.
+func (m *Manager) Start(ctx context.Context, dryRun bool, format string, run RunFunc) *Job {
+	job := &Job{summary: Summary{
+		ID:        uuid.New().String(),
+		Status:    StatusPending,
+		DryRun:    dryRun,
+		Format:    format,
+		CreatedAt: time.Now().UTC(),
+	}}
+
+	m.mu.Lock()
+	now := time.Now()
+	for id, j := range m.jobs {
+		if j.expired(now, m.ttl) {
+			delete(m.jobs, id)
+		}
+	}
+	m.jobs[job.ID()] = job
+	m.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	m.wg.Go(func() {
+		m.run(ctx, job, run)
+	})
+
+	return job
+}
+
+func (m *Manager) run(ctx context.Context, job *Job, run RunFunc) {
+	// Close huỷ job đang chạy khi hết thời gian shutdown
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(m.ctx, cancel)()
+
+	ctx, span := tracing.Start(ctx, "imports.Job", tracing.String("job.id", job.ID()), tracing.Bool("job.dry_run", job.DryRun()))
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		job.finish(err)
+		span.RecordError(err)
+		span.End()
+
+		summary := job.Summary()
+		logging.FromContext(ctx).Info("import finished",
+			slog.String("job_id", summary.ID),
+			slog.String("status", string(summary.Status)),
+			slog.Int("total_rows", summary.TotalRows),
+			slog.Int("invalid_rows", summary.InvalidRows),
+			slog.Int("created", summary.Created),
+		)
+	}()
+
+	job.mu.Lock()
+	job.summary.Status = StatusRunning
+	job.mu.Unlock()
+
+	err = run(ctx, job)
+}
+
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok || job.expired(time.Now(), m.ttl) {
+		return nil, false
+	}
+	return job, true
+}
+
+// Close chờ các job đang chạy xong, hết ctx thì huỷ chúng
+func (m *Manager) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/utils"
+)
+
+type GetJobsParam struct {
+	Status Status `form:"status" binding:"omitempty,oneof=queued running succeeded dead cancelled"`
+	Queue  string `form:"queue"`
+	Type   string `form:"type"`
+	Limit  int    `form:"limit" binding:"omitempty,gte=1,lte=200"`
+	Offset int    `form:"offset" binding:"omitempty,gte=0"`
+}
+
+type GetJobByIDParam struct {
+	ID int64 `uri:"id" binding:"gt=0"`
+}
+
+// GetJobs liệt kê job, lọc theo status=dead để xem dead-letter
+func (m *Manager) GetJobs(ctx *gin.Context) {
+	var params GetJobsParam
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = 50
+	}
+
+	jobs, err := m.List(ctx.Request.Context(), Filter(params))
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot list jobs"})
+		return
+	}
+
+	queues, err := m.Queues(ctx.Request.Context())
+	if err != nil {
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot list jobs"})
+		return
+	}
+
+	utils.Render(ctx, http.StatusOK, gin.H{
+		"jobs":   jobs,
+		"queues": queues,
+		"limit":  params.Limit,
+		"offset": params.Offset,
+	})
+}
+
+func (m *Manager) GetJobByID(ctx *gin.Context) {
+	var params GetJobByIDParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	job, err := m.Get(ctx.Request.Context(), params.ID)
+	if err != nil {
+		renderJobError(ctx, job, err)
+		return
+	}
+	utils.Render(ctx, http.StatusOK, gin.H{"job": job})
+}
+
+// PostJobRetry chạy lại job dead hoặc cancelled
+func (m *Manager) PostJobRetry(ctx *gin.Context) {
+	var params GetJobByIDParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	job, err := m.Retry(ctx.Request.Context(), params.ID)
+	if err != nil {
+		renderJobError(ctx, job, err)
+		return
+	}
+	utils.Render(ctx, http.StatusOK, gin.H{"message": "Job queued for retry", "job": job})
+}
+
+// PostJobCancel huỷ job queued (200) hoặc yêu cầu huỷ job đang chạy (202)
+func (m *Manager) PostJobCancel(ctx *gin.Context) {
+	var params GetJobByIDParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	job, err := m.Cancel(ctx.Request.Context(), params.ID)
+	if err != nil {
+		renderJobError(ctx, job, err)
+		return
+	}
+
+	if job.Status == StatusRunning {
+		utils.Render(ctx, http.StatusAccepted, gin.H{"message": "Cancellation requested", "job": job})
+		return
+	}
+	utils.Render(ctx, http.StatusOK, gin.H{"message": "Job cancelled", "job": job})
+}
+
+func (m *Manager) GetJobSchedules(ctx *gin.Context) {
+	utils.Render(ctx, http.StatusOK, gin.H{"schedules": m.Schedules()})
+}
+
+func renderJobError(ctx *gin.Context, job *Job, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		utils.Render(ctx, http.StatusNotFound, gin.H{"error": "Job not found"})
+	case errors.Is(err, ErrInvalidState):
+		utils.Render(ctx, http.StatusConflict, gin.H{"error": "Job is " + string(job.Status) + ", action not allowed", "job": job})
+	default:
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Job store error"})
+	}
+}
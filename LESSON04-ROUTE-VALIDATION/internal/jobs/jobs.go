@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	// StatusDead: hết số lần thử hoặc lỗi vĩnh viễn, nằm trong dead-letter chờ admin retry
+	StatusDead      Status = "dead"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
+	// ErrInvalidState: job không ở trạng thái cho phép thao tác, VD: retry job đang chạy
+	ErrInvalidState = errors.New("job is not in a valid state for this action")
+	ErrUnknownType  = errors.New("unknown job type")
+)
+
+// Job là 1 bản ghi trong hàng đợi. Payload là JSON của struct mà handler của Type nhận.
+type Job struct {
+	ID          int64           `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    int             `json:"priority"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	UniqueKey   string          `json:"unique_key,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+type EnqueueOptions struct {
+	// Priority cao hơn chạy trước trong cùng queue
+	Priority int
+	// RunAt rỗng là chạy ngay
+	RunAt time.Time
+	// UniqueKey: đã có job cùng key thì không tạo thêm mà trả về job cũ
+	UniqueKey string
+}
+
+// Filter của List, field rỗng là không lọc
+type Filter struct {
+	Status Status
+	Queue  string
+	Type   string
+	Limit  int
+	Offset int
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent đánh dấu lỗi không thể khỏi khi thử lại (VD: file không phải ảnh),
+// job vào dead-letter ngay thay vì retry
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,228 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mamba.com/route-group/internal/jobs"
+)
+
+func openStore(t *testing.T, path string) *jobs.Store {
+	t.Helper()
+	store, err := jobs.OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func newManager(t *testing.T, opts jobs.Options) *jobs.Manager {
+	t.Helper()
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 5 * time.Millisecond
+	}
+	m := jobs.NewManager(openStore(t, filepath.Join(t.TempDir(), "jobs.db")), opts)
+	t.Cleanup(func() { m.Close(context.Background()) })
+	return m
+}
+
+func TestClaimOrder(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer store.Close()
+
+	now := time.Now()
+	insert := func(queue string, priority int, runAt time.Time) int64 {
+		t.Helper()
+		job, err := store.Insert(ctx, &jobs.Job{Queue: queue, Type: "noop", Payload: []byte("{}"), Priority: priority, MaxAttempts: 1, RunAt: runAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job.ID
+	}
+
+	low := insert("default", 0, now.Add(-time.Minute))
+	highLater := insert("default", 10, now.Add(-time.Second))
+	highEarlier := insert("default", 10, now.Add(-time.Minute))
+	future := insert("default", 100, now.Add(time.Hour))
+	insert("other", 100, now.Add(-time.Minute))
+
+	for _, want := range []int64{highEarlier, highLater, low} {
+		job, err := store.Claim(ctx, "default", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ID != want || job.Status != jobs.StatusRunning || job.Attempts != 1 {
+			t.Errorf("claimed job %d (%s, attempts %d), want %d running with 1 attempt", job.ID, job.Status, job.Attempts, want)
+		}
+	}
+
+	// Job chưa đến run_at chỉ được lấy khi đến giờ
+	if _, err := store.Claim(ctx, "default", now); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("claim before run_at: err = %v, want ErrNotFound", err)
+	}
+	job, err := store.Claim(ctx, "default", now.Add(2*time.Hour))
+	if err != nil || job.ID != future {
+		t.Errorf("claim after run_at = %v, %v, want job %d", job, err, future)
+	}
+}
+
+func TestRetryUntilDead(t *testing.T) {
+	m := newManager(t, jobs.Options{MaxAttempts: 3, RetryBase: 20 * time.Millisecond, RetryMax: 40 * time.Millisecond})
+
+	var mu sync.Mutex
+	var attempts []time.Time
+	jobs.Register(m, "flaky", jobs.TypeOptions{}, func(ctx context.Context, payload struct{}) error {
+		mu.Lock()
+		attempts = append(attempts, time.Now())
+		mu.Unlock()
+		return errors.New("upstream unavailable")
+	})
+	jobs.Register(m, "broken", jobs.TypeOptions{MaxAttempts: 5}, func(ctx context.Context, payload struct{}) error {
+		return jobs.Permanent(errors.New("not an image"))
+	})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	flaky, err := m.Enqueue(context.Background(), "flaky", struct{}{}, jobs.EnqueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, err := m.Enqueue(context.Background(), "broken", struct{}{}, jobs.EnqueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := waitStatus(t, m, flaky.ID, jobs.StatusDead)
+	if job.Attempts != 3 || job.LastError != "upstream unavailable" || job.FinishedAt == nil {
+		t.Errorf("dead job = %+v, want 3 attempts with last error", job)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Lần thử n chờ RetryBase * 2^(n-1) trừ jitter tối đa 1 nửa
+	for i, least := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond} {
+		if gap := attempts[i+1].Sub(attempts[i]); gap < least {
+			t.Errorf("retry %d after %v, want at least %v", i+1, gap, least)
+		}
+	}
+
+	job = waitStatus(t, m, broken.ID, jobs.StatusDead)
+	if job.Attempts != 1 {
+		t.Errorf("permanent error: attempts = %d, want 1", job.Attempts)
+	}
+
+	// Admin retry đưa job dead về hàng đợi với đủ lượt thử
+	job, err = m.Retry(context.Background(), broken.ID)
+	if err != nil || job.Status != jobs.StatusQueued || job.Attempts != 0 {
+		t.Errorf("Retry = %+v, %v", job, err)
+	}
+}
+
+func TestUniqueKey(t *testing.T) {
+	m := newManager(t, jobs.Options{MaxAttempts: 1})
+	jobs.Register(m, "thumbnail", jobs.TypeOptions{}, func(ctx context.Context, payload int) error { return nil })
+
+	first, err := m.Enqueue(context.Background(), "thumbnail", 1, jobs.EnqueueOptions{UniqueKey: "thumbnail:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Enqueue(context.Background(), "thumbnail", 2, jobs.EnqueueOptions{UniqueKey: "thumbnail:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID || string(second.Payload) != "1" {
+		t.Errorf("duplicate enqueue returned job %d payload %s, want job %d payload 1", second.ID, second.Payload, first.ID)
+	}
+
+	other, err := m.Enqueue(context.Background(), "thumbnail", 2, jobs.EnqueueOptions{UniqueKey: "thumbnail:2"})
+	if err != nil || other.ID == first.ID {
+		t.Errorf("different key returned job %v, %v", other, err)
+	}
+
+	list, err := m.List(context.Background(), jobs.Filter{Limit: 10})
+	if err != nil || len(list) != 2 {
+		t.Errorf("List = %d jobs, %v, want 2", len(list), err)
+	}
+
+	if _, err := m.Enqueue(context.Background(), "missing", nil, jobs.EnqueueOptions{}); !errors.Is(err, jobs.ErrUnknownType) {
+		t.Errorf("unknown type: err = %v", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jobs.db")
+
+	store := openStore(t, path)
+	insert := func(maxAttempts int) int64 {
+		t.Helper()
+		job, err := store.Insert(ctx, &jobs.Job{Queue: jobs.DefaultQueue, Type: "resize", Payload: []byte(`"a.png"`), MaxAttempts: maxAttempts, RunAt: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job.ID
+	}
+	// 2 job đang chạy khi process bị kill: 1 còn lượt, 1 đã hết lượt
+	exhausted := insert(1)
+	if _, err := store.Claim(ctx, jobs.DefaultQueue, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	interrupted := insert(3)
+	if _, err := store.Claim(ctx, jobs.DefaultQueue, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	queued := insert(3)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := jobs.NewManager(openStore(t, path), jobs.Options{MaxAttempts: 3, PollInterval: 5 * time.Millisecond})
+	t.Cleanup(func() { m.Close(context.Background()) })
+
+	ran := make(chan string, 2)
+	jobs.Register(m, "resize", jobs.TypeOptions{}, func(ctx context.Context, file string) error {
+		ran <- file
+		return nil
+	})
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int64{interrupted, queued} {
+		job := waitStatus(t, m, id, jobs.StatusSucceeded)
+		if string(job.Payload) != `"a.png"` {
+			t.Errorf("job %d payload = %s", id, job.Payload)
+		}
+	}
+	job := waitStatus(t, m, exhausted, jobs.StatusDead)
+	if job.LastError == "" {
+		t.Error("interrupted job without attempts left should record why it died")
+	}
+	if len(ran) != 2 {
+		t.Errorf("handler ran %d times, want 2", len(ran))
+	}
+}
+
+func waitStatus(t *testing.T, m *jobs.Manager, id int64, status jobs.Status) *jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d is %s after 5s, want %s", id, job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
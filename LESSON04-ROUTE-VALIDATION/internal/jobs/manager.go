@@ -0,0 +1,415 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/durable"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/tracing"
+)
+
+// DefaultQueue là queue của job type không khai báo Queue
+const DefaultQueue = "default"
+
+var errCancelled = errors.New("cancelled by admin")
+
+type Options struct {
+	// Queues: tên queue => số job chạy đồng thời của queue đó
+	Queues map[string]int
+	// PollInterval: khoảng nghỉ giữa 2 lần tìm job khi queue rỗng. Enqueue đánh thức worker ngay.
+	PollInterval time.Duration
+	MaxAttempts  int
+	// Lần thử thứ n chờ khoảng RetryBase * 2^(n-1), tối đa RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Timeout mặc định của 1 lần chạy, 0 là không giới hạn
+	Timeout time.Duration
+	// Retention: job succeeded/cancelled cũ hơn chừng này bị xoá, job dead được giữ lại
+	Retention time.Duration
+	// Heartbeat được gọi mỗi vòng lặp của worker, để health check phát hiện worker bị treo
+	Heartbeat func()
+}
+
+// TypeOptions của 1 job type, giá trị 0 thì lấy theo Options của Manager
+type TypeOptions struct {
+	Queue       string
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+type handler struct {
+	queue       string
+	maxAttempts int
+	timeout     time.Duration
+	run         func(ctx context.Context, payload json.RawMessage) error
+}
+
+// Manager chạy job từ Store: mỗi queue có 1 vòng lặp lấy job theo priority
+// và chạy tối đa Queues[queue] job cùng lúc
+type Manager struct {
+	store *Store
+	opts  Options
+
+	mu        sync.Mutex
+	handlers  map[string]*handler
+	schedules []*schedule
+	running   map[int64]context.CancelCauseFunc
+	wake      map[string]chan struct{}
+
+	// ctx dừng vòng lặp lấy job, jobCtx huỷ job đang chạy khi hết thời gian shutdown
+	ctx       context.Context
+	cancel    context.CancelFunc
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+	loops     sync.WaitGroup
+	jobs      sync.WaitGroup
+}
+
+func NewManager(store *Store, opts Options) *Manager {
+	if _, ok := opts.Queues[DefaultQueue]; !ok {
+		queues := map[string]int{DefaultQueue: 1}
+		for name, n := range opts.Queues {
+			queues[name] = n
+		}
+		opts.Queues = queues
+	}
+
+	m := &Manager{
+		store:    store,
+		opts:     opts,
+		handlers: make(map[string]*handler),
+		running:  make(map[int64]context.CancelCauseFunc),
+		wake:     make(map[string]chan struct{}),
+	}
+	for name := range opts.Queues {
+		m.wake[name] = make(chan struct{}, 1)
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.jobCtx, m.jobCancel = context.WithCancel(context.Background())
+	return m
+}
+
+// Register khai báo handler cho jobType. Payload được decode từ JSON sang T,
+// decode lỗi thì job vào dead-letter ngay vì thử lại cũng không khỏi.
+func Register[T any](m *Manager, jobType string, opts TypeOptions, run func(ctx context.Context, payload T) error) {
+	if opts.Queue == "" {
+		opts.Queue = DefaultQueue
+	}
+	if _, ok := m.opts.Queues[opts.Queue]; !ok {
+		panic(fmt.Sprintf("jobs: queue %q of job type %q is not configured", opts.Queue, jobType))
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = m.opts.MaxAttempts
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = m.opts.Timeout
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.handlers[jobType]; exists {
+		panic(fmt.Sprintf("jobs: job type %q registered twice", jobType))
+	}
+	m.handlers[jobType] = &handler{
+		queue:       opts.Queue,
+		maxAttempts: max(opts.MaxAttempts, 1),
+		timeout:     opts.Timeout,
+		run: func(ctx context.Context, raw json.RawMessage) error {
+			var payload T
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+			return run(ctx, payload)
+		},
+	}
+}
+
+func (m *Manager) handler(jobType string) *handler {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.handlers[jobType]
+}
+
+// Enqueue thêm job vào queue của jobType, payload được lưu dạng JSON
+func (m *Manager) Enqueue(ctx context.Context, jobType string, payload any, opts EnqueueOptions) (*Job, error) {
+	h := m.handler(jobType)
+	if h == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RunAt.IsZero() {
+		opts.RunAt = time.Now()
+	}
+
+	job, err := m.store.Insert(ctx, &Job{
+		Queue:       h.queue,
+		Type:        jobType,
+		Payload:     data,
+		Priority:    opts.Priority,
+		MaxAttempts: h.maxAttempts,
+		RunAt:       opts.RunAt,
+		UniqueKey:   opts.UniqueKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.RunAt.After(time.Now()) {
+		select {
+		case m.wake[h.queue] <- struct{}{}:
+		default:
+		}
+	}
+	return job, nil
+}
+
+// Start đưa job dở dang của lần chạy trước về hàng đợi rồi chạy worker và lịch cron ở nền
+func (m *Manager) Start(ctx context.Context) error {
+	recovered, err := m.store.Recover(ctx)
+	if err != nil {
+		return fmt.Errorf("recover jobs: %w", err)
+	}
+	if recovered > 0 {
+		slog.Warn("requeued jobs interrupted by previous shutdown", slog.Int64("count", recovered))
+	}
+
+	for queue, concurrency := range m.opts.Queues {
+		m.loops.Go(func() {
+			m.dispatch(queue, max(concurrency, 1))
+		})
+	}
+	m.loops.Go(m.runSchedules)
+	return nil
+}
+
+// Close ngừng lấy job mới và chờ job đang chạy xong. Hết ctx thì huỷ chúng,
+// job bị huỷ được trả về hàng đợi mà không mất lượt thử.
+func (m *Manager) Close(ctx context.Context) error {
+	m.cancel()
+	m.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		m.jobs.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.jobCancel()
+		<-done
+		err = ctx.Err()
+	}
+	return errors.Join(err, m.store.Close())
+}
+
+func (m *Manager) dispatch(queue string, concurrency int) {
+	slots := make(chan struct{}, concurrency)
+	ticker := time.NewTicker(m.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		m.heartbeat()
+
+		// Hết slot thì chờ, vẫn gửi heartbeat vì job chạy lâu không có nghĩa worker bị treo
+		select {
+		case slots <- struct{}{}:
+		case <-ticker.C:
+			continue
+		case <-m.ctx.Done():
+			return
+		}
+
+		job, err := m.store.Claim(m.ctx, queue, time.Now())
+		if err == nil {
+			m.jobs.Go(func() {
+				defer func() { <-slots }()
+				m.execute(job)
+			})
+			continue
+		}
+
+		<-slots
+		if !errors.Is(err, ErrNotFound) && m.ctx.Err() == nil {
+			slog.Error("claim job failed", slog.String("queue", queue), slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-m.wake[queue]:
+		case <-ticker.C:
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) heartbeat() {
+	if m.opts.Heartbeat != nil {
+		m.opts.Heartbeat()
+	}
+}
+
+func (m *Manager) execute(job *Job) {
+	ctx, cancel := context.WithCancelCause(m.jobCtx)
+	defer cancel(nil)
+
+	m.mu.Lock()
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	ctx, span := tracing.Start(ctx, "jobs."+job.Type,
+		tracing.Int64("job.id", job.ID),
+		tracing.String("job.queue", job.Queue),
+		tracing.Int("job.attempt", job.Attempts),
+	)
+	defer span.End()
+
+	logger := slog.Default().With(
+		slog.Int64("job_id", job.ID),
+		slog.String("job_type", job.Type),
+		slog.String("queue", job.Queue),
+		slog.Int("attempt", job.Attempts),
+	)
+	if sc := span.SpanContext(); sc.IsValid() {
+		logger = logger.With(slog.String("trace_id", sc.TraceID().String()))
+	}
+	ctx = logging.WithLogger(ctx, logger)
+
+	start := time.Now()
+	err := m.run(ctx, job)
+	metrics.JobDuration.Observe(time.Since(start).Seconds(), job.Queue, job.Type)
+	span.RecordError(err)
+
+	result, finishErr := m.finish(job, err, context.Cause(ctx))
+	metrics.JobsProcessed.Inc(job.Queue, job.Type, result)
+	if finishErr != nil {
+		logger.Error("saving job result failed", slog.String("result", result), slog.String("error", finishErr.Error()))
+	}
+}
+
+func (m *Manager) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	h := m.handler(job.Type)
+	if h == nil {
+		return Permanent(fmt.Errorf("%w: %s", ErrUnknownType, job.Type))
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	return h.run(ctx, job.Payload)
+}
+
+// finish ghi kết quả lần chạy, trả về result cho metric
+func (m *Manager) finish(job *Job, err, cause error) (string, error) {
+	// ctx của job có thể đã bị huỷ, vẫn phải ghi được kết quả
+	ctx := context.Background()
+	logger := slog.Default().With(slog.Int64("job_id", job.ID), slog.String("job_type", job.Type))
+
+	switch {
+	case err == nil:
+		logger.Debug("job succeeded")
+		return "succeeded", m.store.Finish(ctx, job.ID, StatusSucceeded, "")
+	case errors.Is(cause, errCancelled):
+		logger.Info("job cancelled")
+		return "cancelled", m.store.Finish(ctx, job.ID, StatusCancelled, errCancelled.Error())
+	case m.jobCtx.Err() != nil:
+		logger.Warn("job interrupted by shutdown, requeued")
+		return "interrupted", m.store.Reschedule(ctx, job.ID, time.Now(), "interrupted by shutdown", true)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		logger.Error("job moved to dead letter", slog.Int("attempts", job.Attempts), slog.String("error", err.Error()))
+		return "dead", m.store.Finish(ctx, job.ID, StatusDead, err.Error())
+	default:
+		delay := durable.Backoff(job.Attempts, m.opts.RetryBase, m.opts.RetryMax)
+		logger.Warn("job failed, will retry", slog.Int("attempts", job.Attempts), slog.Duration("retry_in", delay), slog.String("error", err.Error()))
+		return "retried", m.store.Reschedule(ctx, job.ID, time.Now().Add(delay), err.Error(), false)
+	}
+}
+
+// Retry đưa job dead hoặc cancelled về hàng đợi
+func (m *Manager) Retry(ctx context.Context, id int64) (*Job, error) {
+	job, err := m.store.Retry(ctx, id)
+	if err == nil {
+		select {
+		case m.wake[job.Queue] <- struct{}{}:
+		default:
+		}
+	}
+	return job, err
+}
+
+// Cancel huỷ job queued ngay. Job đang chạy được huỷ qua context, job trả về vẫn là running
+// và chuyển sang cancelled khi handler dừng.
+func (m *Manager) Cancel(ctx context.Context, id int64) (*Job, error) {
+	job, err := m.store.Cancel(ctx, id)
+	if !errors.Is(err, ErrInvalidState) || job.Status != StatusRunning {
+		return job, err
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.running[id]
+	m.mu.Unlock()
+	if !ok {
+		return job, ErrInvalidState
+	}
+	cancel(errCancelled)
+	return job, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id int64) (*Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+func (m *Manager) List(ctx context.Context, filter Filter) ([]Job, error) {
+	return m.store.List(ctx, filter)
+}
+
+// Queues trả về cấu hình và số job theo trạng thái của từng queue
+func (m *Manager) Queues(ctx context.Context) ([]QueueInfo, error) {
+	counts, err := m.store.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queues := make([]QueueInfo, 0, len(m.opts.Queues))
+	for name, concurrency := range m.opts.Queues {
+		info := QueueInfo{Name: name, Concurrency: concurrency, Jobs: counts[name]}
+		if info.Jobs == nil {
+			info.Jobs = map[Status]int{}
+		}
+		queues = append(queues, info)
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Name < queues[j].Name })
+	return queues, nil
+}
+
+type QueueInfo struct {
+	Name        string         `json:"name"`
+	Concurrency int            `json:"concurrency"`
+	Jobs        map[Status]int `json:"jobs"`
+}
@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cleanupInterval: chu kỳ xoá job cũ theo Options.Retention
+const cleanupInterval = time.Hour
+
+type schedule struct {
+	name    string
+	spec    string
+	jobType string
+	payload any
+	cron    cron.Schedule
+
+	mu      sync.Mutex
+	next    time.Time
+	lastJob int64
+}
+
+// ScheduleInfo là lịch cron trả về cho admin
+type ScheduleInfo struct {
+	Name      string    `json:"name"`
+	Spec      string    `json:"spec"`
+	Type      string    `json:"type"`
+	NextRun   time.Time `json:"next_run"`
+	LastJobID int64     `json:"last_job_id,omitempty"`
+}
+
+// Schedule tạo job jobType theo lịch cron 5 trường (VD: "*/5 * * * *"), phải gọi trước Start.
+// Mỗi lần chạy có UniqueKey theo thời điểm nên restart không tạo job trùng;
+// các lần bị lỡ khi server dừng thì bỏ qua.
+func (m *Manager) Schedule(name, spec, jobType string, payload any) error {
+	parsed, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %q: %w", name, err)
+	}
+	if m.handler(jobType) == nil {
+		return fmt.Errorf("schedule %q: %w: %s", name, ErrUnknownType, jobType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedules = append(m.schedules, &schedule{
+		name:    name,
+		spec:    spec,
+		jobType: jobType,
+		payload: payload,
+		cron:    parsed,
+		next:    parsed.Next(time.Now()),
+	})
+	return nil
+}
+
+func (m *Manager) Schedules() []ScheduleInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]ScheduleInfo, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		s.mu.Lock()
+		infos = append(infos, ScheduleInfo{Name: s.name, Spec: s.spec, Type: s.jobType, NextRun: s.next.UTC(), LastJobID: s.lastJob})
+		s.mu.Unlock()
+	}
+	return infos
+}
+
+func (m *Manager) runSchedules() {
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+	m.deleteFinished()
+
+	for {
+		timer := time.NewTimer(m.nextScheduled())
+		select {
+		case <-m.ctx.Done():
+			timer.Stop()
+			return
+		case <-cleanup.C:
+			timer.Stop()
+			m.deleteFinished()
+		case now := <-timer.C:
+			m.enqueueDue(now)
+		}
+	}
+}
+
+// nextScheduled trả về thời gian chờ đến lịch gần nhất, không có lịch thì chờ cleanup
+func (m *Manager) nextScheduled() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wait := cleanupInterval
+	for _, s := range m.schedules {
+		s.mu.Lock()
+		wait = min(wait, time.Until(s.next))
+		s.mu.Unlock()
+	}
+	return max(wait, 0)
+}
+
+func (m *Manager) enqueueDue(now time.Time) {
+	m.mu.Lock()
+	schedules := append([]*schedule(nil), m.schedules...)
+	m.mu.Unlock()
+
+	for _, s := range schedules {
+		s.mu.Lock()
+		due := s.next
+		if due.After(now) {
+			s.mu.Unlock()
+			continue
+		}
+		s.next = s.cron.Next(now)
+		s.mu.Unlock()
+
+		job, err := m.Enqueue(m.ctx, s.jobType, s.payload, EnqueueOptions{
+			UniqueKey: "schedule:" + s.name + ":" + due.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			slog.Error("enqueue scheduled job failed", slog.String("schedule", s.name), slog.String("error", err.Error()))
+			continue
+		}
+
+		s.mu.Lock()
+		s.lastJob = job.ID
+		s.mu.Unlock()
+	}
+}
+
+func (m *Manager) deleteFinished() {
+	if m.opts.Retention <= 0 {
+		return
+	}
+
+	deleted, err := m.store.DeleteFinished(context.Background(), time.Now().Add(-m.opts.Retention))
+	if err != nil {
+		slog.Error("delete finished jobs failed", slog.String("error", err.Error()))
+		return
+	}
+	if deleted > 0 {
+		slog.Info("deleted finished jobs", slog.Int64("count", deleted), slog.Duration("retention", m.opts.Retention))
+	}
+}
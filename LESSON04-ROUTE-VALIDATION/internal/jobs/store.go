@@ -0,0 +1,324 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mamba.com/route-group/internal/durable"
+	"mamba.com/route-group/internal/tracing"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	queue        TEXT    NOT NULL,
+	type         TEXT    NOT NULL,
+	payload      BLOB    NOT NULL,
+	priority     INTEGER NOT NULL DEFAULT 0,
+	status       TEXT    NOT NULL,
+	attempts     INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL,
+	run_at       INTEGER NOT NULL,
+	last_error   TEXT    NOT NULL DEFAULT '',
+	unique_key   TEXT UNIQUE,
+	created_at   INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL,
+	finished_at  INTEGER
+);
+CREATE INDEX IF NOT EXISTS jobs_ready ON jobs (queue, status, priority DESC, run_at, id);
+CREATE INDEX IF NOT EXISTS jobs_finished ON jobs (status, finished_at);
+`
+
+const jobColumns = `id, queue, type, payload, priority, status, attempts, max_attempts,
+	run_at, last_error, unique_key, created_at, updated_at, finished_at`
+
+// Store lưu hàng đợi trong SQLite để job không mất khi restart
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore mở (hoặc tạo) file SQLite tại path
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := durable.Open(path, schema)
+	if err != nil {
+		return nil, fmt.Errorf("jobs store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Ping cho health check readiness
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Insert thêm job, trùng UniqueKey thì trả về job đã có
+func (s *Store) Insert(ctx context.Context, job *Job) (*Job, error) {
+	_, span := tracing.Start(ctx, "JobStore.Insert", tracing.String("job.type", job.Type))
+	defer span.End()
+
+	now := time.Now()
+	var uniqueKey any
+	if job.UniqueKey != "" {
+		uniqueKey = job.UniqueKey
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO jobs (queue, type, payload, priority, status, max_attempts, run_at, unique_key, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (unique_key) DO NOTHING
+		RETURNING `+jobColumns,
+		job.Queue, job.Type, []byte(job.Payload), job.Priority, StatusQueued, job.MaxAttempts,
+		durable.Millis(job.RunAt), uniqueKey, durable.Millis(now), durable.Millis(now),
+	)
+
+	inserted, err := scanJob(row)
+	if errors.Is(err, ErrNotFound) && job.UniqueKey != "" {
+		return s.getBy(ctx, "unique_key = ?", job.UniqueKey)
+	}
+	span.RecordError(err)
+	return inserted, err
+}
+
+// Claim lấy job sẵn sàng có priority cao nhất của queue và chuyển sang running
+func (s *Store) Claim(ctx context.Context, queue string, now time.Time) (*Job, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE queue = ? AND status = ? AND run_at <= ?
+			ORDER BY priority DESC, run_at, id
+			LIMIT 1
+		)
+		RETURNING `+jobColumns,
+		StatusRunning, durable.Millis(now), queue, StatusQueued, durable.Millis(now),
+	)
+	return scanJob(row)
+}
+
+// Finish ghi kết quả cuối cùng của job đang chạy: succeeded, dead hoặc cancelled
+func (s *Store) Finish(ctx context.Context, id int64, status Status, lastError string) error {
+	now := durable.Millis(time.Now())
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, last_error = ?, updated_at = ?, finished_at = ?
+		WHERE id = ? AND status = ?`,
+		status, lastError, now, now, id, StatusRunning,
+	)
+	return err
+}
+
+// Reschedule đưa job đang chạy về queued để thử lại lúc runAt.
+// refund=true trả lại lượt thử, dùng khi job bị ngắt do shutdown chứ không phải do lỗi.
+func (s *Store) Reschedule(ctx context.Context, id int64, runAt time.Time, lastError string, refund bool) error {
+	refunded := 0
+	if refund {
+		refunded = 1
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, run_at = ?, last_error = ?, attempts = attempts - ?, updated_at = ?
+		WHERE id = ? AND status = ?`,
+		StatusQueued, durable.Millis(runAt), lastError, refunded, durable.Millis(time.Now()), id, StatusRunning,
+	)
+	return err
+}
+
+// Recover xử lý job còn running từ lần chạy trước (process bị kill):
+// còn lượt thì chạy lại, hết lượt thì vào dead-letter
+func (s *Store) Recover(ctx context.Context) (int64, error) {
+	now := durable.Millis(time.Now())
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, last_error = 'interrupted: process stopped while running', updated_at = ?, finished_at = ?
+		WHERE status = ? AND attempts >= max_attempts`,
+		StatusDead, now, now, StatusRunning,
+	); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, run_at = ?, updated_at = ? WHERE status = ?`,
+		StatusQueued, now, now, StatusRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+	recovered, _ := result.RowsAffected()
+	return recovered, tx.Commit()
+}
+
+// Retry đưa job dead hoặc cancelled về hàng đợi với đủ số lượt thử
+func (s *Store) Retry(ctx context.Context, id int64) (*Job, error) {
+	now := durable.Millis(time.Now())
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = ?, attempts = 0, run_at = ?, updated_at = ?, finished_at = NULL
+		WHERE id = ? AND status IN (?, ?)
+		RETURNING `+jobColumns,
+		StatusQueued, now, now, id, StatusDead, StatusCancelled,
+	)
+	return s.transition(ctx, id, row)
+}
+
+// Cancel huỷ job chưa chạy. Job đang chạy do Manager huỷ qua context.
+func (s *Store) Cancel(ctx context.Context, id int64) (*Job, error) {
+	now := durable.Millis(time.Now())
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = ?, updated_at = ?, finished_at = ?
+		WHERE id = ? AND status = ?
+		RETURNING `+jobColumns,
+		StatusCancelled, now, now, id, StatusQueued,
+	)
+	return s.transition(ctx, id, row)
+}
+
+// transition phân biệt job không tồn tại (ErrNotFound) với job sai trạng thái (ErrInvalidState)
+func (s *Store) transition(ctx context.Context, id int64, row *sql.Row) (*Job, error) {
+	job, err := scanJob(row)
+	if !errors.Is(err, ErrNotFound) {
+		return job, err
+	}
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return current, ErrInvalidState
+}
+
+func (s *Store) Get(ctx context.Context, id int64) (*Job, error) {
+	return s.getBy(ctx, "id = ?", id)
+}
+
+func (s *Store) getBy(ctx context.Context, where string, arg any) (*Job, error) {
+	return scanJob(s.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE `+where, arg))
+}
+
+// List trả về job mới nhất trước
+func (s *Store) List(ctx context.Context, filter Filter) ([]Job, error) {
+	_, span := tracing.Start(ctx, "JobStore.List")
+	defer span.End()
+
+	var conditions []string
+	var args []any
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Queue != "" {
+		conditions = append(conditions, "queue = ?")
+		args = append(args, filter.Queue)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// Counts đếm job theo queue và trạng thái, dùng cho trang admin
+func (s *Store) Counts(ctx context.Context) (map[string]map[Status]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT queue, status, COUNT(*) FROM jobs GROUP BY queue, status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[Status]int)
+	for rows.Next() {
+		var queue string
+		var status Status
+		var n int
+		if err := rows.Scan(&queue, &status, &n); err != nil {
+			return nil, err
+		}
+		if counts[queue] == nil {
+			counts[queue] = make(map[Status]int)
+		}
+		counts[queue][status] = n
+	}
+	return counts, rows.Err()
+}
+
+// DeleteFinished xoá job succeeded/cancelled cũ hơn before. Job dead được giữ lại đến khi admin xử lý.
+func (s *Store) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM jobs WHERE status IN (?, ?) AND finished_at < ?`,
+		StatusSucceeded, StatusCancelled, durable.Millis(before),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (*Job, error) {
+	var job Job
+	var payload []byte
+	var runAt, createdAt, updatedAt int64
+	var uniqueKey sql.NullString
+	var finishedAt sql.NullInt64
+
+	err := row.Scan(&job.ID, &job.Queue, &job.Type, &payload, &job.Priority, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&runAt, &job.LastError, &uniqueKey, &createdAt, &updatedAt, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+	job.RunAt = durable.Time(runAt)
+	job.UniqueKey = uniqueKey.String
+	job.CreatedAt = durable.Time(createdAt)
+	job.UpdatedAt = durable.Time(updatedAt)
+	if finishedAt.Valid {
+		t := durable.Time(finishedAt.Int64)
+		job.FinishedAt = &t
+	}
+	return &job, nil
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type Options struct {
+	// Format là "json" hoặc "text"
+	Format string
+	// Level dùng *slog.LevelVar nếu muốn đổi level lúc đang chạy (config reload)
+	Level slog.Leveler
+}
+
+// ParseLevel đọc "debug", "info", "warn" hoặc "error"
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("logging: invalid level %q", value)
+	}
+	return level, nil
+}
+
+// New tạo logger, mọi attr có key nhạy cảm (password, token, authorization...)
+// đều bị thay bằng [REDACTED] kể cả khi handler tự log
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	handlerOpts := &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: redactAttr,
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("logging: invalid format %q", opts.Format)
+	}
+}
+
+type contextKey struct{}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext trả về logger của request, ngoài request thì dùng slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if IsSensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
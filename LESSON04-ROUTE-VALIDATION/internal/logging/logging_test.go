@@ -0,0 +1,194 @@
+package logging_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/logging"
+)
+
+// newEngine ghi log dạng JSON ở mức debug (access log có cả header) vào buffer trả về
+func newEngine(t *testing.T) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, logging.Options{Format: "json", Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(logging.Middleware(logger))
+	r.GET("/echo", func(ctx *gin.Context) {
+		logging.From(ctx).Info("handler", slog.String("password", "hunter2"), slog.String("name", "alice"))
+		ctx.String(http.StatusOK, logging.RequestID(ctx))
+	})
+	r.GET("/status/:code", func(ctx *gin.Context) {
+		switch ctx.Param("code") {
+		case "404":
+			ctx.Status(http.StatusNotFound)
+		case "500":
+			ctx.Status(http.StatusInternalServerError)
+		default:
+			ctx.Status(http.StatusOK)
+		}
+	})
+	// Giống versioning.Dispatch: chuyển request sang route khác bằng HandleContext
+	r.GET("/forward", func(ctx *gin.Context) {
+		ctx.Request.URL.Path = "/echo"
+		r.HandleContext(ctx)
+	})
+	return r, &logs
+}
+
+func entries(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	lines := bufio.NewScanner(logs)
+	for lines.Scan() {
+		var entry map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", lines.Text(), err)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		incoming string
+		// keep: request ID của client được dùng lại
+		keep bool
+	}{
+		{"generated", "/echo", "", false},
+		{"from client", "/echo", "client-42.retry:1", true},
+		{"unsafe value replaced", "/echo", "bad id\nwith newline", false},
+		{"too long replaced", "/echo", strings.Repeat("a", 129), false},
+		{"forwarded request", "/forward", "forwarded-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, logs := newEngine(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.incoming != "" {
+				req.Header.Set(logging.HeaderRequestID, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(logging.HeaderRequestID)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("request ID = %q, want %q", id, tt.incoming)
+			}
+			if !tt.keep {
+				if _, err := uuid.Parse(id); err != nil {
+					t.Errorf("request ID = %q, want a generated UUID", id)
+				}
+			}
+			if w.Body.String() != id {
+				t.Errorf("RequestID in handler = %q, header = %q", w.Body.String(), id)
+			}
+
+			// 1 dòng của handler và đúng 1 access log, cả 2 mang cùng request_id
+			got := entries(t, logs)
+			if len(got) != 2 || got[0]["msg"] != "handler" || got[1]["msg"] != "request" {
+				t.Fatalf("log entries = %v", got)
+			}
+			for _, entry := range got {
+				if entry["request_id"] != id {
+					t.Errorf("%s: request_id = %v, want %q", entry["msg"], entry["request_id"], id)
+				}
+			}
+		})
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	r, logs := newEngine(t)
+	req := httptest.NewRequest(http.MethodGet, "/echo?access_token=q-secret&page=2&API_KEY=k-secret", nil)
+	req.Header.Set("Authorization", "Bearer h-secret")
+	req.Header.Set("X-Api-Key", "x-secret")
+	req.Header.Set("Cookie", "session=c-secret")
+	req.Header.Set("Accept", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	raw := logs.String()
+	for _, secret := range []string{"hunter2", "q-secret", "k-secret", "h-secret", "x-secret", "c-secret"} {
+		if strings.Contains(raw, secret) {
+			t.Errorf("log contains %q:\n%s", secret, raw)
+		}
+	}
+
+	got := entries(t, bytes.NewBufferString(raw))
+	if len(got) != 2 {
+		t.Fatalf("log entries = %v", got)
+	}
+	handler, access := got[0], got[1]
+	if handler["password"] != logging.Redacted || handler["name"] != "alice" {
+		t.Errorf("handler entry = %v", handler)
+	}
+	if query := access["query"]; query != "API_KEY=[REDACTED]&access_token=[REDACTED]&page=2" {
+		t.Errorf("query = %v", query)
+	}
+	headers, _ := access["headers"].(map[string]any)
+	for _, name := range []string{"Authorization", "X-Api-Key", "Cookie"} {
+		if headers[name] != logging.Redacted {
+			t.Errorf("header %s = %v", name, headers[name])
+		}
+	}
+	if headers["Accept"] != "application/json" {
+		t.Errorf("Accept = %v, other headers must be kept", headers["Accept"])
+	}
+}
+
+func TestAccessLogLevel(t *testing.T) {
+	tests := []struct {
+		code  string
+		level string
+	}{
+		{"200", "INFO"},
+		{"404", "WARN"},
+		{"500", "ERROR"},
+	}
+	for _, tt := range tests {
+		r, logs := newEngine(t)
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/"+tt.code, nil))
+
+		got := entries(t, logs)
+		if len(got) != 1 || got[0]["level"] != tt.level || got[0]["route"] != "/status/:code" {
+			t.Errorf("status %s: log = %v, want level %s", tt.code, got, tt.level)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	if _, err := logging.New(&bytes.Buffer{}, logging.Options{Format: "xml"}); err == nil {
+		t.Error("invalid format should fail")
+	}
+	if _, err := logging.ParseLevel("loud"); err == nil {
+		t.Error("invalid level should fail")
+	}
+
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, logging.Options{Format: "text", Level: slog.LevelWarn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", slog.String("client_secret", "s"))
+	if out := logs.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "client_secret="+logging.Redacted) {
+		t.Errorf("text log = %q", out)
+	}
+}
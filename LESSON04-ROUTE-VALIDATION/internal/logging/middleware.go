@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/tracing"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+const requestIDKey = "logging.request_id"
+
+type requestIDContextKey struct{}
+
+// Request ID từ client chỉ được dùng lại khi an toàn để ghi vào log và header
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware gán X-Request-ID, gắn logger theo request vào context và ghi access log
+// khi request kết thúc: 5xx ở mức error, 4xx ở mức warn, còn lại info
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Request được chuyển tiếp bằng engine.HandleContext (VD: versioning.Dispatch)
+		// đi qua middleware lần 2, access log đã do lần đầu ghi
+		if requestID, ok := ctx.Request.Context().Value(requestIDContextKey{}).(string); ok {
+			ctx.Set(requestIDKey, requestID)
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+
+		requestID := ctx.GetHeader(HeaderRequestID)
+		if !requestIDRegex.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(HeaderRequestID, requestID)
+
+		requestLogger := logger.With(slog.String("request_id", requestID))
+		if sc := tracing.SpanContextFromContext(ctx.Request.Context()); sc.IsValid() {
+			requestLogger = requestLogger.With(
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+		requestCtx := context.WithValue(ctx.Request.Context(), requestIDContextKey{}, requestID)
+		ctx.Request = ctx.Request.WithContext(WithLogger(requestCtx, requestLogger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("query", RedactQuery(ctx.Request.URL.Query())),
+			slog.Int("status", status),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.String("user_agent", ctx.Request.UserAgent()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", ctx.Errors.String()))
+		}
+		if requestLogger.Enabled(ctx.Request.Context(), slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("headers", RedactHeaders(ctx.Request.Header)))
+		}
+
+		requestLogger.LogAttrs(context.Background(), level, "request", attrs...)
+	}
+}
+
+// From trả về logger của request hiện tại, dùng trong handler
+func From(ctx *gin.Context) *slog.Logger {
+	return FromContext(ctx.Request.Context())
+}
+
+func RequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
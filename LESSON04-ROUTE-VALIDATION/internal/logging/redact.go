@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+var sensitiveKeys = []string{
+	"authorization",
+	"cookie",
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"api_key",
+	"api-key",
+	"apikey",
+}
+
+// IsSensitive so khớp không phân biệt hoa thường và theo chuỗi con,
+// VD: "access_token", "X-Api-Key", "new_password" đều bị coi là nhạy cảm
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func RedactHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if IsSensitive(key) {
+			result[key] = Redacted
+			continue
+		}
+		result[key] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+func RedactQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	redacted := make(url.Values, len(query))
+	for key, values := range query {
+		if IsSensitive(key) {
+			redacted[key] = []string{Redacted}
+			continue
+		}
+		redacted[key] = values
+	}
+
+	// Encode sẽ escape [REDACTED], log bản đã unescape cho dễ đọc
+	encoded, err := url.QueryUnescape(redacted.Encode())
+	if err != nil {
+		return redacted.Encode()
+	}
+	return encoded
+}
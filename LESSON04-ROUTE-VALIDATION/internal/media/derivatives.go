@@ -0,0 +1,103 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/storage"
+	"mamba.com/route-group/internal/tracing"
+)
+
+// DerivativeDir là thư mục con của thư mục upload chứa ảnh thu nhỏ
+const DerivativeDir = "derivatives"
+
+// DefaultWidths là chiều rộng các bản thu nhỏ, ảnh gốc hẹp hơn thì bỏ qua bản đó
+var DefaultWidths = []int{320, 640}
+
+// maxPixels chặn ảnh có kích thước khai báo quá lớn (decompression bomb)
+const maxPixels = 50_000_000
+
+// ErrUnsupportedImage: file không phải JPEG/PNG hoặc quá lớn, tạo lại cũng không được
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+// Derivatives đọc ảnh filename từ store và ghi các bản thu nhỏ theo widths, giữ tỉ lệ.
+// Tên file: derivatives/<tên gốc>-<width>w<đuôi gốc>.
+func Derivatives(ctx context.Context, store storage.Storage, filename string, widths []int) (result []models.ImageDerivative, err error) {
+	ctx, span := tracing.Start(ctx, "media.Derivatives", tracing.String("media.filename", filename))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	file, err := store.Open(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrUnsupportedImage, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	ext := path.Ext(filename)
+	base := strings.TrimSuffix(path.Base(filename), ext)
+	for _, width := range widths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if width >= config.Width {
+			continue
+		}
+
+		height := max(config.Height*width/config.Width, 1)
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := encode(&buf, dst, format); err != nil {
+			return nil, err
+		}
+
+		name := path.Join(DerivativeDir, fmt.Sprintf("%s-%dw%s", base, width, ext))
+		if err := store.Put(ctx, name, &buf); err != nil {
+			return nil, err
+		}
+		result = append(result, models.ImageDerivative{Width: width, Height: height, Filename: name})
+	}
+
+	span.SetAttributes(tracing.Int("media.derivatives", len(result)))
+	return result, nil
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case "png":
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("%w: format %s", ErrUnsupportedImage, format)
+}
@@ -0,0 +1,104 @@
+package metrics
+
+var (
+	HTTPRequests = NewCounterVec("http_requests_total",
+		"Total HTTP requests by method, route template and status.",
+		"method", "route", "status")
+	HTTPRequestDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency by method, route template and status.",
+		DefaultBuckets, "method", "route", "status")
+	HTTPRequestsInFlight = NewGaugeVec("http_requests_in_flight",
+		"HTTP requests currently being served.")
+
+	ValidationFailures = NewCounterVec("validation_failures_total",
+		"Request validation failures by validator tag and field.",
+		"tag", "field")
+
+	UploadBytes = NewCounterVec("upload_bytes_total",
+		"Bytes of uploaded files that were accepted and saved.")
+	UploadFiles = NewCounterVec("upload_files_total",
+		"Uploaded files that were accepted and saved.")
+	UploadRejections = NewCounterVec("upload_rejections_total",
+		"Uploaded files rejected by ValidateAndSaveFile, by reason.",
+		"reason")
+
+	RateLimited = NewCounterVec("rate_limited_requests_total",
+		"Requests rejected with 429 by rate limiter.",
+		"limiter")
+	RateLimitErrors = NewCounterVec("rate_limit_store_errors_total",
+		"Rate limit store failures; the request was allowed through.",
+		"limiter")
+
+	IdempotencyRequests = NewCounterVec("idempotency_requests_total",
+		"Requests with Idempotency-Key by result: stored, replayed, mismatch (422) or conflict (409).",
+		"result")
+
+	JobsProcessed = NewCounterVec("jobs_processed_total",
+		"Background job runs by queue, type and result: succeeded, retried, dead, cancelled or interrupted.",
+		"queue", "type", "result")
+	JobDuration = NewHistogramVec("job_duration_seconds",
+		"Background job run time by queue and type.",
+		[]float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300}, "queue", "type")
+
+	EventsAppended = NewCounterVec("outbox_events_appended_total",
+		"Domain events written to the outbox by type.",
+		"type")
+	EventsHandled = NewCounterVec("outbox_events_handled_total",
+		"Domain event handler runs by consumer and result: succeeded, retried or skipped.",
+		"consumer", "result")
+
+	WebhookDeliveries = NewCounterVec("webhook_delivery_attempts_total",
+		"Outgoing webhook delivery attempts by event and result: succeeded, retrying or failed.",
+		"event", "result")
+
+	StreamClients = NewGaugeVec("stream_clients",
+		"Clients connected to the live update stream by transport: sse or websocket.",
+		"transport")
+	StreamMessages = NewCounterVec("stream_messages_total",
+		"Live update messages published by topic.",
+		"topic")
+	StreamDropped = NewCounterVec("stream_clients_dropped_total",
+		"Stream clients disconnected because their send buffer was full, by transport.",
+		"transport")
+
+	CacheRequests = NewCounterVec("response_cache_requests_total",
+		"Cacheable requests by route and result: hit, stale, miss or coalesced.",
+		"route", "result")
+	CacheEvictions = NewCounterVec("response_cache_evictions_total",
+		"Response cache entries removed by reason: capacity, expired, purged or deleted.",
+		"reason")
+
+	CompressedResponses = NewCounterVec("http_responses_compressed_total",
+		"Responses sent with a Content-Encoding by encoding.",
+		"encoding")
+	CompressionBytes = NewCounterVec("http_response_compression_bytes_total",
+		"Body bytes of compressed responses by encoding, before (in) and after (out) compression.",
+		"encoding", "stage")
+)
+
+func init() {
+	Default.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		ValidationFailures,
+		UploadBytes,
+		UploadFiles,
+		UploadRejections,
+		RateLimited,
+		RateLimitErrors,
+		IdempotencyRequests,
+		JobsProcessed,
+		JobDuration,
+		EventsAppended,
+		EventsHandled,
+		WebhookDeliveries,
+		StreamClients,
+		StreamMessages,
+		StreamDropped,
+		CacheRequests,
+		CacheEvictions,
+		CompressedResponses,
+		CompressionBytes,
+	)
+}
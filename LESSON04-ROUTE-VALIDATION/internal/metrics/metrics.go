@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CounterVec bọc prometheus.CounterVec để call site chỉ truyền giá trị label
+type CounterVec struct {
+	*prometheus.CounterVec
+}
+
+// NewCounterVec không có label thì có sẵn series giá trị 0
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)}
+	if len(labels) == 0 {
+		c.WithLabelValues()
+	}
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.WithLabelValues(values...).Inc()
+}
+
+func (c *CounterVec) Add(delta float64, values ...string) {
+	c.WithLabelValues(values...).Add(delta)
+}
+
+type GaugeVec struct {
+	*prometheus.GaugeVec
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)}
+	if len(labels) == 0 {
+		g.WithLabelValues()
+	}
+	return g
+}
+
+func (g *GaugeVec) Add(delta float64, values ...string) { g.WithLabelValues(values...).Add(delta) }
+func (g *GaugeVec) Set(value float64, values ...string) { g.WithLabelValues(values...).Set(value) }
+func (g *GaugeVec) Inc(values ...string)                { g.WithLabelValues(values...).Inc() }
+func (g *GaugeVec) Dec(values ...string)                { g.WithLabelValues(values...).Dec() }
+
+// DefaultBuckets đơn vị giây
+var DefaultBuckets = prometheus.DefBuckets
+
+type HistogramVec struct {
+	*prometheus.HistogramVec
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)}
+}
+
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.WithLabelValues(values...).Observe(value)
+}
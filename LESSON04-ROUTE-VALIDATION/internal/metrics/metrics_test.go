@@ -0,0 +1,204 @@
+package metrics_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/utils"
+)
+
+type postUser struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func TestScrape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(metrics.Middleware())
+	r.GET("/users/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	r.POST("/users", func(ctx *gin.Context) {
+		var body postUser
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			utils.RenderValidationError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusCreated)
+	})
+	r.GET("/metrics", metrics.Default.Handler())
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := http.Post(srv.URL+"/users", "application/json", strings.NewReader(`{"email":"nope"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	samples, types := parseExposition(t, resp.Body)
+
+	want := map[string]float64{
+		`http_requests_total{method="GET",route="/users/:id",status="200"}`:                 2,
+		`http_requests_total{method="GET",route="",status="404"}`:                           1,
+		`http_requests_total{method="POST",route="/users",status="400"}`:                    1,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"}`: 2,
+		`validation_failures_total{field="postUser.Email",tag="email"}`:                     1,
+		// chính request /metrics đang được phục vụ
+		`http_requests_in_flight`: 1,
+		// counter không label có sẵn series 0
+		`upload_files_total`: 0,
+	}
+	for series, value := range want {
+		got, ok := samples[series]
+		if !ok {
+			t.Errorf("missing %s", series)
+			continue
+		}
+		if got != value {
+			t.Errorf("%s = %v, want %v", series, got, value)
+		}
+	}
+
+	for name, kind := range map[string]string{
+		"http_requests_total":           "counter",
+		"http_requests_in_flight":       "gauge",
+		"http_request_duration_seconds": "histogram",
+	} {
+		if types[name] != kind {
+			t.Errorf("TYPE %s = %q, want %q", name, types[name], kind)
+		}
+	}
+
+	// Bucket cộng dồn, không giảm, và +Inf bằng _count
+	labels := `method="GET",route="/users/:id",status="200"`
+	previous := 0.0
+	for _, le := range []string{"0.005", "0.01", "0.025", "0.05", "0.1", "0.25", "0.5", "1", "2.5", "5", "10", "+Inf"} {
+		series := `http_request_duration_seconds_bucket{` + labels + `,le="` + le + `"}`
+		got, ok := samples[series]
+		if !ok {
+			t.Fatalf("missing %s", series)
+		}
+		if got < previous {
+			t.Errorf("%s = %v, less than previous bucket %v", series, got, previous)
+		}
+		previous = got
+	}
+	if previous != samples[`http_request_duration_seconds_count{`+labels+`}`] {
+		t.Errorf("+Inf bucket %v differs from _count", previous)
+	}
+}
+
+var sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*",?)*\})? (\S+)$`)
+
+// parseExposition đọc text format 0.0.4: mỗi sample phải thuộc một metric đã khai báo TYPE trước đó
+func parseExposition(t *testing.T, r io.Reader) (map[string]float64, map[string]string) {
+	t.Helper()
+
+	samples := map[string]float64{}
+	types := map[string]string{}
+	helps := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fields, ok := strings.CutPrefix(line, "# HELP "); ok {
+			name, _, _ := strings.Cut(fields, " ")
+			helps[name] = true
+			continue
+		}
+		if fields, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, kind, _ := strings.Cut(fields, " ")
+			if !helps[name] {
+				t.Errorf("TYPE %s without HELP", name)
+			}
+			if _, ok := types[name]; ok {
+				t.Errorf("TYPE %s declared twice", name)
+			}
+			types[name] = kind
+			continue
+		}
+
+		m := sampleLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("invalid sample line %q", line)
+			continue
+		}
+		value, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			t.Errorf("invalid value in %q: %v", line, err)
+			continue
+		}
+
+		family := m[1]
+		if types[family] == "" {
+			for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+				if base, ok := strings.CutSuffix(family, suffix); ok && types[base] == "histogram" {
+					family = base
+				}
+			}
+		}
+		if types[family] == "" {
+			t.Errorf("sample %q has no TYPE", line)
+		}
+		samples[m[1]+m[2]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return samples, types
+}
+
+func TestOpenMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := metrics.NewRegistry()
+	c := metrics.NewCounterVec("negotiated_total", "Counter served as OpenMetrics.", "value")
+	registry.MustRegister(c)
+	c.Inc("a\"b")
+
+	r := gin.New()
+	r.GET("/metrics", registry.Handler())
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `negotiated_total{value="a\"b"} 1`) || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+
+	if err := registry.Register(metrics.NewCounterVec("negotiated_total", "Counter served as OpenMetrics.", "value")); err == nil {
+		t.Error("registering the same name twice should fail")
+	}
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware đếm request theo route template (ctx.FullPath) thay vì path thật
+// để số series không tăng theo id/slug. Request không khớp route nào có route="".
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Request chuyển tiếp bằng engine.HandleContext đã được đếm ở lần đầu
+		if ctx.Request.Context().Value(observedKey{}) != nil {
+			ctx.Next()
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), observedKey{}, true))
+
+		start := time.Now()
+		HTTPRequestsInFlight.Inc()
+		defer HTTPRequestsInFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		status := strconv.Itoa(ctx.Writer.Status())
+		HTTPRequests.Inc(ctx.Request.Method, route, status)
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), ctx.Request.Method, route, status)
+	}
+}
+
+type observedKey struct{}
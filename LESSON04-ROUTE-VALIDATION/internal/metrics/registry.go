@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Registry struct {
+	*prometheus.Registry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{prometheus.NewRegistry()}
+}
+
+// Default chứa các metric của ứng dụng (HTTP, validation, upload)
+var Default = NewRegistry()
+
+// Handler phục vụ /metrics, định dạng (text hay OpenMetrics) theo Accept của scraper
+func (r *Registry) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(r, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	NewsStatusPublished = "1"
+	NewsStatusDraft     = "2"
+)
+
+// ImageDerivative là bản thu nhỏ của ảnh, Filename tương đối với thư mục upload
+type ImageDerivative struct {
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Filename string `json:"filename"`
+}
+
+type NewsImage struct {
+	Filename string `json:"filename"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	// Derivatives được tạo ở nền sau khi upload, có thể chưa có ngay
+	Derivatives []ImageDerivative `json:"derivatives,omitempty"`
+}
+
+type News struct {
+	ID          int         `json:"id"`
+	Slug        string      `json:"slug"`
+	Title       string      `json:"title"`
+	Content     string      `json:"content"`
+	Category    string      `json:"category"`
+	Status      string      `json:"status"`
+	Images      []NewsImage `json:"images"`
+	PublishedAt time.Time   `json:"published_at"`
+	// ScheduledAt: tin nháp sẽ được publish vào lúc này
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	// Version tăng mỗi lần tin được sửa (publish, thêm ảnh thu nhỏ), dùng cho ETag
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (n *News) IsPublished() bool {
+	return n.Status == NewsStatusPublished
+}
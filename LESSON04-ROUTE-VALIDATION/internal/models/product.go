@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type ProductImage struct {
+	ImageName string `json:"image_name"`
+	ImageLink string `json:"image_link"`
+}
+
+type ProductAttribute struct {
+	AttributeName  string `json:"attribute_name"`
+	AttributeValue string `json:"attribute_value"`
+}
+
+type ProductInfo struct {
+	InfoKey   string `json:"info_key"`
+	InfoValue string `json:"info_value"`
+}
+
+type Product struct {
+	ID               int                    `json:"id"`
+	Slug             string                 `json:"slug"`
+	Name             string                 `json:"name"`
+	Price            int                    `json:"price"`
+	Display          bool                   `json:"display"`
+	ProductImage     ProductImage           `json:"product_image"`
+	Tag              []string               `json:"tags"`
+	ProductAttribute []ProductAttribute     `json:"product_attribute"`
+	ProductInfo      map[string]ProductInfo `json:"product_info"`
+	ProductMetadata  map[string]any         `json:"product_metadata"`
+	// Version tăng mỗi lần update, dùng cho ETag / If-Match
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
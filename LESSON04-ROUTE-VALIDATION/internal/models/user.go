@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type UserProfile struct {
+	Bio     string `json:"bio"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+type User struct {
+	ID      int         `json:"id"`
+	UUID    string      `json:"uuid"`
+	Name    string      `json:"name"`
+	Email   string      `json:"email"`
+	Profile UserProfile `json:"profile"`
+	Avatar  string      `json:"avatar"`
+	// Version tăng mỗi lần update, dùng cho ETag / If-Match
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryRetrying  = "retrying"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription là 1 endpoint của đối tác nhận các event trong Events ("*" là mọi event)
+type WebhookSubscription struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Secret dùng để ký HMAC, chỉ trả về cho client khi tạo subscription
+	Secret string `json:"-"`
+	Active bool   `json:"active"`
+	// FailureCount là số lần gửi lỗi liên tiếp, đủ ngưỡng thì subscription bị tắt
+	FailureCount   int        `json:"failure_count"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookAttempt là 1 lần gửi HTTP của delivery
+type WebhookAttempt struct {
+	StatusCode   int       `json:"status_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	At           time.Time `json:"at"`
+}
+
+// WebhookDelivery là 1 event gửi tới 1 subscription, cùng các lần thử
+type WebhookDelivery struct {
+	ID             string           `json:"id"`
+	SubscriptionID string           `json:"subscription_id"`
+	EventID        string           `json:"event_id"`
+	Event          string           `json:"event"`
+	Payload        json.RawMessage  `json:"payload"`
+	Status         string           `json:"status"`
+	Attempts       []WebhookAttempt `json:"attempts"`
+	// RedeliveryOf là delivery gốc khi được admin gửi lại
+	RedeliveryOf string     `json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
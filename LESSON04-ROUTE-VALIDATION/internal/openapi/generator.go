@@ -0,0 +1,397 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"mamba.com/route-group/utils"
+)
+
+// Route mô tả thêm cho 1 route gin mà generator không tự đọc được
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	// Input là struct bind của handler: tag uri thành path param,
+	// form thành query (GET, DELETE) hoặc form field, json thành body
+	Input any
+	Files []File
+	// Output là kiểu của response thành công, nil thì dùng object bất kỳ
+	Output any
+	// Stream là kiểu của 1 dòng khi route trả được application/x-ndjson (utils.RenderNDJSON)
+	Stream any
+	// Status của response thành công, 0 thì theo method (POST 201, DELETE 204, còn lại 200)
+	Status int
+	// Errors là các status lỗi handler có thể trả về ngoài 400, 406 và 415, VD: 404, 409
+	Errors []int
+	// Responses là các status không phải lỗi ngoài status thành công, VD: 207 của bulk partial, 304
+	Responses  []int
+	Deprecated bool
+}
+
+// Middleware khai báo status mà 1 middleware trả về cho mọi route có path bắt đầu bằng Prefix,
+// VD: 429 của rate limit trên /api/v1. Prefix rỗng là mọi route, Methods rỗng là mọi method.
+type Middleware struct {
+	Prefix    string
+	Methods   []string
+	Responses []int
+}
+
+func (m Middleware) matches(method, path string) bool {
+	if !strings.HasPrefix(path, m.Prefix) {
+		return false
+	}
+	return len(m.Methods) == 0 || slices.Contains(m.Methods, method)
+}
+
+type File struct {
+	Name     string
+	Multiple bool
+	Required bool
+}
+
+// Định dạng body mà utils.BindBody chấp nhận
+var bodyMediaTypes = []string{
+	binding.MIMEJSON,
+	binding.MIMEXML,
+	binding.MIMEYAML2,
+	binding.MIMEMSGPACK2,
+}
+
+var pathParamRegex = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+type Generator struct {
+	info Info
+
+	mu          sync.RWMutex
+	routes      map[string]Route
+	middlewares []Middleware
+}
+
+func NewGenerator(info Info) *Generator {
+	return &Generator{info: info, routes: make(map[string]Route)}
+}
+
+func (g *Generator) Describe(routes ...Route) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, route := range routes {
+		g.routes[routeKey(route.Method, route.Path)] = route
+	}
+}
+
+// DescribeMiddleware khai báo status do middleware trả về, handler không cần liệt kê lại
+func (g *Generator) DescribeMiddleware(middlewares ...Middleware) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// Generate tạo document từ các route đã đăng ký với gin (engine.Routes())
+var traceIDSchema = &Schema{
+	Type:        "string",
+	Description: "Trace id của request, giống header X-Trace-ID",
+}
+
+func (g *Generator) Generate(infos gin.RoutesInfo) *Document {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	builder := newSchemaBuilder()
+	builder.components["Error"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error":    {},
+			"trace_id": traceIDSchema,
+		},
+		Required: []string{"error"},
+	}
+	builder.components["ValidationError"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": {
+				Description: "Map field => thông báo lỗi, hoặc chuỗi khi request không đọc được",
+			},
+			"trace_id": traceIDSchema,
+		},
+		Required: []string{"error"},
+	}
+	builder.components["MultiStatus"] = &Schema{
+		Type:        "object",
+		Description: "Kết quả của bulk partial, status của từng phần tử nằm trong results",
+		Properties: map[string]*Schema{
+			"message":   {Type: "string"},
+			"mode":      {Type: "string"},
+			"succeeded": {Type: "integer"},
+			"failed":    {Type: "integer"},
+			"results":   {Type: "array", Items: &Schema{Type: "object"}},
+		},
+		Required: []string{"results"},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    g.info,
+		Paths:   make(map[string]*PathItem),
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+
+	for _, info := range infos {
+		// Bỏ qua route static dạng /uploads/*filepath
+		if strings.Contains(info.Path, "*") || info.Method == http.MethodHead {
+			continue
+		}
+
+		route, ok := g.routes[routeKey(info.Method, info.Path)]
+		if !ok {
+			route = Route{Method: info.Method, Path: info.Path}
+		}
+
+		path := pathParamRegex.ReplaceAllString(info.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		(*item)[strings.ToLower(info.Method)] = g.operation(builder, info, route)
+	}
+
+	doc.Components.Schemas = builder.components
+	return doc
+}
+
+func (g *Generator) operation(builder *schemaBuilder, info gin.RouteInfo, route Route) *Operation {
+	op := &Operation{
+		OperationID: operationID(info.Handler),
+		Summary:     route.Summary,
+		Tags:        routeTags(info.Path),
+		Deprecated:  route.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+
+	declared := make(map[string]bool)
+	var formFields *Schema
+	var jsonBody reflect.Type
+
+	if route.Input != nil {
+		t := reflect.TypeOf(route.Input)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		for _, f := range inputFields(t) {
+			field := f.field
+			bindingTag := field.Tag.Get("binding")
+
+			schema := builder.schemaFor(field.Type)
+			applyBinding(schema, field.Type, bindingTag)
+
+			if name := tagName(field, "uri"); name != "" {
+				declared[name] = true
+				op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+				continue
+			}
+
+			if name := tagName(field, "form"); name != "" {
+				required := hasRule(bindingTag, "required")
+				if info.Method == http.MethodGet || info.Method == http.MethodDelete {
+					op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+					continue
+				}
+
+				if formFields == nil {
+					formFields = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+				}
+				formFields.Properties[name] = schema
+				if required {
+					formFields.Required = append(formFields.Required, name)
+				}
+				continue
+			}
+
+			if _, ok := field.Tag.Lookup("json"); ok {
+				jsonBody = f.owner
+			}
+		}
+	}
+
+	// Path param không có trong struct bind (VD: PUT /:id) vẫn phải được khai báo
+	for _, match := range pathParamRegex.FindAllStringSubmatch(info.Path, -1) {
+		if !declared[match[1]] {
+			op.Parameters = append(op.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	switch {
+	case jsonBody != nil:
+		schema := builder.schemaFor(jsonBody)
+		op.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+		for _, mediaType := range bodyMediaTypes {
+			op.RequestBody.Content[mediaType] = &MediaType{Schema: schema}
+		}
+	case formFields != nil || len(route.Files) > 0:
+		if formFields == nil {
+			formFields = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		}
+		for _, file := range route.Files {
+			fileSchema := &Schema{Type: "string", Format: "binary"}
+			if file.Multiple {
+				fileSchema = &Schema{Type: "array", Items: fileSchema}
+			}
+			formFields.Properties[file.Name] = fileSchema
+			if file.Required {
+				formFields.Required = append(formFields.Required, file.Name)
+			}
+		}
+
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			binding.MIMEMultipartPOSTForm: {Schema: formFields},
+		}}
+		if len(route.Files) == 0 {
+			op.RequestBody.Content[binding.MIMEPOSTForm] = &MediaType{Schema: formFields}
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = successStatus(info.Method)
+	}
+
+	success := &Response{Description: http.StatusText(status)}
+	if status != http.StatusNoContent {
+		var schema *Schema
+		if route.Output != nil {
+			schema = builder.schemaFor(reflect.TypeOf(route.Output))
+		} else {
+			schema = &Schema{Type: "object"}
+		}
+		success.Content = map[string]*MediaType{binding.MIMEJSON: {Schema: schema}}
+		if route.Stream != nil {
+			success.Content[utils.MIMENDJSON] = &MediaType{Schema: builder.schemaFor(reflect.TypeOf(route.Stream))}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	if route.Input != nil || len(route.Files) > 0 {
+		op.Responses["400"] = refResponse("Validation error", "ValidationError")
+	}
+
+	// utils.Render trả 406 cho mọi route, utils.BindBody trả 415 khi sai Content-Type
+	op.Responses["406"] = refResponse(http.StatusText(http.StatusNotAcceptable), "Error")
+	if op.RequestBody != nil {
+		op.Responses["415"] = refResponse(http.StatusText(http.StatusUnsupportedMediaType), "Error")
+	}
+	for _, code := range slices.Concat(route.Errors, route.Responses) {
+		op.Responses[strconv.Itoa(code)] = statusResponse(code)
+	}
+	for _, m := range g.middlewares {
+		if !m.matches(info.Method, info.Path) {
+			continue
+		}
+		for _, code := range m.Responses {
+			// Response handler đã khai báo (VD: 409 có body riêng) được giữ nguyên
+			if _, ok := op.Responses[strconv.Itoa(code)]; !ok {
+				op.Responses[strconv.Itoa(code)] = statusResponse(code)
+			}
+		}
+	}
+
+	return op
+}
+
+// statusResponse là response của status ngoài status thành công: 304 không có body,
+// 207 theo định dạng của bulk, 2xx khác là object bất kỳ, còn lại là lỗi
+func statusResponse(code int) *Response {
+	switch {
+	case code == http.StatusNotModified:
+		return &Response{Description: http.StatusText(code)}
+	case code == http.StatusMultiStatus:
+		return refResponse(http.StatusText(code), "MultiStatus")
+	case code < http.StatusBadRequest:
+		return &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{binding.MIMEJSON: {Schema: &Schema{Type: "object"}}},
+		}
+	}
+	return refResponse(http.StatusText(code), "Error")
+}
+
+type inputField struct {
+	field reflect.StructField
+	owner reflect.Type
+}
+
+// inputFields trả về field của struct bind, struct nhúng (embedded) được trải phẳng
+// để 1 route có thể gộp struct uri và struct body
+func inputFields(t reflect.Type) []inputField {
+	var fields []inputField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, inputFields(field.Type)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, inputField{field: field, owner: t})
+		}
+	}
+	return fields
+}
+
+func refResponse(description, schema string) *Response {
+	return &Response{
+		Description: description,
+		Content: map[string]*MediaType{
+			binding.MIMEJSON: {Schema: &Schema{Ref: "#/components/schemas/" + schema}},
+		},
+	}
+}
+
+func successStatus(method string) int {
+	switch method {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// operationID lấy tên method từ tên handler gin,
+// VD: ".../handler.(*ProductHandler).GetProductsV1-fm" => GetProductsV1
+func operationID(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// routeTags nhóm theo resource ngay sau version, VD: /api/v1/products/:slug => products
+func routeTags(path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "v") && i > 0 && parts[i-1] == "api" && i+1 < len(parts) {
+			return []string{parts[i+1]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDescribeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	noop := func(*gin.Context) {}
+	r.GET("/api/v1/items", noop)
+	r.POST("/api/v1/items", noop)
+	r.GET("/healthz", noop)
+
+	g := NewGenerator(Info{})
+	g.Describe(Route{Method: http.MethodPost, Path: "/api/v1/items", Errors: []int{http.StatusConflict}})
+	g.DescribeMiddleware(
+		Middleware{Responses: []int{http.StatusInternalServerError}},
+		Middleware{Prefix: "/api/", Responses: []int{http.StatusTooManyRequests}},
+		Middleware{Prefix: "/api/", Methods: []string{http.MethodPost}, Responses: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+	)
+	doc := g.Generate(r.Routes())
+
+	tests := []struct {
+		path, method string
+		want         []string
+	}{
+		{"/api/v1/items", "get", []string{"200", "406", "429", "500"}},
+		{"/api/v1/items", "post", []string{"201", "406", "409", "422", "429", "500"}},
+		{"/healthz", "get", []string{"200", "406", "500"}},
+	}
+	for _, tt := range tests {
+		op := (*doc.Paths[tt.path])[tt.method]
+		var got []string
+		for status := range op.Responses {
+			got = append(got, status)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s %s: responses = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	// 409 do handler khai báo được giữ nguyên, không bị response của middleware ghi đè
+	post := (*doc.Paths["/api/v1/items"])["post"]
+	if post.Responses["409"].Description != http.StatusText(http.StatusConflict) {
+		t.Errorf("409 was replaced: %+v", post.Responses["409"])
+	}
+	if post.Responses["304"] != nil {
+		t.Errorf("unexpected 304")
+	}
+}
+
+func TestStatusResponse(t *testing.T) {
+	if r := statusResponse(http.StatusNotModified); r.Content != nil {
+		t.Errorf("304 must not have a body, got %+v", r.Content)
+	}
+	if ref := statusResponse(http.StatusMultiStatus).Content["application/json"].Schema.Ref; ref != "#/components/schemas/MultiStatus" {
+		t.Errorf("207 schema = %q", ref)
+	}
+	if ref := statusResponse(http.StatusTooManyRequests).Content["application/json"].Schema.Ref; ref != "#/components/schemas/Error" {
+		t.Errorf("429 schema = %q", ref)
+	}
+}
+
+func TestNewValidatorRejectsInvalidPattern(t *testing.T) {
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/items": {"get": &Operation{
+				Parameters: []Parameter{{Name: "q", In: "query", Schema: &Schema{Type: "string", Pattern: `(?<=a)b`}}},
+			}},
+		},
+	}
+	if _, err := NewValidator(doc, ValidatorOptions{}); err == nil {
+		t.Fatal("expected an error for a pattern RE2 cannot compile")
+	}
+
+	doc.Paths["/items"] = &PathItem{"get": &Operation{
+		Parameters: []Parameter{{Name: "q", In: "query", Schema: &Schema{Type: "string", Pattern: `^[a-z]+$`}}},
+	}}
+	if _, err := NewValidator(doc, ValidatorOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbeddedStructSchema(t *testing.T) {
+	type base struct {
+		ID int `json:"id" binding:"required,gt=0"`
+	}
+	type fields struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type item struct {
+		base
+		fields
+		Note   string `json:"note"`
+		Nested fields `json:"nested"`
+	}
+
+	schema := newSchemaBuilder().structSchema(reflect.TypeOf(item{}))
+
+	got := slices.Sorted(maps.Keys(schema.Properties))
+	if want := []string{"id", "name", "nested", "note"}; !slices.Equal(got, want) {
+		t.Errorf("properties = %v, want %v", got, want)
+	}
+	slices.Sort(schema.Required)
+	if want := []string{"id", "name"}; !slices.Equal(schema.Required, want) {
+		t.Errorf("required = %v, want %v", schema.Required, want)
+	}
+}
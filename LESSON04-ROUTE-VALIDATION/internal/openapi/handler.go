@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"embed"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed ui/index.html
+var docsPage []byte
+
+// swagger-ui-dist 5.18.2, nhúng vào binary để /docs không phụ thuộc CDN
+//
+//go:embed ui/swagger-ui.css ui/swagger-ui-bundle.js
+var docsAssets embed.FS
+
+type Handler struct {
+	generator *Generator
+	engine    *gin.Engine
+
+	once sync.Once
+	doc  *Document
+}
+
+// NewHandler: spec được tạo ở request đầu tiên, lúc đó mọi route đã được đăng ký với engine
+func NewHandler(generator *Generator, engine *gin.Engine) *Handler {
+	return &Handler{generator: generator, engine: engine}
+}
+
+func (h *Handler) GetOpenAPISpec(ctx *gin.Context) {
+	h.once.Do(func() {
+		h.doc = h.generator.Generate(h.engine.Routes())
+	})
+
+	ctx.JSON(http.StatusOK, h.doc)
+}
+
+func (h *Handler) GetDocs(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
+
+// GetDocsAsset phục vụ file CSS/JS của swagger-ui cho trang /docs
+func (h *Handler) GetDocsAsset(ctx *gin.Context) {
+	data, err := docsAssets.ReadFile("ui/" + ctx.Param("file"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	contentType := "text/css; charset=utf-8"
+	if path.Ext(ctx.Param("file")) == ".js" {
+		contentType = "text/javascript; charset=utf-8"
+	}
+	// Asset đổi theo bản build nên chỉ cache ngắn
+	ctx.Header("Cache-Control", "public, max-age=3600")
+	ctx.Data(http.StatusOK, contentType, data)
+}
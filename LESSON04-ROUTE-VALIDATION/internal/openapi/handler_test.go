@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Trang /docs chỉ được tải asset từ chính server, không qua CDN
+func TestDocsAssetsEmbedded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewHandler(NewGenerator(Info{}), r)
+	r.GET("/docs", h.GetDocs)
+	r.GET("/docs/:file", h.GetDocsAsset)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	page := get("/docs").Body.String()
+	if strings.Contains(page, "://") {
+		t.Errorf("docs page loads an external resource:\n%s", page)
+	}
+
+	for _, file := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+		if !strings.Contains(page, "/docs/"+file) {
+			t.Errorf("docs page does not reference %s", file)
+		}
+		if w := get("/docs/" + file); w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("%s: status = %d, size = %d", file, w.Code, w.Body.Len())
+		}
+	}
+
+	if w := get("/docs/index.html"); w.Code != http.StatusNotFound {
+		t.Errorf("index.html: status = %d, want 404", w.Code)
+	}
+}
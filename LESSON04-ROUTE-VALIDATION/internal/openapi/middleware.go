@@ -0,0 +1,363 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/goccy/go-yaml"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/utils"
+)
+
+const multipartMemory = 32 << 20
+
+// LoadDocument đọc spec OpenAPI 3.1 từ file .json, .yaml hoặc .yml
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse OpenAPI document %s: %w", path, err)
+	}
+
+	return &doc, nil
+}
+
+type ValidatorOptions struct {
+	ValidateResponses bool
+	// StrictResponses thay response sai spec bằng 500 thay vì chỉ ghi log,
+	// dùng khi chạy test để phát hiện handler lệch khỏi spec
+	StrictResponses bool
+}
+
+type Validator struct {
+	doc     *Document
+	opts    ValidatorOptions
+	schemas *schemaValidator
+}
+
+// NewValidator trả lỗi khi spec có pattern không dùng được để validate
+func NewValidator(doc *Document, opts ValidatorOptions) (*Validator, error) {
+	schemas, err := newSchemaValidator(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{doc: doc, opts: opts, schemas: schemas}, nil
+}
+
+// Middleware validate path param, query, header, body JSON / form / multipart của request
+// theo operation khớp với route gin. Route không có trong spec thì bỏ qua.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		op := v.operation(ctx)
+		if op == nil {
+			ctx.Next()
+			return
+		}
+
+		errs := fieldErrors{}
+		v.validateParameters(ctx, op, errs)
+		if status, err := v.validateBody(ctx, op, errs); err != nil {
+			utils.Render(ctx, status, gin.H{"error": err.Error()})
+			ctx.Abort()
+			return
+		}
+
+		if len(errs) > 0 {
+			errs.report(ctx)
+			utils.Render(ctx, http.StatusBadRequest, errs.response())
+			ctx.Abort()
+			return
+		}
+
+		if !v.opts.ValidateResponses {
+			ctx.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = writer.ResponseWriter
+
+		v.validateResponse(ctx, op, writer)
+	}
+}
+
+func (e fieldErrors) response() gin.H {
+	messages := make(map[string]string, len(e))
+	for field, fe := range e {
+		messages[field] = utils.ValidationMessage(field, fe.tag, fe.param)
+	}
+	return gin.H{"error": messages}
+}
+
+func (v *Validator) operation(ctx *gin.Context) *Operation {
+	fullPath := ctx.FullPath()
+	if fullPath == "" {
+		return nil
+	}
+
+	item, ok := v.doc.Paths[pathParamRegex.ReplaceAllString(fullPath, "{$1}")]
+	if !ok {
+		return nil
+	}
+
+	return (*item)[strings.ToLower(ctx.Request.Method)]
+}
+
+func (v *Validator) validateParameters(ctx *gin.Context, op *Operation, errs fieldErrors) {
+	query := ctx.Request.URL.Query()
+
+	for _, param := range op.Parameters {
+		var raw []string
+		switch param.In {
+		case "path":
+			if value := ctx.Param(param.Name); value != "" {
+				raw = []string{value}
+			}
+		case "query":
+			raw = query[param.Name]
+		case "header":
+			raw = ctx.Request.Header.Values(param.Name)
+		default:
+			continue
+		}
+
+		v.validateStrings(param.Name, param.Schema, raw, param.Required, errs)
+	}
+}
+
+// validateStrings validate giá trị dạng chuỗi (param, form field), schema array nhận nhiều giá trị
+func (v *Validator) validateStrings(field string, schema *Schema, raw []string, required bool, errs fieldErrors) {
+	if len(raw) == 0 {
+		if required {
+			errs.add(field, "required", "")
+		}
+		return
+	}
+
+	schema = v.schemas.resolve(schema)
+	if schema != nil && schema.Type == "array" {
+		items := make([]any, len(raw))
+		for i, value := range raw {
+			items[i] = coerce(v.schemas.resolve(schema.Items), value)
+		}
+		v.schemas.validate(field, schema, items, errs)
+		return
+	}
+
+	v.schemas.validate(field, schema, coerce(schema, raw[0]), errs)
+}
+
+// validateBody trả về status và error khi body không đọc được hoặc sai Content-Type,
+// lỗi validate từng field được ghi vào errs
+func (v *Validator) validateBody(ctx *gin.Context, op *Operation, errs fieldErrors) (int, error) {
+	if op.RequestBody == nil {
+		return 0, nil
+	}
+
+	contentType := ctx.ContentType()
+	if ctx.Request.ContentLength == 0 && contentType == "" {
+		if op.RequestBody.Required {
+			errs.add("body", "required", "")
+		}
+		return 0, nil
+	}
+
+	mediaType, ok := op.RequestBody.Content[contentType]
+	if !ok {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type %s không được hỗ trợ", contentType)
+	}
+
+	switch contentType {
+	case binding.MIMEJSON:
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Yêu cầu không hợp lệ %s", err)
+		}
+		// Trả lại body cho handler bind
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Yêu cầu không hợp lệ %s", err)
+		}
+		v.schemas.validate("", mediaType.Schema, value, errs)
+	case binding.MIMEMultipartPOSTForm:
+		if err := ctx.Request.ParseMultipartForm(multipartMemory); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Invalid multipart form")
+		}
+		v.validateForm(mediaType.Schema, ctx.Request.MultipartForm.Value, ctx.Request.MultipartForm.File, errs)
+	case binding.MIMEPOSTForm:
+		if err := ctx.Request.ParseForm(); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Invalid form")
+		}
+		v.validateForm(mediaType.Schema, ctx.Request.PostForm, nil, errs)
+	}
+
+	// XML, YAML, msgpack: để binding của handler validate
+	return 0, nil
+}
+
+func (v *Validator) validateForm(schema *Schema, values map[string][]string, files map[string][]*multipart.FileHeader, errs fieldErrors) {
+	schema = v.schemas.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	for name, prop := range schema.Properties {
+		prop = v.schemas.resolve(prop)
+		if isBinary(prop) {
+			if required[name] && len(files[name]) == 0 {
+				errs.add(name, "required", "")
+			}
+			continue
+		}
+
+		v.validateStrings(name, prop, values[name], required[name], errs)
+	}
+}
+
+func isBinary(schema *Schema) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.Type == "array" && schema.Items != nil {
+		return schema.Items.Format == "binary"
+	}
+	return schema.Format == "binary"
+}
+
+func (v *Validator) validateResponse(ctx *gin.Context, op *Operation, writer *bufferedWriter) {
+	if writer.streaming {
+		return
+	}
+	status := writer.Status()
+
+	response, ok := op.Responses[fmt.Sprint(status)]
+	if !ok {
+		response, ok = op.Responses["default"]
+	}
+
+	var violation string
+	errs := fieldErrors{}
+
+	switch {
+	case !ok:
+		violation = fmt.Sprintf("status %d không có trong spec", status)
+	case response.Content != nil && writer.buf.Len() > 0 && strings.HasPrefix(writer.Header().Get("Content-Type"), binding.MIMEJSON):
+		if mediaType, ok := response.Content[binding.MIMEJSON]; ok {
+			var value any
+			if err := json.Unmarshal(writer.buf.Bytes(), &value); err != nil {
+				violation = "response không phải JSON hợp lệ"
+			} else {
+				v.schemas.validate("", mediaType.Schema, value, errs)
+			}
+		}
+	}
+
+	if violation == "" && len(errs) == 0 {
+		writer.flush()
+		return
+	}
+
+	logging.From(ctx).Warn("openapi: response does not match spec",
+		slog.String("method", ctx.Request.Method),
+		slog.String("route", ctx.FullPath()),
+		slog.String("violation", violation),
+		slog.Any("errors", errs.response()["error"]),
+	)
+
+	if !v.opts.StrictResponses {
+		writer.flush()
+		return
+	}
+
+	writer.buf.Reset()
+	writer.Header().Del("Content-Length")
+	ctx.Writer.WriteHeader(http.StatusInternalServerError)
+	body := gin.H{"error": errs.response()["error"]}
+	if violation != "" {
+		body["error"] = violation
+	}
+	data, _ := json.Marshal(body)
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	ctx.Writer.Write(data)
+}
+
+// bufferedWriter giữ lại body để validate trước khi gửi cho client.
+// Handler gọi Flush hoặc Hijack (SSE, WebSocket) thì chuyển sang ghi thẳng và bỏ qua validate,
+// vì response stream không có điểm kết thúc để chờ.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.buf.WriteString(s)
+}
+
+func (w *bufferedWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.flush()
+		w.buf.Reset()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streaming = true
+	return w.ResponseWriter.Hijack()
+}
+
+// Unwrap cho http.ResponseController (VD: đặt write deadline của stream)
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bufferedWriter) flush() {
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.ResponseWriter.Write(w.buf.Bytes())
+}
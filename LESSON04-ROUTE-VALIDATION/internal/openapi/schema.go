@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pattern tương ứng với các validator custom trong utils.RegisterValidators
+var customPatterns = map[string]string{
+	"slug":   `^[a-z0-9]+(?:[-.][a-z0-9]+)*$`,
+	"search": `^[a-zA-Z0-9\s]+$`,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type schemaBuilder struct {
+	components map[string]*Schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]*Schema)}
+}
+
+// schemaFor tạo schema cho kiểu Go, struct có tên được đưa vào components
+func (b *schemaBuilder) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+
+		if _, ok := b.components[t.Name()]; !ok {
+			// Đặt chỗ trước để struct đệ quy không lặp vô hạn
+			b.components[t.Name()] = &Schema{}
+			*b.components[t.Name()] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	// interface{} / any: không giới hạn kiểu
+	return &Schema{}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Struct nhúng không có json tag được encoding/json đưa field lên struct ngoài
+		if _, tagged := field.Tag.Lookup("json"); field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(field.Type)
+			maps.Copy(schema.Properties, embedded.Properties)
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tagName(field, "json")
+		if name == "-" || name == "" {
+			continue
+		}
+
+		prop := b.schemaFor(field.Type)
+		binding := field.Tag.Get("binding")
+		applyBinding(prop, field.Type, binding)
+
+		schema.Properties[name] = prop
+		if hasRule(binding, "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// tagName lấy tên từ tag (json, uri, form), json không có tag thì dùng tên field
+func tagName(field reflect.StructField, key string) string {
+	tag, ok := field.Tag.Lookup(key)
+	if !ok {
+		if key == "json" {
+			return field.Name
+		}
+		return ""
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBinding chuyển tag binding của go-playground/validator thành ràng buộc JSON Schema.
+// Các rule sau "dive" áp dụng cho phần tử của slice / giá trị của map.
+func applyBinding(schema *Schema, t reflect.Type, binding string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	rules := strings.Split(binding, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			rest := strings.Join(rules[i+1:], ",")
+			switch {
+			case schema.Items != nil:
+				applyBinding(schema.Items, t.Elem(), rest)
+			case schema.AdditionalProperties != nil:
+				applyBinding(schema.AdditionalProperties, t.Elem(), rest)
+			}
+			return
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+		applyRule(schema, t.Kind(), name, param)
+	}
+}
+
+func applyRule(schema *Schema, kind reflect.Kind, name, param string) {
+	switch name {
+	case "min", "max", "gt", "gte", "lt", "lte":
+		applyRange(schema, kind, name, param)
+	case "min_int":
+		schema.Minimum = floatParam(param)
+	case "max_int":
+		schema.Maximum = floatParam(param)
+	case "oneof":
+		for _, value := range strings.Fields(param) {
+			schema.Enum = append(schema.Enum, enumValue(kind, value))
+		}
+	case "uuid":
+		schema.Format = "uuid"
+	case "email":
+		schema.Format = "email"
+	case "datetime":
+		if param == "2006-01-02" {
+			schema.Format = "date"
+		} else {
+			schema.Format = "date-time"
+			schema.Description = "Layout: " + param
+		}
+	case "slug", "search":
+		schema.Pattern = customPatterns[name]
+	case "file_ext":
+		exts := strings.Fields(param)
+		schema.AllowedExtensions = exts
+		schema.Pattern = fileExtPattern(exts)
+	}
+}
+
+func applyRange(schema *Schema, kind reflect.Kind, name, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+
+	// Với string / slice / map: min/max là độ dài, gt/lt là độ dài so sánh chặt
+	bound := n
+	switch name {
+	case "gt":
+		bound = n + 1
+	case "lt":
+		bound = n - 1
+	}
+	lower := name == "min" || name == "gt" || name == "gte"
+
+	switch kind {
+	case reflect.String:
+		if lower {
+			schema.MinLength = &bound
+		} else {
+			schema.MaxLength = &bound
+		}
+	case reflect.Slice, reflect.Array:
+		if lower {
+			schema.MinItems = &bound
+		} else {
+			schema.MaxItems = &bound
+		}
+	case reflect.Map:
+		if lower {
+			schema.MinProperties = &bound
+		}
+	default:
+		value := float64(n)
+		switch name {
+		case "min", "gte":
+			schema.Minimum = &value
+		case "max", "lte":
+			schema.Maximum = &value
+		case "gt":
+			schema.ExclusiveMinimum = &value
+		case "lt":
+			schema.ExclusiveMaximum = &value
+		}
+	}
+}
+
+func enumValue(kind reflect.Kind, value string) any {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func floatParam(param string) *float64 {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// fileExtPattern: JSON Schema dùng regex ECMA-262 không có cờ (?i),
+// nên mỗi chữ cái được viết thành [xX] để khớp với file_ext (không phân biệt hoa thường)
+func fileExtPattern(exts []string) string {
+	var parts []string
+	for _, ext := range exts {
+		var sb strings.Builder
+		for _, r := range strings.ToLower(ext) {
+			if r >= 'a' && r <= 'z' {
+				sb.WriteString("[" + string(r) + strings.ToUpper(string(r)) + "]")
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+		parts = append(parts, sb.String())
+	}
+
+	return `\.(` + strings.Join(parts, "|") + `)$`
+}
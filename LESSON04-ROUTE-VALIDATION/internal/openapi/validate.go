@@ -0,0 +1,308 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+)
+
+// schemaValidator kiểm tra giá trị (đã decode từ JSON hoặc đã ép kiểu từ query/form)
+// theo Schema. Lỗi được ghi theo tag của go-playground/validator để dùng
+// chung thông báo với utils.HandleValidationError.
+type schemaValidator struct {
+	doc *Document
+	// patterns được dịch hết lúc tạo validator, sau đó chỉ đọc
+	patterns map[string]*regexp.Regexp
+}
+
+type fieldErrors map[string]fieldError
+
+type fieldError struct {
+	tag   string
+	param string
+}
+
+// add chỉ giữ lỗi đầu tiên của mỗi field, giống validator dừng ở rule đầu tiên sai
+func (e fieldErrors) add(field, tag, param string) {
+	if field == "" {
+		field = "body"
+	}
+	if _, ok := e[field]; !ok {
+		e[field] = fieldError{tag: tag, param: param}
+	}
+}
+
+// report đếm và ghi log mức debug từng lỗi, giống utils.RenderValidationError
+func (e fieldErrors) report(ctx *gin.Context) {
+	logger := logging.From(ctx)
+	debug := logger.Enabled(ctx.Request.Context(), slog.LevelDebug)
+
+	for field, err := range e {
+		metrics.ValidationFailures.Inc(err.tag, field)
+		if !debug {
+			continue
+		}
+		logger.LogAttrs(ctx.Request.Context(), slog.LevelDebug, "validation failed",
+			slog.String("route", ctx.FullPath()),
+			slog.String("field", field),
+			slog.String("tag", err.tag),
+			slog.String("param", err.param),
+		)
+	}
+}
+
+// newSchemaValidator dịch trước mọi pattern trong spec. Pattern không dịch được sang RE2
+// là lỗi của spec, bỏ qua nó thì field đó không còn được kiểm tra mà không ai biết.
+func newSchemaValidator(doc *Document) (*schemaValidator, error) {
+	v := &schemaValidator{doc: doc, patterns: make(map[string]*regexp.Regexp)}
+
+	var errs []error
+	var compile func(where string, schema *Schema)
+	compile = func(where string, schema *Schema) {
+		if schema == nil {
+			return
+		}
+		if schema.Pattern != "" {
+			if _, ok := v.patterns[schema.Pattern]; !ok {
+				re, err := regexp.Compile(schema.Pattern)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid pattern %q: %w", where, schema.Pattern, err))
+				}
+				v.patterns[schema.Pattern] = re
+			}
+		}
+		compile(where+".items", schema.Items)
+		compile(where+".additionalProperties", schema.AdditionalProperties)
+		for name, prop := range schema.Properties {
+			compile(where+"."+name, prop)
+		}
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		compile("components.schemas."+name, schema)
+	}
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			where := strings.ToUpper(method) + " " + path
+			for _, param := range op.Parameters {
+				compile(where+" "+param.In+"."+param.Name, param.Schema)
+			}
+			if op.RequestBody != nil {
+				for mediaType, content := range op.RequestBody.Content {
+					compile(where+" requestBody "+mediaType, content.Schema)
+				}
+			}
+			for status, response := range op.Responses {
+				for mediaType, content := range response.Content {
+					compile(where+" response "+status+" "+mediaType, content.Schema)
+				}
+			}
+		}
+	}
+
+	return v, errors.Join(errs...)
+}
+
+func (v *schemaValidator) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		schema = v.doc.Components.Schemas[name]
+	}
+	return schema
+}
+
+func (v *schemaValidator) validate(field string, schema *Schema, value any, errs fieldErrors) {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	if schema.Type != "" && !matchesType(schema.Type, value) {
+		errs.add(field, "type", schema.Type)
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		var allowed []string
+		for _, e := range schema.Enum {
+			allowed = append(allowed, fmt.Sprint(e))
+		}
+		errs.add(field, "oneof", strings.Join(allowed, " "))
+		return
+	}
+
+	switch val := value.(type) {
+	case string:
+		v.validateString(field, schema, val, errs)
+	case float64:
+		validateNumber(field, schema, val, errs)
+	case []any:
+		if schema.MinItems != nil && len(val) < *schema.MinItems {
+			errs.add(field, "gte", strconv.Itoa(*schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(val) > *schema.MaxItems {
+			errs.add(field, "lte", strconv.Itoa(*schema.MaxItems))
+		}
+		for i, item := range val {
+			v.validate(fmt.Sprintf("%s[%d]", field, i), schema.Items, item, errs)
+		}
+	case map[string]any:
+		v.validateObject(field, schema, val, errs)
+	}
+}
+
+func (v *schemaValidator) validateObject(field string, schema *Schema, obj map[string]any, errs fieldErrors) {
+	for _, name := range schema.Required {
+		if value, ok := obj[name]; !ok || value == nil {
+			errs.add(joinField(field, name), "required", "")
+		}
+	}
+
+	if schema.MinProperties != nil && len(obj) < *schema.MinProperties {
+		errs.add(field, "gte", strconv.Itoa(*schema.MinProperties))
+	}
+
+	for name, value := range obj {
+		if value == nil {
+			continue
+		}
+
+		if prop, ok := schema.Properties[name]; ok {
+			v.validate(joinField(field, name), prop, value, errs)
+		} else if schema.AdditionalProperties != nil {
+			v.validate(joinField(field, name), schema.AdditionalProperties, value, errs)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(field string, schema *Schema, value string, errs fieldErrors) {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		errs.add(field, "min", strconv.Itoa(*schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		errs.add(field, "max", strconv.Itoa(*schema.MaxLength))
+	}
+
+	if schema.Pattern != "" && !v.patterns[schema.Pattern].MatchString(value) {
+		switch {
+		case len(schema.AllowedExtensions) > 0:
+			errs.add(field, "file_ext", strings.Join(schema.AllowedExtensions, " "))
+		case schema.Pattern == customPatterns["slug"]:
+			errs.add(field, "slug", "")
+		case schema.Pattern == customPatterns["search"]:
+			errs.add(field, "search", "")
+		default:
+			errs.add(field, "pattern", schema.Pattern)
+		}
+	}
+
+	switch schema.Format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			errs.add(field, "uuid", "")
+		}
+	case "email":
+		if _, err := mail.ParseAddress(value); err != nil {
+			errs.add(field, "email", "")
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			errs.add(field, "datetime", "2006-01-02")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			errs.add(field, "datetime", time.RFC3339)
+		}
+	}
+}
+
+func validateNumber(field string, schema *Schema, value float64, errs fieldErrors) {
+	switch {
+	case schema.Minimum != nil && value < *schema.Minimum:
+		errs.add(field, "gte", formatNumber(*schema.Minimum))
+	case schema.Maximum != nil && value > *schema.Maximum:
+		errs.add(field, "lte", formatNumber(*schema.Maximum))
+	case schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum:
+		errs.add(field, "gt", formatNumber(*schema.ExclusiveMinimum))
+	case schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum:
+		errs.add(field, "lt", formatNumber(*schema.ExclusiveMaximum))
+	}
+}
+
+func matchesType(schemaType string, value any) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// coerce ép giá trị chuỗi từ path/query/header/form sang kiểu trong schema.
+// Không ép được thì giữ nguyên chuỗi để validate báo lỗi "type".
+func coerce(schema *Schema, raw string) any {
+	if schema == nil {
+		return raw
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+
+	return raw
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
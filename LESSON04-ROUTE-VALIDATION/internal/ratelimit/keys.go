@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// KeyFunc xác định client để đếm quota
+type KeyFunc func(ctx *gin.Context) string
+
+// ByIP dùng ctx.ClientIP(), chỉ tin X-Forwarded-For từ trusted proxy của engine
+func ByIP() KeyFunc {
+	return func(ctx *gin.Context) string {
+		return "ip:" + ctx.ClientIP()
+	}
+}
+
+// ByAPIKey đếm theo API key trong header, không có thì đếm theo IP.
+// Key được hash để không lưu secret vào store.
+func ByAPIKey(header string) KeyFunc {
+	byIP := ByIP()
+	return func(ctx *gin.Context) string {
+		apiKey := ctx.GetHeader(header)
+		if apiKey == "" {
+			return byIP(ctx)
+		}
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// ByUser đếm theo user đã xác thực do user trả về, chưa xác thực thì dùng fallback
+func ByUser(user func(ctx *gin.Context) string, fallback KeyFunc) KeyFunc {
+	return func(ctx *gin.Context) string {
+		if id := user(ctx); id != "" {
+			return "user:" + id
+		}
+		return fallback(ctx)
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/utils"
+)
+
+// Limiter giới hạn 1 nhóm route. Limit đổi được lúc chạy (SIGHUP) bằng SetLimit.
+type Limiter struct {
+	name  string
+	store Store
+	key   KeyFunc
+	limit atomic.Pointer[Limit]
+}
+
+func NewLimiter(name string, store Store, key KeyFunc, limit Limit) *Limiter {
+	l := &Limiter{name: name, store: store, key: key}
+	l.SetLimit(limit)
+	return l
+}
+
+func (l *Limiter) SetLimit(limit Limit) {
+	l.limit.Store(&limit)
+}
+
+// Middleware trả 429 khi hết quota, kèm header theo draft IETF RateLimit:
+// RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset và Retry-After.
+// Store lỗi (VD: Redis mất kết nối) thì cho request đi qua thay vì chặn toàn bộ API.
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit := *l.limit.Load()
+		if !limit.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		result, err := l.store.Allow(ctx.Request.Context(), l.name+":"+l.key(ctx), limit)
+		if err != nil {
+			logging.From(ctx).Warn("rate limit store failed, allowing request",
+				slog.String("limiter", l.name),
+				slog.String("error", err.Error()),
+			)
+			metrics.RateLimitErrors.Inc(l.name)
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Writer.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)))
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			metrics.RateLimited.Inc(l.name)
+			header.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			utils.Render(ctx, http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// seconds làm tròn lên để client không thử lại quá sớm
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit cho phép Requests request mỗi Period, dồn được tối đa Requests request cùng lúc.
+// Limit rỗng (Requests = 0) là không giới hạn.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// interval là khoảng cách giữa 2 request khi dùng đều
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter là thời gian đến khi quota hồi đầy
+	ResetAfter time.Duration
+	// RetryAfter > 0 khi bị chặn: thời gian đến khi được gửi request tiếp theo
+	RetryAfter time.Duration
+}
+
+// Store lưu trạng thái GCRA theo key, dùng chung giữa các instance nếu là Redis
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// gcra (Generic Cell Rate Algorithm) chỉ cần lưu 1 mốc thời gian cho mỗi key:
+// tat (theoretical arrival time) là thời điểm quota hồi đầy nếu không có request nào nữa.
+// Trả về tat mới để lưu, giữ nguyên tat khi request bị chặn.
+func gcra(now, tat time.Time, limit Limit) (time.Time, Result) {
+	interval := limit.interval()
+	burst := time.Duration(limit.Requests) * interval
+
+	if tat.Before(now) {
+		tat = now
+	}
+	// Limit vừa được nới (SIGHUP): tat tính theo limit cũ có thể vượt xa burst mới
+	if maxTAT := now.Add(burst); tat.After(maxTAT) {
+		tat = maxTAT
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-burst)
+
+	if now.Before(allowAt) {
+		return tat, Result{
+			Limit:      limit.Requests,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+
+	return newTAT, Result{
+		Allowed:    true,
+		Limit:      limit.Requests,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}
+}
+
+// MemoryStore giữ trạng thái trong process, mỗi instance đếm riêng
+type MemoryStore struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	swept time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tats: make(map[string]time.Time),
+	}
+}
+
+// sweepInterval: key đã hồi đầy quota thì xoá, tránh map lớn dần theo số IP
+const sweepInterval = time.Minute
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.swept) >= sweepInterval {
+		for k, tat := range s.tats {
+			if !tat.After(now) {
+				delete(s.tats, k)
+			}
+		}
+		s.swept = now
+	}
+
+	tat, result := gcra(now, s.tats[key], limit)
+	s.tats[key] = tat
+	return result, nil
+}
@@ -0,0 +1,266 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"mamba.com/route-group/internal/tracing"
+)
+
+// gcraScript là gcra viết bằng Lua để chạy nguyên tử trên Redis. Thời gian lấy từ TIME
+// của Redis (micro giây) nên các instance lệch đồng hồ vẫn đếm đúng.
+// KEYS[1]: key, ARGV[1]: interval, ARGV[2]: burst (micro giây).
+// tat ghi bằng string.format('%d') vì tostring của Lua 5.1 làm tròn số > 14 chữ số.
+// Trả về {allowed, remaining, reset_after, retry_after}
+const gcraScript = `
+redis.replicate_commands()
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+elseif tat > now + burst then
+  tat = now + burst
+end
+local new_tat = tat + interval
+local allow_at = new_tat - burst
+if now < allow_at then
+  return {0, 0, tat - now, allow_at - now}
+end
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), new_tat - now, 0}
+`
+
+var gcraScriptSHA = func() string {
+	sum := sha1.Sum([]byte(gcraScript))
+	return hex.EncodeToString(sum[:])
+}()
+
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	// Timeout cho mỗi lệnh, tính cả thời gian kết nối
+	Timeout  time.Duration
+	PoolSize int
+}
+
+// RedisStore dùng chung quota giữa nhiều instance. Chỉ cần server nói giao thức
+// Redis (RESP) và hỗ trợ EVAL: Redis, Valkey, KeyDB...
+type RedisStore struct {
+	opts  RedisOptions
+	conns chan *redisConn
+}
+
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	return &RedisStore{
+		opts:  opts,
+		conns: make(chan *redisConn, opts.PoolSize),
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (result Result, err error) {
+	ctx, span := tracing.Start(ctx, "RedisStore.Allow", tracing.String("ratelimit.key", key))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	interval := limit.interval()
+	burst := time.Duration(limit.Requests) * interval
+	args := []string{
+		"1", "ratelimit:" + key,
+		strconv.FormatInt(interval.Microseconds(), 10),
+		strconv.FormatInt(burst.Microseconds(), 10),
+	}
+
+	reply, err := s.do(ctx, append([]string{"EVALSHA", gcraScriptSHA}, args...)...)
+	var redisErr redisError
+	if errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "NOSCRIPT") {
+		// Lần đầu (hoặc sau SCRIPT FLUSH) phải gửi cả script
+		reply, err = s.do(ctx, append([]string{"EVAL", gcraScript}, args...)...)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	n := make([]int64, len(values))
+	for i, v := range values {
+		if n[i], ok = v.(int64); !ok {
+			return Result{}, fmt.Errorf("redis: unexpected reply %v", reply)
+		}
+	}
+
+	return Result{
+		Allowed:    n[0] == 1,
+		Limit:      limit.Requests,
+		Remaining:  int(n[1]),
+		ResetAfter: time.Duration(n[2]) * time.Microsecond,
+		RetryAfter: time.Duration(n[3]) * time.Microsecond,
+	}, nil
+}
+
+// Close đóng các kết nối đang rảnh trong pool
+func (s *RedisStore) Close(context.Context) error {
+	for {
+		select {
+		case conn := <-s.conns:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *RedisStore) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, s.opts.Timeout, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// Lỗi mạng/giao thức: trạng thái kết nối không còn tin được
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case s.conns <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.conns:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: s.opts.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+
+	if s.opts.Password != "" {
+		if _, err := conn.do(ctx, s.opts.Timeout, "AUTH", s.opts.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: auth: %w", err)
+		}
+	}
+	if s.opts.DB != 0 {
+		if _, err := conn.do(ctx, s.opts.Timeout, "SELECT", strconv.Itoa(s.opts.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: select: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError là lỗi server trả về (reply "-ERR ..."), kết nối vẫn dùng tiếp được
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn là client RESP tối thiểu: gửi lệnh dạng array of bulk string, đọc 1 reply
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, args ...string) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		values := make([]any, count)
+		for i := range values {
+			if values[i], err = c.readReply(); err != nil {
+				var redisErr redisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+				values[i] = err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newRedisStore(t *testing.T, opts RedisOptions) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	m := miniredis.RunT(t)
+	// TIME trong script lấy từ đồng hồ của miniredis, cố định để kết quả không phụ thuộc tốc độ máy
+	m.SetTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	opts.Addr = m.Addr()
+	store := NewRedisStore(opts)
+	t.Cleanup(func() { store.Close(context.Background()) })
+	return store, m
+}
+
+func allow(t *testing.T, store Store, key string, limit Limit) Result {
+	t.Helper()
+
+	result, err := store.Allow(context.Background(), key, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRedisStoreGCRA(t *testing.T) {
+	store, m := newRedisStore(t, RedisOptions{})
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	for want := 2; want >= 0; want-- {
+		result := allow(t, store, "ip:1", limit)
+		if !result.Allowed || result.Remaining != want || result.Limit != 3 {
+			t.Fatalf("request %d: %+v", 3-want, result)
+		}
+	}
+
+	result := allow(t, store, "ip:1", limit)
+	if result.Allowed || result.RetryAfter != 20*time.Second || result.ResetAfter != time.Minute {
+		t.Fatalf("4th request: %+v", result)
+	}
+
+	// Key khác có quota riêng
+	if result := allow(t, store, "ip:2", limit); !result.Allowed {
+		t.Errorf("other key blocked: %+v", result)
+	}
+
+	// Key hết hạn đúng lúc quota hồi đầy để Redis tự dọn
+	if ttl := m.TTL("ratelimit:ip:1"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+
+	m.SetTime(time.Date(2025, 1, 1, 0, 0, 20, 0, time.UTC))
+	if result := allow(t, store, "ip:1", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after RetryAfter: %+v", result)
+	}
+}
+
+// Hai instance dùng chung Redis thì dùng chung quota, khác với MemoryStore
+func TestRedisStoreShared(t *testing.T) {
+	a, m := newRedisStore(t, RedisOptions{})
+	b := NewRedisStore(RedisOptions{Addr: m.Addr()})
+	defer b.Close(context.Background())
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	allow(t, a, "user:1", limit)
+	allow(t, b, "user:1", limit)
+	if result := allow(t, a, "user:1", limit); result.Allowed {
+		t.Errorf("quota was not shared: %+v", result)
+	}
+}
+
+func TestRedisStoreScriptCache(t *testing.T) {
+	store, _ := newRedisStore(t, RedisOptions{})
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	allow(t, store, "k", limit)
+	reply, err := store.do(context.Background(), "SCRIPT", "EXISTS", gcraScriptSHA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := reply.([]any); len(exists) != 1 || exists[0] != int64(1) {
+		t.Fatalf("script was not loaded with EVAL after NOSCRIPT: %v", reply)
+	}
+
+	// Sau SCRIPT FLUSH (VD: Redis restart) EVALSHA lại trả NOSCRIPT
+	if _, err := store.do(context.Background(), "SCRIPT", "FLUSH"); err != nil {
+		t.Fatal(err)
+	}
+	if result := allow(t, store, "k", limit); result.Remaining != 8 {
+		t.Errorf("after SCRIPT FLUSH: %+v", result)
+	}
+}
+
+func TestRedisStoreAuthAndDB(t *testing.T) {
+	m := miniredis.RunT(t)
+	m.RequireAuth("secret")
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	wrong := NewRedisStore(RedisOptions{Addr: m.Addr(), Password: "wrong"})
+	if _, err := wrong.Allow(context.Background(), "k", limit); err == nil {
+		t.Error("expected an auth error")
+	}
+
+	store := NewRedisStore(RedisOptions{Addr: m.Addr(), Password: "secret", DB: 2})
+	defer store.Close(context.Background())
+	allow(t, store, "k", limit)
+
+	if !m.DB(2).Exists("ratelimit:k") || m.DB(0).Exists("ratelimit:k") {
+		t.Error("key should be written to DB 2 only")
+	}
+}
+
+// Redis chết thì trả lỗi (middleware cho request đi qua), kết nối hỏng bị bỏ khỏi pool
+// và request sau khi Redis lên lại dùng kết nối mới
+func TestRedisStoreReconnect(t *testing.T) {
+	store, m := newRedisStore(t, RedisOptions{Timeout: 200 * time.Millisecond})
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	allow(t, store, "k", limit)
+
+	m.Close()
+	if _, err := store.Allow(context.Background(), "k", limit); err == nil {
+		t.Fatal("expected an error while Redis is down")
+	}
+
+	if err := m.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if result := allow(t, store, "k", limit); !result.Allowed {
+		t.Errorf("after restart: %+v", result)
+	}
+}
+
+func TestRedisStoreServerError(t *testing.T) {
+	store, m := newRedisStore(t, RedisOptions{})
+	m.SetError("LOADING Redis is loading the dataset in memory")
+
+	if _, err := store.Allow(context.Background(), "k", Limit{Requests: 1, Period: time.Minute}); err == nil {
+		t.Error("expected the server error to be returned")
+	}
+	// Lỗi do server trả về không làm hỏng kết nối, kết nối được trả lại pool
+	if len(store.conns) != 1 {
+		t.Errorf("pool has %d connections, want 1", len(store.conns))
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/tracing"
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	CreateMany(ctx context.Context, categories []*models.Category) error
+	FindByID(ctx context.Context, id int) (*models.Category, bool)
+	FindBySlug(ctx context.Context, slug string) (*models.Category, bool)
+	List(ctx context.Context) []models.Category
+	UpdateMany(ctx context.Context, categories []*models.Category) error
+	DeleteMany(ctx context.Context, ids []int) error
+}
+
+type InMemoryCategoryRepository struct {
+	inMemoryPinger
+
+	mu     sync.RWMutex
+	nextID int
+	items  []models.Category
+}
+
+// NewInMemoryCategoryRepository tạo sẵn các category mà route /categories/:category chấp nhận
+func NewInMemoryCategoryRepository() *InMemoryCategoryRepository {
+	r := &InMemoryCategoryRepository{nextID: 1}
+	for _, name := range []string{"php", "python", "golang"} {
+		r.Create(context.Background(), &models.Category{Slug: name, Name: name, Status: "1"})
+	}
+
+	return r
+}
+
+func (r *InMemoryCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	_, span := tracing.Start(ctx, "CategoryRepository.Create")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.create(category, time.Now().UTC())
+
+	return nil
+}
+
+// CreateMany dùng cho API bulk
+func (r *InMemoryCategoryRepository) CreateMany(ctx context.Context, categories []*models.Category) error {
+	_, span := tracing.Start(ctx, "CategoryRepository.CreateMany", tracing.Int("batch.size", len(categories)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, category := range categories {
+		r.create(category, now)
+	}
+
+	return nil
+}
+
+func (r *InMemoryCategoryRepository) create(category *models.Category, now time.Time) {
+	category.ID = r.nextID
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	r.nextID++
+	r.items = append(r.items, *category)
+}
+
+func (r *InMemoryCategoryRepository) FindByID(ctx context.Context, id int) (*models.Category, bool) {
+	_, span := tracing.Start(ctx, "CategoryRepository.FindByID")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(id); i >= 0 {
+		category := r.items[i]
+		return &category, true
+	}
+
+	return nil, false
+}
+
+func (r *InMemoryCategoryRepository) FindBySlug(ctx context.Context, slug string) (*models.Category, bool) {
+	_, span := tracing.Start(ctx, "CategoryRepository.FindBySlug")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, item := range r.items {
+		if item.Slug == slug {
+			category := item
+			return &category, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *InMemoryCategoryRepository) List(ctx context.Context) []models.Category {
+	_, span := tracing.Start(ctx, "CategoryRepository.List")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]models.Category, len(r.items))
+	copy(result, r.items)
+
+	return result
+}
+
+// UpdateMany dùng cho API bulk, 1 category không tồn tại thì không category nào được ghi.
+// Slug giữ nguyên như khi tạo.
+func (r *InMemoryCategoryRepository) UpdateMany(ctx context.Context, categories []*models.Category) error {
+	_, span := tracing.Start(ctx, "CategoryRepository.UpdateMany", tracing.Int("batch.size", len(categories)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := make([]int, len(categories))
+	for i, category := range categories {
+		if index[i] = r.indexOf(category.ID); index[i] < 0 {
+			return ErrNotFound
+		}
+	}
+
+	now := time.Now().UTC()
+	for i, category := range categories {
+		stored := r.items[index[i]]
+		category.Slug = stored.Slug
+		category.CreatedAt = stored.CreatedAt
+		category.UpdatedAt = now
+		r.items[index[i]] = *category
+	}
+
+	return nil
+}
+
+// DeleteMany dùng cho API bulk, 1 ID không tồn tại (hoặc lặp lại) thì không category nào bị xoá
+func (r *InMemoryCategoryRepository) DeleteMany(ctx context.Context, ids []int) error {
+	_, span := tracing.Start(ctx, "CategoryRepository.DeleteMany", tracing.Int("batch.size", len(ids)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if r.indexOf(id) < 0 || deleted[id] {
+			return ErrNotFound
+		}
+		deleted[id] = true
+	}
+
+	r.items = slices.DeleteFunc(r.items, func(c models.Category) bool { return deleted[c.ID] })
+
+	return nil
+}
+
+func (r *InMemoryCategoryRepository) indexOf(id int) int {
+	for i, item := range r.items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
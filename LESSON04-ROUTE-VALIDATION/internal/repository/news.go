@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/tracing"
+)
+
+type NewsRepository interface {
+	Create(ctx context.Context, news *models.News) error
+	FindBySlug(ctx context.Context, slug string) (*models.News, bool)
+	// ListPublished trả về các tin đã publish, mới nhất trước.
+	// category rỗng nghĩa là lấy tất cả.
+	ListPublished(ctx context.Context, category string, limit int) []models.News
+	// SetImageDerivatives gắn ảnh thu nhỏ vào ảnh filename của tin id
+	SetImageDerivatives(ctx context.Context, id int, filename string, derivatives []models.ImageDerivative) error
+	// PublishDue publish các tin nháp có ScheduledAt không muộn hơn now, trả về các tin vừa publish
+	PublishDue(ctx context.Context, now time.Time) ([]models.News, error)
+}
+
+type InMemoryNewsRepository struct {
+	inMemoryPinger
+
+	mu     sync.RWMutex
+	nextID int
+	items  []models.News
+	outbox Outbox
+}
+
+func NewInMemoryNewsRepository(outbox Outbox) *InMemoryNewsRepository {
+	return &InMemoryNewsRepository{nextID: 1, outbox: outbox}
+}
+
+// Create ghi event news.published nếu tin được publish ngay, tin nháp thì chờ PublishDue
+func (r *InMemoryNewsRepository) Create(ctx context.Context, news *models.News) error {
+	ctx, span := tracing.Start(ctx, "NewsRepository.Create")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	created := *news
+	created.ID = r.nextID
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.Version = 1
+	if created.IsPublished() && created.PublishedAt.IsZero() {
+		created.PublishedAt = now
+	}
+
+	var batch []*events.Event
+	if created.IsPublished() {
+		event, err := newEvent(events.NewsPublished, events.AggregateNews, created.ID, created)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, event)
+	}
+
+	count := len(r.items)
+	err := commit(ctx, r.outbox, batch, func() {
+		r.items = append(r.items, created)
+		r.nextID++
+	}, func() {
+		r.items = r.items[:count]
+		r.nextID--
+	})
+	if err != nil {
+		return err
+	}
+
+	*news = created
+	return nil
+}
+
+func (r *InMemoryNewsRepository) FindBySlug(ctx context.Context, slug string) (*models.News, bool) {
+	_, span := tracing.Start(ctx, "NewsRepository.FindBySlug")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, item := range r.items {
+		if item.Slug == slug {
+			news := item
+			return &news, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *InMemoryNewsRepository) ListPublished(ctx context.Context, category string, limit int) []models.News {
+	_, span := tracing.Start(ctx, "NewsRepository.ListPublished")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.News
+	for _, item := range r.items {
+		if !item.IsPublished() {
+			continue
+		}
+		if category != "" && item.Category != category {
+			continue
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PublishedAt.After(result[j].PublishedAt)
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+func (r *InMemoryNewsRepository) SetImageDerivatives(ctx context.Context, id int, filename string, derivatives []models.ImageDerivative) error {
+	_, span := tracing.Start(ctx, "NewsRepository.SetImageDerivatives")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.items {
+		if r.items[i].ID != id {
+			continue
+		}
+		for j := range r.items[i].Images {
+			if r.items[i].Images[j].Filename == filename {
+				// Bản sao của tin trả ra ngoài dùng chung slice Images, phải copy trước khi sửa
+				images := slices.Clone(r.items[i].Images)
+				images[j].Derivatives = derivatives
+				r.items[i].Images = images
+				r.items[i].UpdatedAt = time.Now().UTC()
+				r.items[i].Version++
+				return nil
+			}
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *InMemoryNewsRepository) PublishDue(ctx context.Context, now time.Time) ([]models.News, error) {
+	ctx, span := tracing.Start(ctx, "NewsRepository.PublishDue")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var indexes []int
+	var published []models.News
+	var batch []*events.Event
+	for i, item := range r.items {
+		if item.IsPublished() || item.ScheduledAt == nil || item.ScheduledAt.After(now) {
+			continue
+		}
+
+		// PublishedAt là giờ đã hẹn chứ không phải giờ job chạy, để thứ tự trong feed đúng
+		item.Status = models.NewsStatusPublished
+		item.PublishedAt = *item.ScheduledAt
+		item.ScheduledAt = nil
+		item.UpdatedAt = now.UTC()
+		item.Version++
+
+		event, err := newEvent(events.NewsPublished, events.AggregateNews, item.ID, item)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, i)
+		published = append(published, item)
+		batch = append(batch, event)
+	}
+	drafts := make([]models.News, len(indexes))
+	err := commit(ctx, r.outbox, batch, func() {
+		for j, i := range indexes {
+			drafts[j] = r.items[i]
+			r.items[i] = published[j]
+		}
+	}, func() {
+		for j, i := range indexes {
+			r.items[i] = drafts[j]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return published, nil
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/tracing"
+)
+
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product) error
+	CreateMany(ctx context.Context, products []*models.Product) error
+	FindByID(ctx context.Context, id int) (*models.Product, bool)
+	FindBySlug(ctx context.Context, slug string) (*models.Product, bool)
+	List(ctx context.Context) []models.Product
+	Scan(ctx context.Context, batchSize int) iter.Seq[models.Product]
+	Update(ctx context.Context, product *models.Product) error
+	UpdateMany(ctx context.Context, products []*models.Product) error
+	Delete(ctx context.Context, id, version int) error
+	DeleteMany(ctx context.Context, products []*models.Product) error
+}
+
+type InMemoryProductRepository struct {
+	inMemoryPinger
+
+	mu     sync.RWMutex
+	nextID int
+	items  []models.Product
+	outbox Outbox
+}
+
+func NewInMemoryProductRepository(outbox Outbox) *InMemoryProductRepository {
+	return &InMemoryProductRepository{nextID: 1, outbox: outbox}
+}
+
+func (r *InMemoryProductRepository) Create(ctx context.Context, product *models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.Create")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(ctx, []*models.Product{product})
+}
+
+// CreateMany dùng cho API bulk, các product của batch có ID liên tiếp
+func (r *InMemoryProductRepository) CreateMany(ctx context.Context, products []*models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.CreateMany", tracing.Int("batch.size", len(products)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(ctx, products)
+}
+
+func (r *InMemoryProductRepository) create(ctx context.Context, products []*models.Product) error {
+	now := time.Now().UTC()
+	created := make([]models.Product, len(products))
+	batch := make([]*events.Event, len(products))
+	slugs := make(map[string]bool, len(r.items)+len(products))
+	for _, item := range r.items {
+		slugs[item.Slug] = true
+	}
+	for i, product := range products {
+		created[i] = *product
+		created[i].ID = r.nextID + i
+		created[i].Slug = uniqueSlug(product.Slug, slugs)
+		created[i].CreatedAt = now
+		created[i].UpdatedAt = now
+		created[i].Version = 1
+
+		var err error
+		if batch[i], err = newEvent(events.ProductCreated, events.AggregateProduct, created[i].ID, created[i]); err != nil {
+			return err
+		}
+	}
+	count := len(r.items)
+	err := commit(ctx, r.outbox, batch, func() {
+		r.items = append(r.items, created...)
+		r.nextID += len(created)
+	}, func() {
+		r.items = r.items[:count]
+		r.nextID -= len(created)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, product := range products {
+		*product = created[i]
+	}
+	return nil
+}
+
+// uniqueSlug thêm hậu tố -2, -3... khi slug đã có trong taken (VD: 2 product cùng tên),
+// slug được chọn được đánh dấu vào taken
+func uniqueSlug(slug string, taken map[string]bool) string {
+	candidate := slug
+	for n := 2; taken[candidate]; n++ {
+		candidate = slug + "-" + strconv.Itoa(n)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+func (r *InMemoryProductRepository) FindByID(ctx context.Context, id int) (*models.Product, bool) {
+	_, span := tracing.Start(ctx, "ProductRepository.FindByID")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(id); i >= 0 {
+		product := r.items[i]
+		return &product, true
+	}
+
+	return nil, false
+}
+
+func (r *InMemoryProductRepository) FindBySlug(ctx context.Context, slug string) (*models.Product, bool) {
+	_, span := tracing.Start(ctx, "ProductRepository.FindBySlug")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, item := range r.items {
+		if item.Slug == slug {
+			product := item
+			return &product, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *InMemoryProductRepository) List(ctx context.Context) []models.Product {
+	_, span := tracing.Start(ctx, "ProductRepository.List")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]models.Product, len(r.items))
+	copy(result, r.items)
+
+	return result
+}
+
+// Scan đọc product theo thứ tự ID, mỗi lần giữ lock chỉ copy batchSize dòng nên
+// người đọc chậm (VD: stream response) không chặn ghi. Dòng tạo/xoá trong lúc duyệt
+// có thể có hoặc không có trong kết quả. Dừng khi ctx bị huỷ.
+func (r *InMemoryProductRepository) Scan(ctx context.Context, batchSize int) iter.Seq[models.Product] {
+	return func(yield func(models.Product) bool) {
+		_, span := tracing.Start(ctx, "ProductRepository.Scan", tracing.Int("batch.size", batchSize))
+		defer span.End()
+
+		lastID := 0
+		for ctx.Err() == nil {
+			batch := r.batchAfter(lastID, batchSize)
+			for _, product := range batch {
+				if !yield(product) {
+					return
+				}
+			}
+			if len(batch) < batchSize {
+				return
+			}
+			lastID = batch[len(batch)-1].ID
+		}
+	}
+}
+
+// batchAfter dựa vào items luôn tăng dần theo ID (chỉ append khi tạo)
+func (r *InMemoryProductRepository) batchAfter(lastID, batchSize int) []models.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start := sort.Search(len(r.items), func(i int) bool { return r.items[i].ID > lastID })
+	end := min(start+batchSize, len(r.items))
+	return append([]models.Product(nil), r.items[start:end]...)
+}
+
+// Update chỉ ghi khi product.Version còn bằng version đang lưu, thành công thì tăng Version
+func (r *InMemoryProductRepository) Update(ctx context.Context, product *models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.Update")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, []*models.Product{product})
+}
+
+// UpdateMany dùng cho API bulk, kiểm tra cả batch trước khi ghi product đầu tiên
+func (r *InMemoryProductRepository) UpdateMany(ctx context.Context, products []*models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.UpdateMany", tracing.Int("batch.size", len(products)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, products)
+}
+
+func (r *InMemoryProductRepository) update(ctx context.Context, products []*models.Product) error {
+	now := time.Now().UTC()
+	index := make([]int, len(products))
+	stored := make([]models.Product, len(products))
+	updated := make([]models.Product, len(products))
+	batch := make([]*events.Event, len(products))
+	for i, product := range products {
+		index[i] = r.indexOf(product.ID)
+		if index[i] < 0 {
+			return ErrNotFound
+		}
+		// Cùng 1 product 2 lần trong batch thì lần sau đã cũ version
+		if r.items[index[i]].Version != product.Version || slices.Contains(index[:i], index[i]) {
+			return ErrVersionConflict
+		}
+
+		stored[i] = r.items[index[i]]
+		updated[i] = *product
+		updated[i].CreatedAt = stored[i].CreatedAt
+		updated[i].UpdatedAt = now
+		updated[i].Version++
+
+		var err error
+		if batch[i], err = newEvent(events.ProductUpdated, events.AggregateProduct, updated[i].ID, updated[i]); err != nil {
+			return err
+		}
+	}
+	err := commit(ctx, r.outbox, batch, func() {
+		for i, product := range updated {
+			r.items[index[i]] = product
+		}
+	}, func() {
+		for i, product := range stored {
+			r.items[index[i]] = product
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, product := range products {
+		*product = updated[i]
+	}
+	return nil
+}
+
+// Delete chỉ xoá khi version còn bằng version đang lưu
+func (r *InMemoryProductRepository) Delete(ctx context.Context, id, version int) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.Delete")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(ctx, []*models.Product{{ID: id, Version: version}})
+}
+
+// DeleteMany xoá theo ID và Version của từng product, 1 product không xoá được thì không product nào bị xoá
+func (r *InMemoryProductRepository) DeleteMany(ctx context.Context, products []*models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepository.DeleteMany", tracing.Int("batch.size", len(products)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(ctx, products)
+}
+
+func (r *InMemoryProductRepository) delete(ctx context.Context, products []*models.Product) error {
+	deleted := make(map[int]bool, len(products))
+	batch := make([]*events.Event, len(products))
+	for i, product := range products {
+		j := r.indexOf(product.ID)
+		if j < 0 || deleted[product.ID] {
+			return ErrNotFound
+		}
+		if r.items[j].Version != product.Version {
+			return ErrVersionConflict
+		}
+		deleted[product.ID] = true
+
+		var err error
+		if batch[i], err = newEvent(events.ProductDeleted, events.AggregateProduct, product.ID, r.items[j]); err != nil {
+			return err
+		}
+	}
+	stored := r.items
+	return commit(ctx, r.outbox, batch,
+		func() {
+			r.items = slices.DeleteFunc(slices.Clone(stored), func(p models.Product) bool { return deleted[p.ID] })
+		},
+		func() { r.items = stored })
+}
+
+func (r *InMemoryProductRepository) indexOf(id int) int {
+	for i, item := range r.items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"mamba.com/route-group/internal/events"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	// ErrVersionConflict: record đã bị ghi bởi request khác kể từ lúc được đọc
+	ErrVersionConflict = errors.New("record version conflict")
+	// ErrEmailExists: email đã thuộc về user khác, được kiểm tra khi đang giữ lock ghi
+	ErrEmailExists = errors.New("email already exists")
+)
+
+// Pinger được health check dùng để kiểm tra kết nối tới nơi lưu dữ liệu
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// inMemoryPinger được các repository trong bộ nhớ embed: Ping luôn thành công vì không có kết nối nào,
+// chỉ tôn trọng ctx bị huỷ
+type inMemoryPinger struct{}
+
+func (inMemoryPinger) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Outbox nhận domain event của thay đổi dữ liệu (events.Outbox). Dữ liệu trong bộ nhớ và outbox
+// không chung transaction nên repository dùng commit: event chỉ được ghi sau khi thay đổi đã áp dụng.
+type Outbox interface {
+	Append(ctx context.Context, batch ...*events.Event) error
+}
+
+// appendEvents bỏ qua khi repository không có outbox (VD: repository dựng riêng trong test)
+func appendEvents(ctx context.Context, outbox Outbox, batch ...*events.Event) error {
+	if outbox == nil || len(batch) == 0 {
+		return nil
+	}
+	return outbox.Append(ctx, batch...)
+}
+
+// commit áp dụng thay đổi rồi mới ghi event, ghi lỗi thì undo trả lại dữ liệu cũ.
+// Phải gọi khi đang giữ lock ghi để không request nào đọc được thay đổi sắp bị undo,
+// relay không bao giờ publish event của thay đổi chưa được áp dụng.
+func commit(ctx context.Context, outbox Outbox, batch []*events.Event, apply, undo func()) error {
+	apply()
+	if err := appendEvents(ctx, outbox, batch...); err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+func newEvent(eventType, aggregateType string, id int, data any) (*events.Event, error) {
+	event, err := events.New(eventType, aggregateType, strconv.Itoa(id), data)
+	return &event, err
+}
+
+// WriteBatch ghi items cho API bulk và trả về lỗi của từng phần tử.
+// atomic thì gọi many 1 lần, lỗi của many là lỗi của mọi phần tử. Không atomic thì gọi one cho từng phần tử
+// để phần tử bị request khác sửa/xoá ngay trước lúc ghi không làm các phần tử còn lại thất bại.
+func WriteBatch[T any](items []T, atomic bool, many func([]T) error, one func(T) error) []error {
+	errs := make([]error, len(items))
+	if len(items) == 0 {
+		return errs
+	}
+
+	if atomic {
+		if err := many(items); err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+		return errs
+	}
+
+	for i, item := range items {
+		errs[i] = one(item)
+	}
+	return errs
+}
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/tracing"
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	CreateMany(ctx context.Context, users []*models.User) error
+	FindByID(ctx context.Context, id int) (*models.User, bool)
+	FindByUUID(ctx context.Context, uid string) (*models.User, bool)
+	FindByEmail(ctx context.Context, email string) (*models.User, bool)
+	List(ctx context.Context) []models.User
+	Scan(ctx context.Context, batchSize int) iter.Seq[models.User]
+	Update(ctx context.Context, user *models.User) error
+	UpdateMany(ctx context.Context, users []*models.User) error
+	Delete(ctx context.Context, id, version int) error
+	DeleteMany(ctx context.Context, users []*models.User) error
+}
+
+type InMemoryUserRepository struct {
+	inMemoryPinger
+
+	mu     sync.RWMutex
+	nextID int
+	items  map[int]models.User
+	outbox Outbox
+}
+
+func NewInMemoryUserRepository(outbox Outbox) *InMemoryUserRepository {
+	return &InMemoryUserRepository{nextID: 1, items: make(map[int]models.User), outbox: outbox}
+}
+
+func (r *InMemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.Create")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(ctx, []*models.User{user})
+}
+
+// CreateMany tạo cả batch trong 1 lần giữ lock, request khác không thấy batch tạo dở
+func (r *InMemoryUserRepository) CreateMany(ctx context.Context, users []*models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.CreateMany", tracing.Int("batch.size", len(users)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(ctx, users)
+}
+
+func (r *InMemoryUserRepository) create(ctx context.Context, users []*models.User) error {
+	now := time.Now().UTC()
+	created := make([]models.User, len(users))
+	batch := make([]*events.Event, len(users))
+	emails := make(map[string]bool, len(users))
+	for i, user := range users {
+		// Email phải khác nhau cả giữa các user trong cùng batch
+		if emails[user.Email] || r.emailTaken(user.Email, 0) {
+			return ErrEmailExists
+		}
+		emails[user.Email] = true
+
+		created[i] = *user
+		created[i].ID = r.nextID + i
+		created[i].UUID = uuid.New().String()
+		created[i].CreatedAt = now
+		created[i].UpdatedAt = now
+		created[i].Version = 1
+
+		var err error
+		if batch[i], err = newEvent(events.UserCreated, events.AggregateUser, created[i].ID, created[i]); err != nil {
+			return err
+		}
+	}
+	err := commit(ctx, r.outbox, batch, func() {
+		for _, user := range created {
+			r.items[user.ID] = user
+		}
+		r.nextID += len(created)
+	}, func() {
+		for _, user := range created {
+			delete(r.items, user.ID)
+		}
+		r.nextID -= len(created)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, user := range users {
+		*user = created[i]
+	}
+	return nil
+}
+
+func (r *InMemoryUserRepository) FindByID(ctx context.Context, id int) (*models.User, bool) {
+	_, span := tracing.Start(ctx, "UserRepository.FindByID")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.items[id]
+	if !ok {
+		return nil, false
+	}
+	return &user, true
+}
+
+func (r *InMemoryUserRepository) FindByUUID(ctx context.Context, uid string) (*models.User, bool) {
+	_, span := tracing.Start(ctx, "UserRepository.FindByUUID")
+	defer span.End()
+
+	return r.findBy(func(u models.User) bool { return u.UUID == uid })
+}
+
+func (r *InMemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, bool) {
+	_, span := tracing.Start(ctx, "UserRepository.FindByEmail")
+	defer span.End()
+
+	return r.findBy(func(u models.User) bool { return u.Email == email })
+}
+
+// emailTaken cho biết email đã thuộc về user khác ngoài except, phải gọi khi đang giữ r.mu
+func (r *InMemoryUserRepository) emailTaken(email string, except int) bool {
+	for id, item := range r.items {
+		if id != except && item.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *InMemoryUserRepository) findBy(match func(models.User) bool) (*models.User, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, item := range r.items {
+		if match(item) {
+			user := item
+			return &user, true
+		}
+	}
+	return nil, false
+}
+
+func (r *InMemoryUserRepository) List(ctx context.Context) []models.User {
+	_, span := tracing.Start(ctx, "UserRepository.List")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]models.User, 0, len(r.items))
+	for _, item := range r.items {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
+
+// Scan đọc user theo thứ tự ID, từng batch như ProductRepository.Scan.
+// Danh sách ID được chụp lúc bắt đầu nên user tạo sau đó không có trong kết quả.
+func (r *InMemoryUserRepository) Scan(ctx context.Context, batchSize int) iter.Seq[models.User] {
+	return func(yield func(models.User) bool) {
+		_, span := tracing.Start(ctx, "UserRepository.Scan", tracing.Int("batch.size", batchSize))
+		defer span.End()
+
+		r.mu.RLock()
+		ids := slices.Sorted(maps.Keys(r.items))
+		r.mu.RUnlock()
+
+		for chunk := range slices.Chunk(ids, batchSize) {
+			if ctx.Err() != nil {
+				return
+			}
+			for _, user := range r.lookup(chunk) {
+				if !yield(user) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// lookup bỏ qua user đã bị xoá
+func (r *InMemoryUserRepository) lookup(ids []int) []models.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := r.items[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// Update chỉ ghi khi user.Version còn bằng version đang lưu, thành công thì tăng Version
+func (r *InMemoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.Update")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, []*models.User{user})
+}
+
+// UpdateMany dùng cho API bulk: 1 user không ghi được thì không user nào được ghi
+func (r *InMemoryUserRepository) UpdateMany(ctx context.Context, users []*models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.UpdateMany", tracing.Int("batch.size", len(users)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, users)
+}
+
+func (r *InMemoryUserRepository) update(ctx context.Context, users []*models.User) error {
+	now := time.Now().UTC()
+	stored := make([]models.User, len(users))
+	updated := make([]models.User, len(users))
+	batch := make([]*events.Event, len(users))
+	ids := make(map[int]bool, len(users))
+	emails := make(map[string]bool, len(users))
+	for i, user := range users {
+		var ok bool
+		if stored[i], ok = r.items[user.ID]; !ok {
+			return ErrNotFound
+		}
+		// Cùng 1 user 2 lần trong batch thì lần sau đã cũ version
+		if ids[user.ID] || stored[i].Version != user.Version {
+			return ErrVersionConflict
+		}
+		ids[user.ID] = true
+
+		if emails[user.Email] || user.Email != stored[i].Email && r.emailTaken(user.Email, user.ID) {
+			return ErrEmailExists
+		}
+		emails[user.Email] = true
+
+		updated[i] = *user
+		updated[i].UpdatedAt = now
+		updated[i].Version++
+
+		var err error
+		if batch[i], err = newEvent(events.UserUpdated, events.AggregateUser, updated[i].ID, updated[i]); err != nil {
+			return err
+		}
+	}
+	err := commit(ctx, r.outbox, batch, func() {
+		for _, user := range updated {
+			r.items[user.ID] = user
+		}
+	}, func() {
+		for _, user := range stored {
+			r.items[user.ID] = user
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, user := range users {
+		*user = updated[i]
+	}
+	return nil
+}
+
+// Delete chỉ xoá khi version còn bằng version đang lưu
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id, version int) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.Delete")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(ctx, []*models.User{{ID: id, Version: version}})
+}
+
+// DeleteMany xoá theo ID và Version của từng user, 1 user không xoá được thì không user nào bị xoá
+func (r *InMemoryUserRepository) DeleteMany(ctx context.Context, users []*models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.DeleteMany", tracing.Int("batch.size", len(users)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(ctx, users)
+}
+
+func (r *InMemoryUserRepository) delete(ctx context.Context, users []*models.User) error {
+	stored := make([]models.User, len(users))
+	batch := make([]*events.Event, len(users))
+	ids := make(map[int]bool, len(users))
+	for i, user := range users {
+		var ok bool
+		if stored[i], ok = r.items[user.ID]; !ok || ids[user.ID] {
+			return ErrNotFound
+		}
+		if stored[i].Version != user.Version {
+			return ErrVersionConflict
+		}
+		ids[user.ID] = true
+
+		var err error
+		if batch[i], err = newEvent(events.UserDeleted, events.AggregateUser, user.ID, stored[i]); err != nil {
+			return err
+		}
+	}
+	return commit(ctx, r.outbox, batch, func() {
+		for _, user := range stored {
+			delete(r.items, user.ID)
+		}
+	}, func() {
+		for _, user := range stored {
+			r.items[user.ID] = user
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/tracing"
+)
+
+// maxDeliveriesPerSubscription: log delivery chỉ giữ chừng này bản ghi mới nhất mỗi subscription
+const maxDeliveriesPerSubscription = 100
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	FindSubscription(ctx context.Context, id string) (*models.WebhookSubscription, bool)
+	ListSubscriptions(ctx context.Context) []models.WebhookSubscription
+	// ListSubscribers trả về subscription đang bật có nhận event
+	ListSubscribers(ctx context.Context, event string) []models.WebhookSubscription
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+	// RecordResult cập nhật số lần lỗi liên tiếp, lỗi đủ disableAfter lần thì tắt subscription.
+	// Trả về true khi subscription vừa bị tắt.
+	RecordResult(ctx context.Context, id string, ok bool, disableAfter int, reason string) (bool, error)
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDelivery(ctx context.Context, subscriptionID, id string) (*models.WebhookDelivery, bool)
+	// HasDelivery cho biết event đã được gửi (không tính gửi lại) tới subscription chưa
+	HasDelivery(ctx context.Context, subscriptionID, eventID string) bool
+	// ListDeliveries trả về delivery mới nhất trước
+	ListDeliveries(ctx context.Context, subscriptionID string) []models.WebhookDelivery
+	AddAttempt(ctx context.Context, id string, attempt models.WebhookAttempt, status string) error
+}
+
+type InMemoryWebhookRepository struct {
+	inMemoryPinger
+
+	mu            sync.RWMutex
+	subscriptions map[string]models.WebhookSubscription
+	// deliveries theo subscription, cũ nhất trước
+	deliveries map[string][]models.WebhookDelivery
+}
+
+func NewInMemoryWebhookRepository() *InMemoryWebhookRepository {
+	return &InMemoryWebhookRepository{
+		subscriptions: make(map[string]models.WebhookSubscription),
+		deliveries:    make(map[string][]models.WebhookDelivery),
+	}
+}
+
+func (r *InMemoryWebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	_, span := tracing.Start(ctx, "WebhookRepository.CreateSubscription")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	r.subscriptions[sub.ID] = cloneSubscription(*sub)
+
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) FindSubscription(ctx context.Context, id string) (*models.WebhookSubscription, bool) {
+	_, span := tracing.Start(ctx, "WebhookRepository.FindSubscription")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok {
+		return nil, false
+	}
+	sub = cloneSubscription(sub)
+	return &sub, true
+}
+
+func (r *InMemoryWebhookRepository) ListSubscriptions(ctx context.Context) []models.WebhookSubscription {
+	_, span := tracing.Start(ctx, "WebhookRepository.ListSubscriptions")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedSubscriptions(func(models.WebhookSubscription) bool { return true })
+}
+
+func (r *InMemoryWebhookRepository) ListSubscribers(ctx context.Context, event string) []models.WebhookSubscription {
+	_, span := tracing.Start(ctx, "WebhookRepository.ListSubscribers", tracing.String("webhook.event", event))
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedSubscriptions(func(sub models.WebhookSubscription) bool {
+		return sub.Active && (slices.Contains(sub.Events, event) || slices.Contains(sub.Events, "*"))
+	})
+}
+
+func (r *InMemoryWebhookRepository) sortedSubscriptions(match func(models.WebhookSubscription) bool) []models.WebhookSubscription {
+	result := []models.WebhookSubscription{}
+	for _, sub := range r.subscriptions {
+		if match(sub) {
+			result = append(result, cloneSubscription(sub))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+func (r *InMemoryWebhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	_, span := tracing.Start(ctx, "WebhookRepository.UpdateSubscription")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[sub.ID]; !ok {
+		return ErrNotFound
+	}
+
+	sub.UpdatedAt = time.Now().UTC()
+	r.subscriptions[sub.ID] = cloneSubscription(*sub)
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	_, span := tracing.Start(ctx, "WebhookRepository.DeleteSubscription")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.subscriptions, id)
+	delete(r.deliveries, id)
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) RecordResult(ctx context.Context, id string, ok bool, disableAfter int, reason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, exists := r.subscriptions[id]
+	if !exists {
+		return false, ErrNotFound
+	}
+
+	if ok {
+		sub.FailureCount = 0
+		r.subscriptions[id] = sub
+		return false, nil
+	}
+
+	sub.FailureCount++
+	disabled := sub.Active && sub.FailureCount >= disableAfter
+	if disabled {
+		now := time.Now().UTC()
+		sub.Active = false
+		sub.DisabledAt = &now
+		sub.DisabledReason = reason
+		sub.UpdatedAt = now
+	}
+	r.subscriptions[id] = sub
+	return disabled, nil
+}
+
+func (r *InMemoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, span := tracing.Start(ctx, "WebhookRepository.CreateDelivery")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[delivery.SubscriptionID]; !ok {
+		return ErrNotFound
+	}
+
+	delivery.ID = uuid.New().String()
+	delivery.CreatedAt = time.Now().UTC()
+
+	deliveries := append(r.deliveries[delivery.SubscriptionID], cloneDelivery(*delivery))
+	if len(deliveries) > maxDeliveriesPerSubscription {
+		deliveries = slices.Delete(deliveries, 0, len(deliveries)-maxDeliveriesPerSubscription)
+	}
+	r.deliveries[delivery.SubscriptionID] = deliveries
+
+	return nil
+}
+
+func (r *InMemoryWebhookRepository) FindDelivery(ctx context.Context, subscriptionID, id string) (*models.WebhookDelivery, bool) {
+	_, span := tracing.Start(ctx, "WebhookRepository.FindDelivery")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.deliveries[subscriptionID] {
+		if delivery.ID == id {
+			delivery = cloneDelivery(delivery)
+			return &delivery, true
+		}
+	}
+	return nil, false
+}
+
+func (r *InMemoryWebhookRepository) HasDelivery(ctx context.Context, subscriptionID, eventID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.ContainsFunc(r.deliveries[subscriptionID], func(delivery models.WebhookDelivery) bool {
+		return delivery.EventID == eventID && delivery.RedeliveryOf == ""
+	})
+}
+
+func (r *InMemoryWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string) []models.WebhookDelivery {
+	_, span := tracing.Start(ctx, "WebhookRepository.ListDeliveries")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := r.deliveries[subscriptionID]
+	result := make([]models.WebhookDelivery, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		result = append(result, cloneDelivery(deliveries[i]))
+	}
+	return result
+}
+
+func (r *InMemoryWebhookRepository) AddAttempt(ctx context.Context, id string, attempt models.WebhookAttempt, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for subscriptionID, deliveries := range r.deliveries {
+		for i := range deliveries {
+			if deliveries[i].ID != id {
+				continue
+			}
+
+			delivery := cloneDelivery(deliveries[i])
+			delivery.Attempts = append(delivery.Attempts, attempt)
+			delivery.Status = status
+			if status == models.WebhookDeliverySucceeded {
+				delivery.DeliveredAt = &attempt.At
+			}
+			r.deliveries[subscriptionID][i] = delivery
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func cloneSubscription(sub models.WebhookSubscription) models.WebhookSubscription {
+	sub.Events = slices.Clone(sub.Events)
+	return sub
+}
+
+func cloneDelivery(delivery models.WebhookDelivery) models.WebhookDelivery {
+	delivery.Attempts = slices.Clone(delivery.Attempts)
+	return delivery
+}
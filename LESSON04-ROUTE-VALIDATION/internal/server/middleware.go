@@ -0,0 +1,26 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/utils"
+)
+
+// RequireClientCert chặn request không có client cert đã được verify bằng ClientCAFile.
+// Dùng cho group nhạy cảm (admin) khi TLS bật mTLS ở chế độ VerifyClientCertIfGiven.
+func RequireClientCert() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		state := ctx.Request.TLS
+		if state == nil || len(state.VerifiedChains) == 0 {
+			utils.Render(ctx, http.StatusForbidden, gin.H{"error": "Client certificate required"})
+			ctx.Abort()
+			return
+		}
+
+		logging.From(ctx).Debug("client certificate accepted", slog.String("subject", state.VerifiedChains[0][0].Subject.String()))
+		ctx.Next()
+	}
+}
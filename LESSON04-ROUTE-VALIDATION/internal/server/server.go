@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+type Options struct {
+	Addr string
+	// ReadTimeout tính cả thời gian đọc body, phải đủ cho upload nhiều file
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// ShutdownTimeout là hạn chót cho toàn bộ quá trình tắt: drain, chờ request, dừng worker
+	ShutdownTimeout time.Duration
+	// DrainDelay là thời gian chờ sau khi readiness báo lỗi để load balancer
+	// ngừng gửi request mới trước khi đóng listener
+	DrainDelay time.Duration
+	// TLS khác nil thì phục vụ HTTPS, HTTP/2 được chọn qua ALPN
+	TLS *tls.Config
+	// H2C bật HTTP/2 không mã hoá trên listener plaintext, dùng cho traffic nội bộ
+	// (service mesh, load balancer đã terminate TLS)
+	H2C bool
+	// HTTP3 mở thêm listener QUIC (UDP) cùng cổng với Addr, cần TLS. Đang thử nghiệm.
+	HTTP3 bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Addr:              ":8080",
+		ReadTimeout:       60 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   30 * time.Second,
+		DrainDelay:        5 * time.Second,
+	}
+}
+
+type stopFunc struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Runner chạy http.Server đến khi nhận SIGINT/SIGTERM rồi tắt theo thứ tự:
+// gọi OnDrain (VD: readiness báo lỗi) -> chờ DrainDelay -> Shutdown server
+// (chờ request đang chạy, VD: upload) -> dừng worker theo thứ tự đăng ký
+type Runner struct {
+	opts    Options
+	server  *http.Server
+	http3   *http3.Server
+	logger  *slog.Logger
+	onDrain []func()
+	workers []stopFunc
+}
+
+func NewRunner(handler http.Handler, opts Options, logger *slog.Logger) (*Runner, error) {
+	if opts.HTTP3 && opts.TLS == nil {
+		return nil, errors.New("server: HTTP/3 requires TLS")
+	}
+
+	r := &Runner{
+		opts:   opts,
+		logger: logger,
+	}
+
+	if opts.HTTP3 {
+		r.http3 = &http3.Server{
+			Handler:        handler,
+			TLSConfig:      http3.ConfigureTLSConfig(opts.TLS),
+			MaxHeaderBytes: opts.MaxHeaderBytes,
+			IdleTimeout:    opts.IdleTimeout,
+			Logger:         logger,
+		}
+		handler = r.advertiseHTTP3(handler)
+	}
+
+	r.server = &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		TLSConfig:         opts.TLS,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	if opts.H2C && opts.TLS == nil {
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		r.server.Protocols = &protocols
+	}
+
+	return r, nil
+}
+
+// advertiseHTTP3 thêm header Alt-Svc vào response HTTP/1.1 và HTTP/2
+// để client biết có thể chuyển sang HTTP/3
+func (r *Runner) advertiseHTTP3(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.http3.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Server trả về http.Server để cấu hình thêm (TLS...) trước khi Run
+func (r *Runner) Server() *http.Server {
+	return r.server
+}
+
+func (r *Runner) OnDrain(fn func()) {
+	r.onDrain = append(r.onDrain, fn)
+}
+
+// OnStop đăng ký worker cần dừng sau khi server ngừng nhận request,
+// worker đăng ký trước dừng trước
+func (r *Runner) OnStop(name string, stop func(ctx context.Context) error) {
+	r.workers = append(r.workers, stopFunc{name: name, stop: stop})
+}
+
+// Run chặn đến khi nhận tín hiệu hoặc ctx bị huỷ
+func (r *Runner) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", r.opts.Addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(ctx, listener)
+}
+
+func (r *Runner) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// HTTP/3 dùng cùng cổng với listener TCP nhưng qua UDP
+	var udpConn net.PacketConn
+	if r.http3 != nil {
+		conn, err := net.ListenPacket("udp", listener.Addr().String())
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("http3: %w", err)
+		}
+		// http3.Server không tự đóng conn được truyền vào Serve
+		defer conn.Close()
+		udpConn = conn
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		r.logger.Info("server started",
+			slog.String("addr", listener.Addr().String()),
+			slog.Bool("tls", r.opts.TLS != nil),
+			slog.Bool("h2c", r.server.Protocols != nil),
+			slog.Bool("http3", r.http3 != nil),
+		)
+		if r.opts.TLS != nil {
+			// cert lấy từ TLSConfig.GetCertificate
+			serveErr <- r.server.ServeTLS(listener, "", "")
+			return
+		}
+		serveErr <- r.server.Serve(listener)
+	}()
+	if udpConn != nil {
+		go func() {
+			serveErr <- r.http3.Serve(udpConn)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		// Server dừng mà không có tín hiệu tắt: vẫn dừng worker rồi trả lỗi
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), r.opts.ShutdownTimeout)
+		defer cancel()
+		r.server.Close()
+		if r.http3 != nil {
+			r.http3.Close()
+		}
+		return errors.Join(err, r.stopWorkers(shutdownCtx))
+	case <-ctx.Done():
+	}
+
+	// Tín hiệu thứ 2 sẽ dùng hành vi mặc định (thoát ngay) nếu người vận hành không muốn chờ
+	stop()
+
+	return r.shutdown()
+}
+
+func (r *Runner) shutdown() error {
+	r.logger.Info("shutting down, draining connections",
+		slog.Duration("drain_delay", r.opts.DrainDelay),
+		slog.Duration("timeout", r.opts.ShutdownTimeout),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.ShutdownTimeout)
+	defer cancel()
+
+	for _, fn := range r.onDrain {
+		fn()
+	}
+
+	select {
+	case <-time.After(r.opts.DrainDelay):
+	case <-ctx.Done():
+	}
+
+	var errs []error
+	if err := r.server.Shutdown(ctx); err != nil {
+		// Hết hạn mà vẫn còn request: đóng cứng để process thoát được
+		r.logger.Warn("shutdown deadline exceeded, closing remaining connections", slog.String("error", err.Error()))
+		r.server.Close()
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+	if r.http3 != nil {
+		// Client QUIC biến mất không đóng kết nối thì phải chờ idle timeout của QUIC,
+		// tối đa đến ShutdownTimeout
+		if err := r.http3.Shutdown(ctx); err != nil {
+			r.http3.Close()
+			errs = append(errs, fmt.Errorf("http3 server: %w", err))
+		}
+	}
+
+	errs = append(errs, r.stopWorkers(ctx))
+
+	err := errors.Join(errs...)
+	if err == nil {
+		r.logger.Info("server stopped")
+	}
+	return err
+}
+
+func (r *Runner) stopWorkers(ctx context.Context) error {
+	var errs []error
+	for _, worker := range r.workers {
+		start := time.Now()
+		if err := worker.stop(ctx); err != nil {
+			r.logger.Error("worker did not stop cleanly", slog.String("worker", worker.name), slog.String("error", err.Error()))
+			errs = append(errs, fmt.Errorf("%s: %w", worker.name, err))
+			continue
+		}
+		r.logger.Info("worker stopped", slog.String("worker", worker.name), slog.Duration("took", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
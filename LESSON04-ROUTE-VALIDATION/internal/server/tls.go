@@ -0,0 +1,146 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile bật mTLS: cert của client được verify nếu có gửi,
+	// route nào bắt buộc cert thì dùng RequireClientCert
+	ClientCAFile string
+	// ReloadInterval là khoảng thời gian tối thiểu giữa 2 lần kiểm tra cert trên đĩa
+	ReloadInterval time.Duration
+}
+
+// NewTLSConfig tạo tls.Config đọc lại cert/key khi file trên đĩa thay đổi
+// (VD: certbot, cert-manager gia hạn) mà không cần restart
+func NewTLSConfig(opts TLSOptions, logger *slog.Logger) (*tls.Config, error) {
+	certs, err := newCertReloader(opts.CertFile, opts.KeyFile, opts.ReloadInterval, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+
+	if opts.ClientCAFile != "" {
+		pool, err := loadCertPool(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return conf, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("client CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("client CA: no PEM certificates in %s", file)
+	}
+	return pool, nil
+}
+
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration, logger *slog.Logger) (*certReloader, error) {
+	c := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+		logger:   logger,
+	}
+
+	modTime, err := c.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.load(modTime); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate kiểm tra file tối đa 1 lần mỗi interval trong lúc handshake.
+// Cert mới lỗi (VD: đang ghi dở) thì giữ cert cũ và thử lại ở lần kiểm tra sau.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := time.Now(); now.Sub(c.checked) >= c.interval {
+		c.checked = now
+
+		modTime, err := c.stat()
+		if err == nil && !modTime.Equal(c.modTime) {
+			err = c.load(modTime)
+			if err == nil {
+				c.logger.Info("tls certificate reloaded", slog.Time("not_after", c.cert.Leaf.NotAfter))
+			}
+		}
+		if err != nil {
+			c.logger.Error("tls certificate reload failed, keeping current certificate", slog.String("error", err.Error()))
+		}
+	}
+
+	return c.cert, nil
+}
+
+// stat trả về thời điểm sửa mới nhất của cert và key, đổi 1 trong 2 file là load lại
+func (c *certReloader) stat() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (c *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		return errors.New("tls: certificate has no leaf")
+	}
+	if time.Now().After(cert.Leaf.NotAfter) {
+		c.logger.Warn("tls certificate has expired", slog.Time("not_after", cert.Leaf.NotAfter))
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
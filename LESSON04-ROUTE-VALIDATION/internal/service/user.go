@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailExists  = repository.ErrEmailExists
+	// ErrUserModified: user đã bị request khác sửa/xoá kể từ lúc được đọc
+	ErrUserModified = errors.New("user was modified")
+)
+
+// UserInput là dữ liệu chung cho create/update, adapter của v1 và v2 đều chuyển về dạng này.
+// Field con trỏ nil nghĩa là giữ nguyên giá trị cũ khi update.
+type UserInput struct {
+	Name    *string
+	Email   *string
+	Profile *models.UserProfile
+	Avatar  *string
+}
+
+// UserService là nơi duy nhất chứa nghiệp vụ user, handler v1 và v2 chỉ khác nhau ở representation
+type UserService struct {
+	repo repository.UserRepository
+}
+
+func NewUserService(repo repository.UserRepository) *UserService {
+	return &UserService{repo: repo}
+}
+
+func (s *UserService) List(ctx context.Context) []models.User {
+	return s.repo.List(ctx)
+}
+
+// Scan đọc user theo từng batch cho response stream, không giữ cả danh sách trong bộ nhớ
+func (s *UserService) Scan(ctx context.Context, batchSize int) iter.Seq[models.User] {
+	return s.repo.Scan(ctx, batchSize)
+}
+
+func (s *UserService) GetByID(ctx context.Context, id int) (*models.User, error) {
+	user, ok := s.repo.FindByID(ctx, id)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserService) GetByUUID(ctx context.Context, uid string) (*models.User, error) {
+	user, ok := s.repo.FindByUUID(ctx, uid)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserService) Create(ctx context.Context, input UserInput) (*models.User, error) {
+	user := &models.User{}
+	s.apply(user, input)
+
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateMany tạo nhiều user trong 1 lần ghi. errs[i] khác nil là lý do inputs[i] không được tạo,
+// users[i] là user đã tạo. atomic = true: có 1 input lỗi thì không tạo user nào.
+func (s *UserService) CreateMany(ctx context.Context, inputs []UserInput, atomic bool) (users []*models.User, errs []error) {
+	users = make([]*models.User, len(inputs))
+	errs = make([]error, len(inputs))
+
+	// Email phải khác nhau cả giữa các input trong cùng batch
+	seen := make(map[string]bool, len(inputs))
+	var valid []*models.User
+	for i, input := range inputs {
+		if input.Email != nil && seen[*input.Email] {
+			errs[i] = ErrEmailExists
+			continue
+		}
+
+		// Chỉ để báo lỗi theo từng item ở mode partial, repository vẫn kiểm tra lại khi ghi
+		if input.Email != nil {
+			if _, exists := s.repo.FindByEmail(ctx, *input.Email); exists {
+				errs[i] = ErrEmailExists
+				continue
+			}
+		}
+
+		user := &models.User{}
+		s.apply(user, input)
+		seen[user.Email] = true
+		users[i] = user
+		valid = append(valid, user)
+	}
+
+	if atomic && len(valid) < len(inputs) {
+		return make([]*models.User, len(inputs)), errs
+	}
+
+	written := repository.WriteBatch(valid, atomic,
+		func(batch []*models.User) error { return s.repo.CreateMany(ctx, batch) },
+		func(user *models.User) error { return s.repo.Create(ctx, user) })
+	return users, mergeErrors(users, errs, written, func(err error) error { return err })
+}
+
+// Update ghi đè user đã đọc trước đó, user bị request khác sửa trong lúc đó thì trả ErrUserModified
+func (s *UserService) Update(ctx context.Context, user *models.User, input UserInput) (*models.User, error) {
+	updated := *user
+	s.apply(&updated, input)
+
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return nil, repositoryError(err)
+	}
+	return &updated, nil
+}
+
+// Delete chỉ xoá khi user chưa bị đổi kể từ lúc đọc (cùng Version)
+func (s *UserService) Delete(ctx context.Context, user *models.User) error {
+	return repositoryError(s.repo.Delete(ctx, user.ID, user.Version))
+}
+
+// UpdateMany là Update cho API bulk: users là các user đã đọc, inputs[i] được áp dụng cho users[i].
+// errs và atomic giống CreateMany.
+func (s *UserService) UpdateMany(ctx context.Context, users []*models.User, inputs []UserInput, atomic bool) (updated []*models.User, errs []error) {
+	updated = make([]*models.User, len(users))
+	errs = make([]error, len(users))
+
+	ids := make(map[int]bool, len(users))
+	emails := make(map[string]bool, len(users))
+	var valid []*models.User
+	for i, user := range users {
+		// Lần sửa thứ 2 của cùng 1 user dựa trên version mà lần đầu đã làm cũ
+		if ids[user.ID] {
+			errs[i] = ErrUserModified
+			continue
+		}
+
+		next := *user
+		s.apply(&next, inputs[i])
+		if emails[next.Email] {
+			errs[i] = ErrEmailExists
+			continue
+		}
+		if next.Email != user.Email {
+			if other, exists := s.repo.FindByEmail(ctx, next.Email); exists && other.ID != user.ID {
+				errs[i] = ErrEmailExists
+				continue
+			}
+		}
+
+		ids[user.ID] = true
+		emails[next.Email] = true
+		updated[i] = &next
+		valid = append(valid, &next)
+	}
+
+	if atomic && len(valid) < len(users) {
+		return make([]*models.User, len(users)), errs
+	}
+
+	written := repository.WriteBatch(valid, atomic,
+		func(batch []*models.User) error { return s.repo.UpdateMany(ctx, batch) },
+		func(user *models.User) error { return s.repo.Update(ctx, user) })
+	return updated, mergeErrors(updated, errs, written, repositoryError)
+}
+
+// DeleteMany là Delete cho API bulk, errs và atomic giống CreateMany
+func (s *UserService) DeleteMany(ctx context.Context, users []*models.User, atomic bool) (errs []error) {
+	errs = make([]error, len(users))
+
+	ids := make(map[int]bool, len(users))
+	deleted := make([]*models.User, len(users))
+	var valid []*models.User
+	for i, user := range users {
+		if ids[user.ID] {
+			errs[i] = ErrUserNotFound
+			continue
+		}
+		ids[user.ID] = true
+		deleted[i] = user
+		valid = append(valid, user)
+	}
+
+	if atomic && len(valid) < len(users) {
+		return errs
+	}
+
+	written := repository.WriteBatch(valid, atomic,
+		func(batch []*models.User) error { return s.repo.DeleteMany(ctx, batch) },
+		func(user *models.User) error { return s.repo.Delete(ctx, user.ID, user.Version) })
+	return mergeErrors(deleted, errs, written, repositoryError)
+}
+
+// mergeErrors chép lỗi lúc ghi (written, theo thứ tự các phần tử khác nil của users) vào errs
+// và đặt users[i] = nil cho phần tử không ghi được
+func mergeErrors(users []*models.User, errs, written []error, convert func(error) error) []error {
+	j := 0
+	for i, user := range users {
+		if user == nil {
+			continue
+		}
+		if written[j] != nil {
+			errs[i] = convert(written[j])
+			users[i] = nil
+		}
+		j++
+	}
+	return errs
+}
+
+func repositoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repository.ErrVersionConflict):
+		return ErrUserModified
+	}
+	return err
+}
+
+// apply chép input vào user. Email trùng được repository kiểm tra khi ghi (trong lock),
+// kiểm tra ở đây thì 2 request tạo cùng email song song đều qua được.
+func (s *UserService) apply(user *models.User, input UserInput) {
+	if input.Email != nil {
+		user.Email = *input.Email
+	}
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+	if input.Profile != nil {
+		user.Profile = *input.Profile
+	}
+	if input.Avatar != nil {
+		user.Avatar = *input.Avatar
+	}
+}
@@ -0,0 +1,213 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxURLs là giới hạn số URL trong 1 file sitemap theo sitemaps.org
+const MaxURLs = 50000
+
+var ErrShardNotFound = errors.New("sitemap shard not found")
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+	Images  []string
+}
+
+// Source trả về toàn bộ URL của một loại nội dung (products, categories, news).
+// baseURL không có "/" ở cuối, VD: https://mamba.com
+type Source func(ctx context.Context, baseURL string) []URL
+
+type Generator struct {
+	MaxURLs int
+
+	mu      sync.RWMutex
+	names   []string
+	sources map[string]Source
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		MaxURLs: MaxURLs,
+		sources: make(map[string]Source),
+	}
+}
+
+func (g *Generator) Register(name string, source Source) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.sources[name]; !ok {
+		g.names = append(g.names, name)
+	}
+	g.sources[name] = source
+}
+
+// Shard là 1 file sitemap con, VD: products-1.xml
+type Shard struct {
+	Name    string
+	LastMod time.Time
+	URLs    []URL
+}
+
+func (s Shard) Filename() string {
+	return s.Name + ".xml"
+}
+
+// Shards chia URL của từng source thành các file tối đa MaxURLs
+func (g *Generator) Shards(ctx context.Context, baseURL string) []Shard {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var shards []Shard
+	for _, name := range g.names {
+		shards = append(shards, g.split(name, g.sources[name](ctx, baseURL))...)
+	}
+
+	return shards
+}
+
+func (g *Generator) Shard(ctx context.Context, baseURL, filename string) (Shard, error) {
+	name := strings.TrimSuffix(filename, ".xml")
+	idx := strings.LastIndex(name, "-")
+	if idx < 0 {
+		return Shard{}, ErrShardNotFound
+	}
+
+	page, err := strconv.Atoi(name[idx+1:])
+	if err != nil || page < 1 {
+		return Shard{}, ErrShardNotFound
+	}
+
+	g.mu.RLock()
+	source, ok := g.sources[name[:idx]]
+	g.mu.RUnlock()
+	if !ok {
+		return Shard{}, ErrShardNotFound
+	}
+
+	shards := g.split(name[:idx], source(ctx, baseURL))
+	if page > len(shards) {
+		return Shard{}, ErrShardNotFound
+	}
+
+	return shards[page-1], nil
+}
+
+func (g *Generator) split(name string, urls []URL) []Shard {
+	size := g.MaxURLs
+	if size <= 0 || size > MaxURLs {
+		size = MaxURLs
+	}
+
+	var shards []Shard
+	for page := 1; len(urls) > 0 || page == 1; page++ {
+		n := min(size, len(urls))
+		shard := Shard{Name: fmt.Sprintf("%s-%d", name, page), URLs: urls[:n]}
+		for _, u := range shard.URLs {
+			if u.LastMod.After(shard.LastMod) {
+				shard.LastMod = u.LastMod
+			}
+		}
+
+		shards = append(shards, shard)
+		urls = urls[n:]
+	}
+
+	return shards
+}
+
+// ShardURL là địa chỉ public của 1 shard, dùng trong sitemap index
+func ShardURL(baseURL string, shard Shard) string {
+	return baseURL + "/sitemaps/" + shard.Filename()
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []indexSitemap `xml:"sitemap"`
+}
+
+type indexSitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	ImageNS string   `xml:"xmlns:image,attr"`
+	URLs    []urlXML `xml:"url"`
+}
+
+type urlXML struct {
+	Loc     string     `xml:"loc"`
+	LastMod string     `xml:"lastmod,omitempty"`
+	Images  []imageXML `xml:"image:image"`
+}
+
+type imageXML struct {
+	Loc string `xml:"image:loc"`
+}
+
+func (g *Generator) Index(ctx context.Context, baseURL string) ([]byte, error) {
+	var index sitemapIndex
+	for _, shard := range g.Shards(ctx, baseURL) {
+		index.Sitemaps = append(index.Sitemaps, indexSitemap{
+			Loc:     ShardURL(baseURL, shard),
+			LastMod: lastMod(shard.LastMod),
+		})
+	}
+
+	return marshal(index)
+}
+
+func (s Shard) XML() ([]byte, error) {
+	set := urlSet{ImageNS: "http://www.google.com/schemas/sitemap-image/1.1"}
+	for _, u := range s.URLs {
+		item := urlXML{Loc: u.Loc, LastMod: lastMod(u.LastMod)}
+		for _, image := range u.Images {
+			item.Images = append(item.Images, imageXML{Loc: image})
+		}
+		set.URLs = append(set.URLs, item)
+	}
+
+	return marshal(set)
+}
+
+func Gzip(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshal(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
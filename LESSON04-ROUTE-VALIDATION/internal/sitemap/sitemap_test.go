@@ -0,0 +1,193 @@
+package sitemap_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mamba.com/route-group/internal/sitemap"
+	"mamba.com/route-group/internal/storage"
+)
+
+const baseURL = "https://mamba.com"
+
+var ict = time.FixedZone("ICT", 7*3600)
+
+// products trả về n URL, URL thứ i sửa lần cuối lúc start + i phút
+func products(n int, start time.Time) sitemap.Source {
+	return func(ctx context.Context, baseURL string) []sitemap.URL {
+		urls := make([]sitemap.URL, n)
+		for i := range urls {
+			urls[i] = sitemap.URL{
+				Loc:     fmt.Sprintf("%s/products/p-%d", baseURL, i),
+				LastMod: start.Add(time.Duration(i) * time.Minute),
+			}
+		}
+		return urls
+	}
+}
+
+type index struct {
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+type urlSet struct {
+	URLs []struct {
+		Loc     string   `xml:"loc"`
+		LastMod string   `xml:"lastmod"`
+		Images  []string `xml:"image>loc"`
+	} `xml:"url"`
+}
+
+func TestSplit(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	g := sitemap.NewGenerator()
+	g.Register("products", products(sitemap.MaxURLs+1, start))
+	g.Register("news", products(0, start))
+
+	shards := g.Shards(ctx, baseURL)
+	var got []string
+	for _, s := range shards {
+		got = append(got, fmt.Sprintf("%s:%d", s.Filename(), len(s.URLs)))
+	}
+	// Source rỗng vẫn có 1 shard để index không trỏ tới file không tồn tại
+	if want := "products-1.xml:50000 products-2.xml:1 news-1.xml:0"; strings.Join(got, " ") != want {
+		t.Fatalf("shards = %v, want %s", got, want)
+	}
+
+	data, err := g.Index(ctx, baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed index
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Sitemaps) != 3 {
+		t.Fatalf("index has %d sitemaps, want 3", len(parsed.Sitemaps))
+	}
+	// lastmod của shard là lastmod mới nhất trong shard
+	first, second := parsed.Sitemaps[0], parsed.Sitemaps[1]
+	if first.Loc != baseURL+"/sitemaps/products-1.xml" || first.LastMod != start.Add((sitemap.MaxURLs-1)*time.Minute).Format(time.RFC3339) {
+		t.Errorf("first shard = %+v", first)
+	}
+	if second.LastMod != start.Add(sitemap.MaxURLs*time.Minute).Format(time.RFC3339) {
+		t.Errorf("second shard = %+v", second)
+	}
+	if parsed.Sitemaps[2].LastMod != "" {
+		t.Errorf("empty shard lastmod = %q", parsed.Sitemaps[2].LastMod)
+	}
+
+	shard, err := g.Shard(ctx, baseURL, "products-2.xml")
+	if err != nil || len(shard.URLs) != 1 || shard.URLs[0].Loc != baseURL+"/products/p-50000" {
+		t.Errorf("Shard(products-2.xml) = %+v, %v", shard, err)
+	}
+	for _, name := range []string{"products-3.xml", "products-0.xml", "products.xml", "missing-1.xml"} {
+		if _, err := g.Shard(ctx, baseURL, name); !errors.Is(err, sitemap.ErrShardNotFound) {
+			t.Errorf("Shard(%s): err = %v", name, err)
+		}
+	}
+
+	// MaxURLs lớn hơn giới hạn của sitemaps.org vẫn bị chặn ở 50000
+	g.MaxURLs = 2 * sitemap.MaxURLs
+	if n := len(g.Shards(ctx, baseURL)); n != 3 {
+		t.Errorf("shards with MaxURLs above the limit = %d, want 3", n)
+	}
+	g.MaxURLs = 20000
+	if n := len(g.Shards(ctx, baseURL)); n != 4 {
+		t.Errorf("shards with MaxURLs 20000 = %d, want 4", n)
+	}
+}
+
+func TestLastMod(t *testing.T) {
+	shard := sitemap.Shard{Name: "news-1", URLs: []sitemap.URL{
+		{Loc: baseURL + "/news/a", LastMod: time.Date(2026, 3, 1, 9, 30, 15, 999, ict), Images: []string{baseURL + "/uploads/a.jpg"}},
+		{Loc: baseURL + "/news/b"},
+	}}
+	data, err := shard.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed urlSet
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.URLs) != 2 {
+		t.Fatalf("urls = %+v", parsed.URLs)
+	}
+	// W3C datetime ở UTC, bỏ phần nhỏ hơn giây
+	if a := parsed.URLs[0]; a.LastMod != "2026-03-01T02:30:15Z" || len(a.Images) != 1 || a.Images[0] != baseURL+"/uploads/a.jpg" {
+		t.Errorf("url a = %+v", a)
+	}
+	if strings.Count(string(data), "<lastmod>") != 1 {
+		t.Errorf("URL without LastMod must omit <lastmod>:\n%s", data)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	g := sitemap.NewGenerator()
+	g.Register("products", products(3, time.Now()))
+	g.MaxURLs = 2
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := g.Index(r.Context(), r.Header.Get("X-Forwarded-Proto")+"://"+r.Host)
+		w.Write(data)
+	})
+	mux.HandleFunc("/sitemaps/{name}", func(w http.ResponseWriter, r *http.Request) {
+		shard, err := g.Shard(r.Context(), r.Header.Get("X-Forwarded-Proto")+"://"+r.Host, r.PathValue("name"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := shard.XML()
+		w.Write(data)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := sitemap.Mirror(context.Background(), srv.Client(), srv.URL, baseURL, storage.NewLocalStorage(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"sitemap.xml", "sitemaps/products-1.xml", "sitemaps/products-2.xml"} {
+		plain, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// <loc> là địa chỉ public chứ không phải địa chỉ của API
+		if !strings.Contains(string(plain), baseURL+"/") || strings.Contains(string(plain), srv.URL) {
+			t.Errorf("%s has wrong locations:\n%s", name, plain)
+		}
+
+		file, err := os.Open(filepath.Join(dir, name+".gz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		r, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unzipped, err := io.ReadAll(r); err != nil || string(unzipped) != string(plain) {
+			t.Errorf("%s.gz does not match %s: %v", name, name, err)
+		}
+	}
+}
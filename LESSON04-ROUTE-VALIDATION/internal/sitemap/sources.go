@@ -0,0 +1,78 @@
+package sitemap
+
+import (
+	"context"
+	"strings"
+
+	"mamba.com/route-group/internal/repository"
+)
+
+func ProductSource(repo repository.ProductRepository) Source {
+	return func(ctx context.Context, baseURL string) []URL {
+		var urls []URL
+		for _, product := range repo.List(ctx) {
+			if !product.Display {
+				continue
+			}
+
+			u := URL{
+				Loc:     baseURL + "/api/v1/products/" + product.Slug,
+				LastMod: product.UpdatedAt,
+			}
+			if link := product.ProductImage.ImageLink; link != "" {
+				u.Images = append(u.Images, absoluteImageURL(baseURL, link))
+			}
+
+			urls = append(urls, u)
+		}
+		return urls
+	}
+}
+
+func CategorySource(repo repository.CategoryRepository) Source {
+	return func(ctx context.Context, baseURL string) []URL {
+		var urls []URL
+		for _, category := range repo.List(ctx) {
+			urls = append(urls, URL{
+				Loc:     baseURL + "/api/v1/categories/" + category.Slug,
+				LastMod: category.UpdatedAt,
+			})
+		}
+		return urls
+	}
+}
+
+func NewsSource(repo repository.NewsRepository) Source {
+	return func(ctx context.Context, baseURL string) []URL {
+		var urls []URL
+		for _, news := range repo.ListPublished(ctx, "", 0) {
+			u := URL{
+				Loc:     baseURL + "/api/v1/news/" + news.Slug,
+				LastMod: news.UpdatedAt,
+			}
+			for _, image := range news.Images {
+				u.Images = append(u.Images, absoluteImageURL(baseURL, image.Filename))
+			}
+
+			urls = append(urls, u)
+		}
+		return urls
+	}
+}
+
+func absoluteImageURL(baseURL, link string) string {
+	if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+		return link
+	}
+	return baseURL + "/uploads/" + strings.TrimPrefix(link, "/")
+}
+
+// NewSiteGenerator đăng ký đủ 3 loại nội dung public của site
+func NewSiteGenerator(products repository.ProductRepository, categories repository.CategoryRepository, news repository.NewsRepository) *Generator {
+	g := NewGenerator()
+	g.Register("products", ProductSource(products))
+	g.Register("categories", CategorySource(categories))
+	g.Register("news", NewsSource(news))
+
+	return g
+}
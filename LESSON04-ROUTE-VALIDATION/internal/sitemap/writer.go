@@ -0,0 +1,87 @@
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"mamba.com/route-group/internal/storage"
+)
+
+// Mirror tải sitemap index và các shard từ API đang chạy ở apiURL rồi ghi vào storage
+// (kèm bản .gz): sitemap.xml ở gốc, shard trong thư mục sitemaps/ để khớp với ShardURL. Dữ liệu chỉ nằm trong process của API nên CLI
+// không tự dựng Generator được. Host và X-Forwarded-Proto lấy theo baseURL để <loc>
+// trong file là địa chỉ public chứ không phải địa chỉ nội bộ của API.
+func Mirror(ctx context.Context, client *http.Client, apiURL, baseURL string, store storage.Storage) error {
+	public, err := url.Parse(baseURL)
+	if err != nil || public.Host == "" {
+		return fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	index, err := fetch(ctx, client, apiURL+"/sitemap.xml", public)
+	if err != nil {
+		return err
+	}
+
+	var parsed sitemapIndex
+	if err := xml.Unmarshal(index, &parsed); err != nil {
+		return fmt.Errorf("parse sitemap index: %w", err)
+	}
+
+	for _, item := range parsed.Sitemaps {
+		name, ok := strings.CutPrefix(item.Loc, baseURL+"/sitemaps/")
+		if !ok || name == "" || path.Base(name) != name {
+			return fmt.Errorf("unexpected sitemap location %q", item.Loc)
+		}
+
+		shard, err := fetch(ctx, client, apiURL+"/sitemaps/"+name, public)
+		if err != nil {
+			return err
+		}
+		if err := putWithGzip(ctx, store, "sitemaps/"+name, shard); err != nil {
+			return err
+		}
+	}
+
+	// Index ghi sau cùng để không trỏ tới shard chưa có trong storage
+	return putWithGzip(ctx, store, "sitemap.xml", index)
+}
+
+func fetch(ctx context.Context, client *http.Client, target string, public *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = public.Host
+	req.Header.Set("X-Forwarded-Proto", public.Scheme)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func putWithGzip(ctx context.Context, store storage.Storage, name string, data []byte) error {
+	if err := store.Put(ctx, name, bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	gz, err := Gzip(data)
+	if err != nil {
+		return err
+	}
+
+	return store.Put(ctx, name+".gz", bytes.NewReader(gz))
+}
@@ -0,0 +1,217 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// utf8BOM giúp Excel nhận đúng UTF-8 (tiếng Việt) khi mở file CSV
+const utf8BOM = "\ufeff"
+
+// ContentType trả về media type của format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FormatOf lấy format từ đuôi file, VD: "products.xlsx" => xlsx
+func FormatOf(filename string) (string, error) {
+	switch format := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); format {
+	case FormatCSV, FormatXLSX:
+		return format, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Writer ghi bảng từng dòng, Close phải được gọi để ghi nốt dữ liệu còn trong buffer
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+// NewWriter: CSV được ghi thẳng ra w theo từng dòng, XLSX là file zip
+// nên chỉ được ghi ra w khi Close
+func NewWriter(w io.Writer, format, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// csvFlushRows: số dòng giữa 2 lần flush, để client nhận dữ liệu dần thay vì đợi cả file
+const csvFlushRows = 100
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (c *csvWriter) Write(row []any) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		if s, ok := value.(string); ok {
+			record[i] = escapeFormula(s)
+			continue
+		}
+		record[i] = fmt.Sprint(value)
+	}
+
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%csvFlushRows == 0 {
+		c.w.Flush()
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxWriter) Write(row []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, row)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
+
+// Read đọc toàn bộ sheet đầu tiên, mọi ô là chuỗi
+func Read(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		return readXLSX(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], utf8BOM)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			row[i] = unescapeFormula(value)
+		}
+	}
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil
+	}
+	// Giá trị gốc của ô, không theo định dạng hiển thị (VD: 200000 thay vì 200,000)
+	return file.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+// escapeFormula chặn CSV injection: ô bắt đầu bằng = + - @ sẽ bị Excel chạy như công thức.
+// XLSX không cần vì ô chuỗi được lưu đúng kiểu chuỗi.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func unescapeFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// Bytes tiện cho bản ghi nhỏ (VD: báo cáo lỗi) cần ghi ra []byte
+func Bytes(format, sheet string, rows [][]any) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, sheet)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
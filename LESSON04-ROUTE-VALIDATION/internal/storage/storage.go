@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mamba.com/route-group/internal/tracing"
+)
+
+var ErrInvalidName = errors.New("invalid file name")
+
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// LocalStorage lưu file trên ổ đĩa, mọi name đều nằm trong Root
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+func (s *LocalStorage) Put(ctx context.Context, name string, r io.Reader) (err error) {
+	_, span := tracing.Start(ctx, "Storage.Put", tracing.String("storage.name", name))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Ghi vào file tạm rồi rename để người đọc không thấy file ghi dở
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStorage) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	_, span := tracing.Start(ctx, "Storage.Open", tracing.String("storage.name", name))
+	defer span.End()
+
+	path, err := s.path(name)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	span.RecordError(err)
+	return file, err
+}
+
+func (s *LocalStorage) path(name string) (string, error) {
+	clean := filepath.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "\x00") {
+		return "", ErrInvalidName
+	}
+
+	return filepath.Join(s.Root, clean), nil
+}
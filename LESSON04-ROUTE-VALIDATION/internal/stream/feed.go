@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/models"
+)
+
+const EventPriceChanged = "product.price_changed"
+
+// PriceChange là data của message product.price_changed.
+// PreviousPrice rỗng khi feed chưa biết giá cũ của sản phẩm.
+type PriceChange struct {
+	ID            int       `json:"id"`
+	Slug          string    `json:"slug"`
+	Name          string    `json:"name"`
+	Price         int       `json:"price"`
+	PreviousPrice *int      `json:"previous_price"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PublishedNews là data của message news.published, client gọi API tin tức để lấy nội dung
+type PublishedNews struct {
+	ID          int       `json:"id"`
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	Category    string    `json:"category"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Feed là consumer của event bus, chuyển domain event thành message cho Hub.
+// Event product.updated chứa cả sản phẩm nên feed tự nhớ giá cũ để chỉ phát khi giá đổi.
+type Feed struct {
+	hub *Hub
+
+	mu     sync.Mutex
+	prices map[int]int
+}
+
+// NewFeed nhận danh sách sản phẩm hiện có làm giá ban đầu
+func NewFeed(hub *Hub, products []models.Product) *Feed {
+	f := &Feed{hub: hub, prices: make(map[int]int, len(products))}
+	for _, product := range products {
+		f.prices[product.ID] = product.Price
+	}
+	return f
+}
+
+// Handle là events.Handler. Hub đã đóng (server đang tắt) thì message bị bỏ qua
+// vì không còn client nào nhận.
+func (f *Feed) Handle(ctx context.Context, event events.Event) error {
+	switch event.Type {
+	case events.ProductCreated, events.ProductUpdated:
+		var product models.Product
+		if err := json.Unmarshal(event.Data, &product); err != nil {
+			return err
+		}
+		change, changed := f.trackPrice(product)
+		// Sản phẩm mới tạo chưa có giá cũ để so, sản phẩm ẩn thì không công khai giá
+		if !changed || event.Type == events.ProductCreated || !product.Display {
+			return nil
+		}
+		return ignoreClosed(f.hub.Publish(TopicProducts, EventPriceChanged, change))
+
+	case events.ProductDeleted:
+		var product models.Product
+		if err := json.Unmarshal(event.Data, &product); err != nil {
+			return err
+		}
+		f.mu.Lock()
+		delete(f.prices, product.ID)
+		f.mu.Unlock()
+
+	case events.NewsPublished:
+		var news models.News
+		if err := json.Unmarshal(event.Data, &news); err != nil {
+			return err
+		}
+		return ignoreClosed(f.hub.Publish(TopicNews, events.NewsPublished, PublishedNews{
+			ID:          news.ID,
+			Slug:        news.Slug,
+			Title:       news.Title,
+			Category:    news.Category,
+			PublishedAt: news.PublishedAt,
+		}))
+	}
+	return nil
+}
+
+// trackPrice ghi giá mới, trả về false khi giá không đổi
+func (f *Feed) trackPrice(product models.Product) (PriceChange, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	previous, known := f.prices[product.ID]
+	f.prices[product.ID] = product.Price
+	if known && previous == product.Price {
+		return PriceChange{}, false
+	}
+
+	change := PriceChange{
+		ID:        product.ID,
+		Slug:      product.Slug,
+		Name:      product.Name,
+		Price:     product.Price,
+		UpdatedAt: product.UpdatedAt,
+	}
+	if known {
+		change.PreviousPrice = &previous
+	}
+	return change, true
+}
+
+func ignoreClosed(err error) error {
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
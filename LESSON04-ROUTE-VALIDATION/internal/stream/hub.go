@@ -0,0 +1,257 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mamba.com/route-group/internal/metrics"
+)
+
+const (
+	TopicProducts = "products"
+	TopicNews     = "news"
+)
+
+// AllTopics là các topic client có thể đăng ký
+var AllTopics = []string{TopicProducts, TopicNews}
+
+var (
+	// ErrSlowConsumer: client không nhận kịp nên buffer đầy, subscription bị huỷ.
+	// Client kết nối lại với Last-Event-ID để nhận tiếp từ ring buffer.
+	ErrSlowConsumer = errors.New("stream: slow consumer")
+	ErrClosed       = errors.New("stream: hub closed")
+)
+
+type Options struct {
+	// History: số message gần nhất giữ lại để client resume bằng Last-Event-ID
+	History int
+	// ClientBuffer: số message chờ gửi tối đa của 1 client, đầy thì client bị ngắt
+	ClientBuffer int
+	// Heartbeat: chu kỳ gửi comment (SSE) / ping (WebSocket) để proxy không đóng kết nối rảnh
+	Heartbeat time.Duration
+	// WriteTimeout: hạn ghi 1 message, client không đọc thì kết nối bị đóng
+	WriteTimeout time.Duration
+}
+
+// Message là 1 cập nhật được đẩy cho client. ID có dạng <epoch>-<seq>: epoch đổi mỗi lần
+// khởi động nên ID của process cũ không bị nhầm với message mới.
+type Message struct {
+	ID    string          `json:"id"`
+	Topic string          `json:"topic"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+
+	seq uint64
+}
+
+// Hub phát message cho các subscription đang mở và giữ History message gần nhất
+// trong ring buffer. Publish không bao giờ chờ client: client có buffer đầy bị ngắt.
+type Hub struct {
+	opts  Options
+	epoch string
+
+	mu      sync.Mutex
+	seq     uint64
+	history []Message
+	// head là vị trí message cũ nhất khi history đã đầy
+	head   int
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub(opts Options) *Hub {
+	return &Hub{
+		opts:    opts,
+		epoch:   strconv.FormatInt(time.Now().UnixMilli(), 36),
+		history: make([]Message, 0, opts.History),
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription nhận message của các topic đã đăng ký.
+// Backlog là message client bỏ lỡ kể từ Last-Event-ID, cần gửi trước message mới.
+// Reset báo Last-Event-ID không còn trong ring buffer (hoặc của process cũ):
+// client phải tải lại dữ liệu thay vì chờ bù.
+type Subscription struct {
+	Backlog []Message
+	Reset   bool
+
+	hub    *Hub
+	ch     chan Message
+	topics map[string]bool
+	err    error
+}
+
+// Subscribe mở subscription cho topics, resume sau lastEventID nếu có.
+// Backlog được lấy cùng lúc đăng ký nên không bị hụt hay trùng message.
+func (h *Hub) Subscribe(topics []string, lastEventID string) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		ch:     make(chan Message, h.opts.ClientBuffer),
+		topics: make(map[string]bool, len(topics)),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+
+	if lastEventID != "" {
+		backlog, ok := h.since(lastEventID)
+		sub.Reset = !ok
+		for _, msg := range backlog {
+			if sub.topics[msg.Topic] {
+				sub.Backlog = append(sub.Backlog, msg)
+			}
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// since trả về message sau id theo thứ tự phát. false khi id không thuộc process này
+// hoặc đã bị đẩy khỏi ring buffer.
+func (h *Hub) since(id string) ([]Message, bool) {
+	epoch, rawSeq, found := strings.Cut(id, "-")
+	if !found || epoch != h.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil || seq > h.seq {
+		return nil, false
+	}
+	if seq == h.seq {
+		return nil, true
+	}
+
+	ordered := append(slices.Clone(h.history[h.head:]), h.history[:h.head]...)
+	if len(ordered) == 0 || ordered[0].seq > seq+1 {
+		return nil, false
+	}
+	return ordered[seq+1-ordered[0].seq:], true
+}
+
+// Publish phát message cho subscription của topic và lưu vào ring buffer
+func (h *Hub) Publish(topic, event string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrClosed
+	}
+
+	h.seq++
+	msg := Message{
+		ID:    h.epoch + "-" + strconv.FormatUint(h.seq, 10),
+		Topic: topic,
+		Event: event,
+		Data:  raw,
+		seq:   h.seq,
+	}
+	if len(h.history) < h.opts.History {
+		h.history = append(h.history, msg)
+	} else if h.opts.History > 0 {
+		h.history[h.head] = msg
+		h.head = (h.head + 1) % h.opts.History
+	}
+	metrics.StreamMessages.Inc(topic)
+
+	for sub := range h.subs {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			h.remove(sub, ErrSlowConsumer)
+		}
+	}
+	return nil
+}
+
+// Close ngắt mọi subscription, gọi khi server bắt đầu tắt để stream không giữ shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub, ErrClosed)
+	}
+}
+
+// remove phải được gọi khi đang giữ h.mu
+func (h *Hub) remove(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.ch)
+}
+
+// Messages bị đóng khi subscription kết thúc, Err cho biết lý do
+func (s *Subscription) Messages() <-chan Message {
+	return s.ch
+}
+
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Topics trả về các topic đang đăng ký, đã sắp xếp
+func (s *Subscription) Topics() []string {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	slices.Sort(topics)
+	return topics
+}
+
+// Add / Remove đổi topic của subscription đang mở, chỉ áp dụng cho message phát sau đó
+func (s *Subscription) Add(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+}
+
+func (s *Subscription) Remove(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+}
+
+// Close huỷ subscription, gọi nhiều lần không sao
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, ErrClosed)
+}
@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/utils"
+)
+
+// retryHint là thời gian EventSource chờ trước khi tự kết nối lại
+const retryHint = 3 * time.Second
+
+// EventReset báo client Last-Event-ID không resume được, cần tải lại dữ liệu
+const EventReset = "reset"
+
+type GetStreamParam struct {
+	Topics []string `form:"topics" binding:"omitempty,dive,oneof=products news"`
+	// LastEventID cho client không đặt được header Last-Event-ID khi kết nối lần đầu
+	LastEventID string `form:"last_event_id" binding:"omitempty,max=64"`
+}
+
+// GetStream đẩy message qua Server-Sent Events, VD: ?topics=products&topics=news, mặc định mọi topic.
+// Client bị ngắt vì chậm sẽ tự kết nối lại với Last-Event-ID và nhận bù từ ring buffer.
+func (h *Hub) GetStream(ctx *gin.Context) {
+	var params GetStreamParam
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+	if len(params.Topics) == 0 {
+		params.Topics = AllTopics
+	}
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = params.LastEventID
+	}
+
+	sub, err := h.Subscribe(params.Topics, lastEventID)
+	if err != nil {
+		utils.Render(ctx, http.StatusServiceUnavailable, gin.H{"error": "Stream is shutting down"})
+		return
+	}
+	defer sub.Close()
+
+	metrics.StreamClients.Inc("sse")
+	defer metrics.StreamClients.Dec("sse")
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	w := &sseWriter{w: ctx.Writer, rc: http.NewResponseController(ctx.Writer), timeout: h.opts.WriteTimeout}
+	opening := []sse.Event{}
+	if sub.Reset {
+		opening = append(opening, sse.Event{Event: EventReset, Data: "{}"})
+	}
+	for _, msg := range sub.Backlog {
+		opening = append(opening, event(msg))
+	}
+	if err := w.send(opening...); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			err = w.send()
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				if errors.Is(sub.Err(), ErrSlowConsumer) {
+					metrics.StreamDropped.Inc("sse")
+					logging.From(ctx).Warn("stream client dropped: slow consumer")
+				}
+				return
+			}
+			err = w.send(event(msg))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func event(msg Message) sse.Event {
+	return sse.Event{Id: msg.ID, Event: msg.Event, Data: []byte(msg.Data)}
+}
+
+// sseWriter gia hạn write deadline trước mỗi lần ghi: stream sống lâu hơn WriteTimeout
+// của server, nhưng client ngừng đọc vẫn phải bị ngắt.
+type sseWriter struct {
+	w       gin.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+// send ghi các event rồi flush ngay. Không có event thì ghi comment để giữ kết nối,
+// lần ghi đầu tiên kèm retry hint cho EventSource.
+func (s *sseWriter) send(events ...sse.Event) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if !s.w.Written() {
+		if _, err := fmt.Fprintf(s.w, "retry: %d\n\n", retryHint.Milliseconds()); err != nil {
+			return err
+		}
+	}
+	if len(events) == 0 {
+		if _, err := s.w.WriteString(": ping\n\n"); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if err := sse.Encode(s.w, e); err != nil {
+			return err
+		}
+	}
+	return s.rc.Flush()
+}
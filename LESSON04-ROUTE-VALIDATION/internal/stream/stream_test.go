@@ -0,0 +1,324 @@
+package stream_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/stream"
+	"mamba.com/route-group/utils"
+)
+
+const apiKey = "stream-key"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := utils.RegisterValidators(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// syncBuffer nhận access log từ handler của middleware
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newServer(t *testing.T, opts stream.Options, keys []string) (*httptest.Server, *stream.Hub, *syncBuffer) {
+	t.Helper()
+	if opts.Heartbeat == 0 {
+		opts.Heartbeat = time.Hour
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = time.Second
+	}
+
+	hub := stream.NewHub(opts)
+	logs := &syncBuffer{}
+	r := gin.New()
+	r.Use(logging.Middleware(slog.New(slog.NewTextHandler(logs, nil))))
+	r.GET("/stream", hub.GetStream)
+	r.GET("/stream/ws", stream.RequireAPIKey(keys), hub.GetStreamWS)
+
+	srv := httptest.NewServer(r)
+	// Hub.Close ngắt stream đang mở để srv.Close không phải chờ
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+	return srv, hub, logs
+}
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE đọc n event, bỏ qua retry hint và comment
+func readSSE(t *testing.T, r *bufio.Reader, n int) []sseEvent {
+	t.Helper()
+
+	var result []sseEvent
+	var current sseEvent
+	for len(result) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event %d: %v", len(result)+1, err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if current != (sseEvent{}) {
+				result = append(result, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id:"):
+			current.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "event:"):
+			current.event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			current.data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	return result
+}
+
+func openSSE(t *testing.T, url, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestSSEResume(t *testing.T) {
+	srv, hub, _ := newServer(t, stream.Options{History: 3, ClientBuffer: 10}, nil)
+
+	// Header được gửi sau khi đã subscribe nên message publish sau đó không bị hụt
+	first, r := openSSE(t, srv.URL+"/stream?topics=products", "")
+	for i := 1; i <= 5; i++ {
+		if err := hub.Publish(stream.TopicProducts, stream.EventPriceChanged, map[string]int{"price": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hub.Publish(stream.TopicNews, "news.published", map[string]int{"id": 1})
+
+	live := readSSE(t, r, 5)
+	for i, e := range live {
+		if e.event != stream.EventPriceChanged || e.data != fmt.Sprintf(`{"price":%d}`, i+1) {
+			t.Errorf("event %d = %+v", i+1, e)
+		}
+	}
+	first.Body.Close()
+
+	ids := func(events []sseEvent) []string {
+		var result []string
+		for _, e := range events {
+			result = append(result, e.id)
+		}
+		return result
+	}
+
+	// Event 3 vẫn trong ring buffer: nhận bù 4, 5 (tin tức không thuộc topic nên bị lọc)
+	_, r = openSSE(t, srv.URL+"/stream?topics=products", live[2].id)
+	if got, want := ids(readSSE(t, r, 2)), ids(live[3:]); !slices.Equal(got, want) {
+		t.Errorf("resumed ids = %v, want %v", got, want)
+	}
+
+	// Event 1 đã bị đẩy khỏi ring buffer: client nhận reset
+	_, r = openSSE(t, srv.URL+"/stream?topics=products", live[0].id)
+	if got := readSSE(t, r, 1)[0]; got.event != stream.EventReset {
+		t.Errorf("first event after evicted id = %+v, want reset", got)
+	}
+
+	// Query last_event_id dùng khi không đặt được header
+	_, r = openSSE(t, srv.URL+"/stream?topics=products&last_event_id="+live[3].id, "")
+	hub.Publish(stream.TopicProducts, stream.EventPriceChanged, map[string]int{"price": 6})
+	got := readSSE(t, r, 2)
+	if got[0].id != live[4].id || got[1].data != `{"price":6}` {
+		t.Errorf("events after last_event_id = %+v", got)
+	}
+}
+
+func dial(t *testing.T, srv *httptest.Server, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/stream/ws"+query, header)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+type wsFrame struct {
+	Type   string          `json:"type"`
+	Topic  string          `json:"topic"`
+	Data   json.RawMessage `json:"data"`
+	Topics []string        `json:"topics"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) wsFrame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var f wsFrame
+	if err := conn.ReadJSON(&f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestWSSubscribe(t *testing.T) {
+	srv, hub, _ := newServer(t, stream.Options{History: 10, ClientBuffer: 10}, []string{apiKey})
+	conn, _, err := dial(t, srv, "", http.Header{"X-API-Key": {apiKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		send  string
+		check func(wsFrame) bool
+	}{
+		{`{"type":"subscribe","topics":["news"]}`, func(f wsFrame) bool {
+			return f.Type == "subscribed" && slices.Equal(f.Topics, []string{"news"})
+		}},
+		{`{"type":"subscribe","topics":["weather"]}`, func(f wsFrame) bool { return f.Type == "error" && len(f.Error) > 0 }},
+		{`not json`, func(f wsFrame) bool { return f.Type == "error" && string(f.Error) == `"Message must be a JSON object"` }},
+	}
+	for i, step := range steps {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(step.send)); err != nil {
+			t.Fatal(err)
+		}
+		if f := readFrame(t, conn); !step.check(f) {
+			t.Errorf("step %d: unexpected frame %+v", i, f)
+		}
+	}
+
+	// Chỉ nhận message của topic đã đăng ký
+	hub.Publish(stream.TopicProducts, stream.EventPriceChanged, map[string]int{"price": 1})
+	hub.Publish(stream.TopicNews, "news.published", map[string]int{"id": 7})
+	if f := readFrame(t, conn); f.Type != "event" || f.Topic != stream.TopicNews || string(f.Data) != `{"id":7}` {
+		t.Errorf("event frame = %+v", f)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"unsubscribe","topics":["news"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if f := readFrame(t, conn); f.Type != "subscribed" || f.Topics == nil || len(f.Topics) != 0 {
+		t.Errorf("unsubscribe frame = %+v, want empty topics", f)
+	}
+	hub.Publish(stream.TopicNews, "news.published", map[string]int{"id": 8})
+	hub.Publish(stream.TopicProducts, stream.EventPriceChanged, map[string]int{"price": 2})
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, data, err := conn.ReadMessage(); err == nil {
+		t.Errorf("received %s after unsubscribing", data)
+	}
+}
+
+func TestWSSlowClient(t *testing.T) {
+	srv, hub, _ := newServer(t, stream.Options{History: 10, ClientBuffer: 1}, []string{apiKey})
+	conn, _, err := dial(t, srv, "?topics=products&api_key="+apiKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish không chờ client: buffer 1 message đầy ngay trong vòng lặp này
+	for i := range 10000 {
+		hub.Publish(stream.TopicProducts, stream.EventPriceChanged, map[string]int{"price": i})
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+			t.Fatalf("err = %v, want close 1013", err)
+		}
+		break
+	}
+}
+
+func TestWSAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []string
+		query  string
+		header http.Header
+		status int
+	}{
+		{"no keys configured", nil, "?api_key=anything", nil, http.StatusForbidden},
+		{"missing key", []string{apiKey}, "", nil, http.StatusUnauthorized},
+		{"wrong key", []string{apiKey}, "?api_key=leaked-secret", nil, http.StatusUnauthorized},
+		{"header key", []string{apiKey}, "", http.Header{"X-API-Key": {apiKey}}, http.StatusSwitchingProtocols},
+		{"query key", []string{"other", apiKey}, "?api_key=" + apiKey, nil, http.StatusSwitchingProtocols},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, logs := newServer(t, stream.Options{History: 1, ClientBuffer: 1}, tt.keys)
+			conn, resp, err := dial(t, srv, tt.query, tt.header)
+			if resp == nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if conn != nil {
+				conn.Close()
+			}
+
+			waitFor(t, func() bool { return strings.Contains(logs.String(), "msg=request") })
+			if access := logs.String(); strings.Contains(access, "leaked-secret") || strings.Contains(access, apiKey) {
+				t.Errorf("API key in access log: %s", access)
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
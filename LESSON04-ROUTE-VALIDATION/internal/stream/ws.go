@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/ratelimit"
+	"mamba.com/route-group/utils"
+)
+
+// Kích thước tối đa của 1 message client gửi lên (subscribe / unsubscribe)
+const maxClientMessage = 4 << 10
+
+// Kiểu frame server gửi xuống, "event" là message của Hub
+const (
+	frameEvent      = "event"
+	frameReset      = "reset"
+	frameSubscribed = "subscribed"
+	frameError      = "error"
+)
+
+// Upgrader mặc định chỉ nhận Origin trùng host, client không phải trình duyệt không gửi Origin
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096}
+
+// ClientMessage là message client gửi để đổi topic, VD: {"type":"subscribe","topics":["news"]}
+type ClientMessage struct {
+	Type   string   `json:"type" binding:"required,oneof=subscribe unsubscribe"`
+	Topics []string `json:"topics" binding:"required,gt=0,dive,oneof=products news"`
+}
+
+type frame struct {
+	Type string `json:"type"`
+	*Message
+	// Topics là con trỏ để frame subscribed vẫn có "topics": [] khi client đã bỏ hết topic
+	Topics *[]string `json:"topics,omitempty"`
+	Error  any       `json:"error,omitempty"`
+}
+
+// RequireAPIKey chỉ cho client có API key trong keys, qua header X-API-Key hoặc query api_key
+// (WebSocket của trình duyệt không đặt được header). keys rỗng thì route bị tắt (403).
+// api_key được bỏ khỏi URL sau khi kiểm tra để không lọt vào log (VD: request line gin.Recovery in ra).
+func RequireAPIKey(keys []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(keys) == 0 {
+			utils.Render(ctx, http.StatusForbidden, gin.H{"error": "WebSocket stream is disabled because no API keys are configured"})
+			ctx.Abort()
+			return
+		}
+
+		apiKey := ctx.GetHeader(ratelimit.HeaderAPIKey)
+		if apiKey == "" {
+			apiKey = ctx.Query("api_key")
+		}
+		for _, key := range keys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+				if query := ctx.Request.URL.Query(); query.Has("api_key") {
+					query.Del("api_key")
+					ctx.Request.URL.RawQuery = query.Encode()
+				}
+				ctx.Next()
+				return
+			}
+		}
+
+		utils.Render(ctx, http.StatusUnauthorized, gin.H{"error": "Invalid or missing API key"})
+		ctx.Abort()
+	}
+}
+
+// GetStreamWS đẩy message qua WebSocket. Topic ban đầu lấy từ query topics (mặc định không có),
+// sau đó client gửi ClientMessage để subscribe / unsubscribe.
+// Client đọc chậm đến mức buffer đầy bị đóng với mã 1013 (try again later).
+func (h *Hub) GetStreamWS(ctx *gin.Context) {
+	var params GetStreamParam
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		utils.RenderValidationError(ctx, err)
+		return
+	}
+
+	sub, err := h.Subscribe(params.Topics, params.LastEventID)
+	if err != nil {
+		utils.Render(ctx, http.StatusServiceUnavailable, gin.H{"error": "Stream is shutting down"})
+		return
+	}
+	defer sub.Close()
+
+	// Upgrade lỗi thì upgrader đã trả response 4xx
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	metrics.StreamClients.Inc("websocket")
+	defer metrics.StreamClients.Dec("websocket")
+
+	replies := make(chan frame, 4)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go h.readClient(conn, sub, replies, closed, done)
+
+	send := func(f frame) error {
+		data, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		conn.SetWriteDeadline(time.Now().Add(h.opts.WriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+	closeWith := func(code int, reason string) {
+		deadline := time.Now().Add(h.opts.WriteTimeout)
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	}
+
+	if sub.Reset {
+		if err := send(frame{Type: frameReset}); err != nil {
+			return
+		}
+	}
+	for _, msg := range sub.Backlog {
+		if err := send(frame{Type: frameEvent, Message: &msg}); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(h.opts.Heartbeat)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case reply := <-replies:
+			err = send(reply)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.opts.WriteTimeout))
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				if errors.Is(sub.Err(), ErrSlowConsumer) {
+					metrics.StreamDropped.Inc("websocket")
+					logging.From(ctx).Warn("stream client dropped: slow consumer")
+					closeWith(websocket.CloseTryAgainLater, "slow consumer")
+				} else {
+					closeWith(websocket.CloseGoingAway, "server shutting down")
+				}
+				return
+			}
+			err = send(frame{Type: frameEvent, Message: &msg})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readClient đọc message của client đến khi kết nối đóng. Client phải trả lời ping
+// trong 2 chu kỳ heartbeat, nếu không kết nối bị coi là đã chết.
+// Chỉ goroutine của GetStreamWS được ghi vào conn nên phản hồi đi qua replies.
+func (h *Hub) readClient(conn *websocket.Conn, sub *Subscription, replies chan<- frame, closed chan<- struct{}, done <-chan struct{}) {
+	defer close(closed)
+
+	reply := func(f frame) bool {
+		select {
+		case replies <- f:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	wait := 2 * h.opts.Heartbeat
+	conn.SetReadLimit(maxClientMessage)
+	conn.SetReadDeadline(time.Now().Add(wait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wait))
+
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			if !reply(frame{Type: frameError, Error: "Message must be a JSON object"}) {
+				return
+			}
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&msg); err != nil {
+			if !reply(frame{Type: frameError, Error: utils.HandleValidationError(err)["error"]}) {
+				return
+			}
+			continue
+		}
+
+		if msg.Type == "subscribe" {
+			sub.Add(msg.Topics...)
+		} else {
+			sub.Remove(msg.Topics...)
+		}
+		topics := sub.Topics()
+		if !reply(frame{Type: frameSubscribed, Topics: &topics}) {
+			return
+		}
+	}
+}
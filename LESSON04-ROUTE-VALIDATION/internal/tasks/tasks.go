@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/media"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/storage"
+)
+
+const (
+	// QueueMedia tách xử lý ảnh (tốn CPU) khỏi queue mặc định
+	QueueMedia = "media"
+
+	TypeImageDerivatives = "image.derivatives"
+	TypeNewsPublishDue   = "news.publish_due"
+)
+
+// ImageDerivativesPayload: tạo ảnh thu nhỏ cho ảnh Filename của tin NewsID
+type ImageDerivativesPayload struct {
+	NewsID   int    `json:"news_id"`
+	Filename string `json:"filename"`
+}
+
+// NewsPublishDuePayload: publish các tin nháp đã đến giờ hẹn, chạy theo lịch cron
+type NewsPublishDuePayload struct{}
+
+// Deps là dữ liệu mà handler của các job type cần
+type Deps struct {
+	News    repository.NewsRepository
+	Uploads storage.Storage
+}
+
+func Register(m *jobs.Manager, deps Deps) {
+	jobs.Register(m, TypeImageDerivatives, jobs.TypeOptions{Queue: QueueMedia, Timeout: 2 * time.Minute},
+		func(ctx context.Context, payload ImageDerivativesPayload) error {
+			derivatives, err := media.Derivatives(ctx, deps.Uploads, payload.Filename, media.DefaultWidths)
+			if errors.Is(err, media.ErrUnsupportedImage) {
+				return jobs.Permanent(err)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = deps.News.SetImageDerivatives(ctx, payload.NewsID, payload.Filename, derivatives)
+			if errors.Is(err, repository.ErrNotFound) {
+				return jobs.Permanent(err)
+			}
+			return err
+		})
+
+	jobs.Register(m, TypeNewsPublishDue, jobs.TypeOptions{MaxAttempts: 1},
+		func(ctx context.Context, _ NewsPublishDuePayload) error {
+			published, err := deps.News.PublishDue(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			for _, news := range published {
+				logging.FromContext(ctx).Info("scheduled news published",
+					slog.Int("news_id", news.ID),
+					slog.String("slug", news.Slug),
+					slog.Time("published_at", news.PublishedAt),
+				)
+			}
+			return nil
+		})
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type Exporter = sdktrace.SpanExporter
+
+// NewWriterExporter ghi mỗi span 1 dòng JSON, dùng cho stdout hoặc file khi chạy local
+func NewWriterExporter(w io.Writer) (Exporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w))
+}
+
+// NewFileExporter ghi nối vào cuối file, file được đóng khi Shutdown
+func NewFileExporter(path string) (Exporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := NewWriterExporter(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileExporter{SpanExporter: exporter, file: file}, nil
+}
+
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.SpanExporter.Shutdown(ctx), e.file.Close())
+}
+
+// NewOTLPExporter gửi span qua OTLP/HTTP (protobuf) tới <endpoint>/v1/traces,
+// dùng được với OpenTelemetry Collector, Jaeger, Tempo...
+func NewOTLPExporter(ctx context.Context, endpoint string, headers map[string]string) (Exporter, error) {
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimRight(endpoint, "/")+"/v1/traces"),
+		otlptracehttp.WithHeaders(headers),
+	)
+}
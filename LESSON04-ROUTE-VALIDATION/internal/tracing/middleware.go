@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const HeaderTraceID = "X-Trace-ID"
+
+type middlewareKey struct{}
+
+// Middleware tạo span server cho mỗi request, nối vào trace của client nếu có traceparent.
+// Tên span là "METHOD route template" để gom được trên backend, giống http_requests_total.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Request chuyển tiếp bằng engine.HandleContext đã có span từ lần đầu
+		if ctx.Request.Context().Value(middlewareKey{}) != nil {
+			ctx.Next()
+			return
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		requestCtx, span := otel.Tracer(scope).Start(parent, ctx.Request.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				String("http.request.method", ctx.Request.Method),
+				String("url.path", ctx.Request.URL.Path),
+				String("client.address", ctx.ClientIP()),
+				String("user_agent.original", ctx.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(context.WithValue(requestCtx, middlewareKey{}, true))
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx.Header(HeaderTraceID, sc.TraceID().String())
+		}
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(Int("http.response.status_code", status))
+		if route := ctx.FullPath(); route != "" {
+			span.SetName(ctx.Request.Method + " " + route)
+			span.SetAttributes(String("http.route", route))
+		}
+		if len(ctx.Errors) > 0 {
+			span.RecordError(ctx.Errors.Last())
+		}
+		// Theo semantic conventions, span server chỉ lỗi khi 5xx
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
+
+// Inject ghi traceparent của span trong ctx vào header request gửi đi (VD: webhook)
+// để phía nhận nối tiếp được trace
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceIDFrom trả về trace id của request, rỗng nếu không có span
+func TraceIDFrom(ctx *gin.Context) string {
+	sc := trace.SpanContextFromContext(ctx.Request.Context())
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
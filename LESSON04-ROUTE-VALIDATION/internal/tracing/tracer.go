@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope là tên instrumentation của các span do ứng dụng tạo
+const scope = "mamba.com/route-group"
+
+// FlushInterval là chu kỳ export của batch processor, cũng là chu kỳ gọi Options.Heartbeat
+const FlushInterval = 5 * time.Second
+
+type Options struct {
+	ServiceName string
+	// SampleRatio áp dụng cho trace mới (không có traceparent), 0..1.
+	// Trace đến từ client giữ nguyên quyết định sampled của client.
+	SampleRatio float64
+	// Exporter nil thì span vẫn có ID để ghi log và truyền tiếp nhưng không được export
+	Exporter Exporter
+	// Heartbeat được gọi sau mỗi lần flush, export bị treo thì health check worker báo lỗi
+	Heartbeat func()
+}
+
+// Tracer giữ TracerProvider của OpenTelemetry SDK
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+func NewTracer(opts Options) *Tracer {
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(opts.ServiceName))),
+	}
+	if opts.Exporter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(opts.Exporter, sdktrace.WithBatchTimeout(FlushInterval)))
+	}
+
+	t := &Tracer{provider: sdktrace.NewTracerProvider(providerOpts...), stop: make(chan struct{})}
+	if opts.Exporter != nil && opts.Heartbeat != nil {
+		t.stopped.Go(func() { t.heartbeat(opts.Heartbeat) })
+	}
+	return t
+}
+
+// heartbeat flush theo chu kỳ: ForceFlush chỉ trả về khi exporter xử lý xong lô hiện tại.
+// Export lỗi thì không beat để health check thấy exporter đang hỏng.
+func (t *Tracer) heartbeat(beat func()) {
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 2*FlushInterval)
+			err := t.provider.ForceFlush(ctx)
+			cancel()
+			if err == nil {
+				beat()
+			}
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// SetDefault đăng ký tracer và propagator W3C (traceparent, tracestate, baggage) làm mặc định
+// của OpenTelemetry, thư viện khác dùng otel cũng nối vào cùng trace
+func SetDefault(t *Tracer) {
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Shutdown export nốt các span còn trong hàng đợi rồi đóng exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	close(t.stop)
+	t.stopped.Wait()
+	return t.provider.Shutdown(ctx)
+}
+
+// Span bọc trace.Span, RecordError đánh dấu span lỗi luôn để không phải gọi SetStatus ở mọi nơi
+type Span struct {
+	trace.Span
+}
+
+// RecordError bỏ qua err nil để dùng được trong defer
+func (s Span) RecordError(err error, opts ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	s.Span.RecordError(err, opts...)
+	s.Span.SetStatus(codes.Error, err.Error())
+}
+
+// Start tạo span con của span trong ctx bằng tracer mặc định
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, Span) {
+	ctx, span := otel.Tracer(scope).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, Span{span}
+}
+
+func String(key, value string) attribute.KeyValue        { return attribute.String(key, value) }
+func Int(key string, value int) attribute.KeyValue       { return attribute.Int(key, value) }
+func Int64(key string, value int64) attribute.KeyValue   { return attribute.Int64(key, value) }
+func Bool(key string, value bool) attribute.KeyValue     { return attribute.Bool(key, value) }
+func Float(key string, value float64) attribute.KeyValue { return attribute.Float64(key, value) }
+
+// SpanContextFromContext trả về SpanContext của span trong ctx, không hợp lệ nếu không có span
+func SpanContextFromContext(ctx context.Context) trace.SpanContext {
+	return trace.SpanContextFromContext(ctx)
+}
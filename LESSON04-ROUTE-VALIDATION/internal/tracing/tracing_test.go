@@ -0,0 +1,307 @@
+package tracing_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/tracing"
+	"mamba.com/route-group/utils"
+)
+
+const (
+	clientTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	clientSpanID  = "00f067aa0ba902b7"
+)
+
+// recorder giữ span đã export, khác tracetest.InMemoryExporter là không xoá khi Shutdown
+type recorder struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *recorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *recorder) Shutdown(context.Context) error { return nil }
+
+func (r *recorder) byName(name string) sdktrace.ReadOnlySpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, span := range r.spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// setup dựng tracer mặc định và engine có middleware như main.go,
+// flush gọi Shutdown để batch processor export hết span
+func setup(t *testing.T, sampleRatio float64, logs *bytes.Buffer) (*gin.Engine, *recorder, func()) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	rec := &recorder{}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "test", SampleRatio: sampleRatio, Exporter: rec})
+	tracing.SetDefault(tracer)
+
+	r := gin.New()
+	r.Use(tracing.Middleware(), logging.Middleware(slog.New(slog.NewJSONHandler(logs, nil))))
+	r.GET("/items/:id", func(ctx *gin.Context) {
+		_, span := tracing.Start(ctx.Request.Context(), "ItemRepository.Find", tracing.String("item.id", ctx.Param("id")))
+		span.RecordError(nil)
+		span.End()
+		utils.Render(ctx, http.StatusOK, gin.H{"id": ctx.Param("id")})
+	})
+	r.GET("/broken", func(ctx *gin.Context) {
+		_, span := tracing.Start(ctx.Request.Context(), "Storage.Put")
+		span.RecordError(errors.New("disk full"))
+		span.End()
+		utils.Render(ctx, http.StatusInternalServerError, gin.H{"error": "Cannot save file"})
+	})
+
+	flush := func() {
+		if err := tracer.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, rec, flush
+}
+
+func serve(r *gin.Engine, path, traceparent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if traceparent != "" {
+		req.Header.Set("traceparent", traceparent)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareContinuesClientTrace(t *testing.T) {
+	var logs bytes.Buffer
+	r, rec, flush := setup(t, 0, &logs)
+
+	// SampleRatio 0 chỉ áp dụng cho trace mới, client đã sample (flag 01) thì vẫn ghi
+	w := serve(r, "/items/42", "00-"+clientTraceID+"-"+clientSpanID+"-01")
+	flush()
+
+	if got := w.Header().Get(tracing.HeaderTraceID); got != clientTraceID {
+		t.Errorf("X-Trace-ID = %q, want %q", got, clientTraceID)
+	}
+
+	server := rec.byName("GET /items/:id")
+	if server == nil {
+		t.Fatalf("no server span, got %d spans", len(rec.spans))
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("kind = %v", server.SpanKind())
+	}
+	if server.SpanContext().TraceID().String() != clientTraceID || server.Parent().SpanID().String() != clientSpanID || !server.Parent().IsRemote() {
+		t.Errorf("server span is not a child of the client span: parent %v", server.Parent())
+	}
+	attrs := map[string]string{}
+	for _, kv := range server.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["http.route"] != "/items/:id" || attrs["http.response.status_code"] != "200" || attrs["url.path"] != "/items/42" {
+		t.Errorf("attributes = %v", attrs)
+	}
+	if res := server.Resource(); res == nil || !strings.Contains(res.String(), "service.name=test") {
+		t.Errorf("resource = %v", res)
+	}
+
+	repo := rec.byName("ItemRepository.Find")
+	if repo == nil || repo.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatalf("repository span is not a child of the server span")
+	}
+	if repo.Status().Code != codes.Unset {
+		t.Errorf("RecordError(nil) changed the status: %v", repo.Status())
+	}
+
+	// Log của request có cùng trace id
+	var entry map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(logs.Bytes()), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["trace_id"] != clientTraceID || entry["span_id"] != server.SpanContext().SpanID().String() {
+		t.Errorf("log entry = %v", entry)
+	}
+}
+
+func TestMiddlewareErrorResponse(t *testing.T) {
+	var logs bytes.Buffer
+	r, rec, flush := setup(t, 1, &logs)
+
+	w := serve(r, "/broken", "")
+	flush()
+
+	traceID := w.Header().Get(tracing.HeaderTraceID)
+	if len(traceID) != 32 {
+		t.Fatalf("X-Trace-ID = %q", traceID)
+	}
+
+	// Client báo lỗi kèm trace_id trong body là tìm được trace
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["trace_id"] != traceID {
+		t.Errorf("body trace_id = %v, want %s", body["trace_id"], traceID)
+	}
+	if !strings.Contains(logs.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("log has no trace_id: %s", logs.String())
+	}
+
+	server := rec.byName("GET /broken")
+	if server == nil || server.Status().Code != codes.Error {
+		t.Fatalf("5xx server span should have error status")
+	}
+	storage := rec.byName("Storage.Put")
+	if storage == nil || storage.Status().Code != codes.Error || storage.Status().Description != "disk full" {
+		t.Fatalf("storage span status = %v", storage.Status())
+	}
+	if events := storage.Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("events = %v", events)
+	}
+	if storage.SpanContext().TraceID().String() != traceID {
+		t.Error("storage span is in another trace")
+	}
+}
+
+func TestMiddlewareRespectsUnsampledParent(t *testing.T) {
+	var logs bytes.Buffer
+	r, rec, flush := setup(t, 1, &logs)
+
+	w := serve(r, "/items/1", "00-"+clientTraceID+"-"+clientSpanID+"-00")
+	flush()
+
+	// Không export nhưng trace id vẫn được trả về và ghi log
+	if len(rec.spans) != 0 {
+		t.Errorf("exported %d spans for an unsampled trace", len(rec.spans))
+	}
+	if got := w.Header().Get(tracing.HeaderTraceID); got != clientTraceID {
+		t.Errorf("X-Trace-ID = %q", got)
+	}
+}
+
+func TestMiddlewareIgnoresInvalidTraceparent(t *testing.T) {
+	var logs bytes.Buffer
+	r, rec, flush := setup(t, 1, &logs)
+
+	w := serve(r, "/items/1", "00-00000000000000000000000000000000-"+clientSpanID+"-01")
+	flush()
+
+	got := w.Header().Get(tracing.HeaderTraceID)
+	if got == "" || got == clientTraceID {
+		t.Errorf("X-Trace-ID = %q, want a new trace", got)
+	}
+	if server := rec.byName("GET /items/:id"); server == nil || server.Parent().IsValid() {
+		t.Error("server span should be a root span")
+	}
+}
+
+func TestInject(t *testing.T) {
+	var logs bytes.Buffer
+	_, _, flush := setup(t, 1, &logs)
+	defer flush()
+
+	ctx, span := tracing.Start(context.Background(), "webhook.deliver")
+	defer span.End()
+
+	header := http.Header{}
+	tracing.Inject(ctx, header)
+	sc := span.SpanContext()
+	if want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"; header.Get("traceparent") != want {
+		t.Errorf("traceparent = %q, want %q", header.Get("traceparent"), want)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter, err := tracing.NewFileExporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "test", SampleRatio: 1, Exporter: exporter})
+	tracing.SetDefault(tracer)
+
+	for _, name := range []string{"upload.validate", "upload.save"} {
+		_, span := tracing.Start(context.Background(), name, tracing.Int64("upload.size", 10))
+		span.End()
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span struct{ Name string }
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("line is not JSON: %v", err)
+		}
+		names = append(names, span.Name)
+	}
+	if strings.Join(names, ",") != "upload.validate,upload.save" {
+		t.Errorf("spans = %v", names)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	type request struct {
+		path, contentType, token string
+		size                     int
+	}
+	received := make(chan request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization"), len(body)}
+	}))
+	defer collector.Close()
+
+	exporter, err := tracing.NewOTLPExporter(context.Background(), collector.URL+"/", map[string]string{"Authorization": "Bearer token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "test", SampleRatio: 1, Exporter: exporter})
+	tracing.SetDefault(tracer)
+
+	_, span := tracing.Start(context.Background(), "NewsRepository.Create")
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-received
+	if got.path != "/v1/traces" || got.contentType != "application/x-protobuf" || got.token != "Bearer token" || got.size == 0 {
+		t.Errorf("collector received %+v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package versioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// DefaultConfig: v1 deprecated, client nên chuyển sang v2 trước ngày sunset
+func DefaultConfig() Config {
+	return Config{
+		Default: "v1",
+		Versions: []Version{
+			{
+				Name:         "v1",
+				DeprecatedAt: date(2026, time.July, 1),
+				Sunset:       date(2027, time.July, 1),
+				Successor:    "v2",
+			},
+			{Name: "v2"},
+		},
+	}
+}
+
+// LoadConfig đọc cấu hình version từ file .json, .yaml hoặc .yml
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("cannot parse versioning config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	names := make(map[string]bool, len(c.Versions))
+	for _, v := range c.Versions {
+		if !pathRegex.MatchString("/api/" + v.Name) {
+			return fmt.Errorf("versioning: invalid version name %q", v.Name)
+		}
+		names[v.Name] = true
+	}
+
+	if c.Default != "" && !names[c.Default] {
+		return fmt.Errorf("versioning: default version %q is not declared", c.Default)
+	}
+
+	for _, v := range c.Versions {
+		if v.Successor != "" && !names[v.Successor] {
+			return fmt.Errorf("versioning: successor %q of %s is not declared", v.Successor, v.Name)
+		}
+		if v.Sunset != nil && v.DeprecatedAt != nil && v.Sunset.Before(*v.DeprecatedAt) {
+			return fmt.Errorf("versioning: sunset of %s is before its deprecation", v.Name)
+		}
+	}
+
+	return nil
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
@@ -0,0 +1,84 @@
+package versioning
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxTrackedClients giới hạn số client được đếm cho mỗi version. X-Client-ID do client tự đặt,
+// không giới hạn thì gửi mỗi request 1 ID khác nhau là làm đầy bộ nhớ.
+const MaxTrackedClients = 1000
+
+// UsageCounter đếm số request vào version đã deprecated theo từng client.
+// Mỗi version là 1 LRU: đủ MaxTrackedClients thì client lâu nhất không gọi bị bỏ.
+type UsageCounter struct {
+	mu       sync.Mutex
+	limit    int
+	versions map[string]*versionUsage
+}
+
+type versionUsage struct {
+	clients map[string]*list.Element
+	lru     *list.List
+}
+
+type ClientUsage struct {
+	Client   string    `json:"client"`
+	Requests int64     `json:"requests"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func NewUsageCounter() *UsageCounter {
+	return newUsageCounter(MaxTrackedClients)
+}
+
+func newUsageCounter(limit int) *UsageCounter {
+	return &UsageCounter{limit: limit, versions: make(map[string]*versionUsage)}
+}
+
+func (u *UsageCounter) Record(version, client string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	v, ok := u.versions[version]
+	if !ok {
+		v = &versionUsage{clients: make(map[string]*list.Element), lru: list.New()}
+		u.versions[version] = v
+	}
+
+	elem, ok := v.clients[client]
+	if ok {
+		v.lru.MoveToFront(elem)
+	} else {
+		elem = v.lru.PushFront(&ClientUsage{Client: client})
+		v.clients[client] = elem
+		for v.lru.Len() > u.limit {
+			oldest := v.lru.Remove(v.lru.Back()).(*ClientUsage)
+			delete(v.clients, oldest.Client)
+		}
+	}
+
+	usage := elem.Value.(*ClientUsage)
+	usage.Requests++
+	usage.LastSeen = time.Now().UTC()
+}
+
+// Snapshot trả về bản sao, client gọi nhiều nhất đứng trước
+func (u *UsageCounter) Snapshot() map[string][]ClientUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make(map[string][]ClientUsage, len(u.versions))
+	for version, v := range u.versions {
+		clients := make([]ClientUsage, 0, v.lru.Len())
+		for elem := v.lru.Front(); elem != nil; elem = elem.Next() {
+			clients = append(clients, *elem.Value.(*ClientUsage))
+		}
+		sort.Slice(clients, func(i, j int) bool { return clients[i].Requests > clients[j].Requests })
+		result[version] = clients
+	}
+
+	return result
+}
@@ -0,0 +1,181 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderAPIVersion = "API-Version"
+	HeaderClientID   = "X-Client-ID"
+)
+
+var (
+	// application/vnd.mamba.v2+json
+	mediaTypeRegex = regexp.MustCompile(`application/vnd\.mamba\.(v\d+)\+[a-z]+`)
+	pathRegex      = regexp.MustCompile(`^/api/(v\d+)(/|$)`)
+)
+
+type Version struct {
+	Name string `json:"name" yaml:"name"`
+	// DeprecatedAt khác nil thì mọi response của version có header Deprecation
+	DeprecatedAt *time.Time `json:"deprecated_at,omitempty" yaml:"deprecated_at"`
+	Sunset       *time.Time `json:"sunset,omitempty" yaml:"sunset"`
+	Successor    string     `json:"successor,omitempty" yaml:"successor"`
+}
+
+func (v Version) Deprecated() bool {
+	return v.DeprecatedAt != nil
+}
+
+type Config struct {
+	// Default là version dùng khi request /api/... không chỉ định version
+	Default  string    `json:"default" yaml:"default"`
+	Versions []Version `json:"versions" yaml:"versions"`
+}
+
+type Manager struct {
+	engine   *gin.Engine
+	versions map[string]Version
+	def      string
+	usage    *UsageCounter
+}
+
+func NewManager(engine *gin.Engine, cfg Config) *Manager {
+	m := &Manager{
+		engine:   engine,
+		versions: make(map[string]Version, len(cfg.Versions)),
+		def:      cfg.Default,
+		usage:    NewUsageCounter(),
+	}
+
+	for _, v := range cfg.Versions {
+		m.versions[v.Name] = v
+	}
+
+	return m
+}
+
+func (m *Manager) Usage() *UsageCounter {
+	return m.usage
+}
+
+// Middleware gắn vào group /api/vN: ghi header API-Version, header deprecation
+// và đếm số lần client gọi version đã deprecated
+func (m *Manager) Middleware(name string) gin.HandlerFunc {
+	version, ok := m.versions[name]
+	if !ok {
+		version = Version{Name: name}
+	}
+
+	return func(ctx *gin.Context) {
+		ctx.Header(HeaderAPIVersion, version.Name)
+
+		if version.Deprecated() {
+			// RFC 9745: Deprecation là thời điểm dạng @unix-seconds
+			ctx.Header("Deprecation", fmt.Sprintf("@%d", version.DeprecatedAt.Unix()))
+			if version.Sunset != nil {
+				// RFC 8594
+				ctx.Header("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if version.Successor != "" {
+				ctx.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successorPath(ctx.Request.URL.Path, version)))
+			}
+
+			m.usage.Record(version.Name, ClientID(ctx))
+		}
+
+		ctx.Next()
+	}
+}
+
+// Dispatch dùng cho NoRoute: request /api/... không có version trong path được
+// chuyển sang /api/vN/... theo header API-Version hoặc Accept: application/vnd.mamba.vN+json
+func (m *Manager) Dispatch(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	if !strings.HasPrefix(path, "/api/") || pathRegex.MatchString(path) || ctx.Request.Context().Value(dispatchedKey{}) != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+
+	version, ok := m.Resolve(ctx.Request)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+			HeaderAPIVersion: "API version không được hỗ trợ",
+		}})
+		return
+	}
+
+	// Đánh dấu trên context của request vì HandleContext xoá ctx.Keys:
+	// path đã chuyển vẫn không khớp route thì trả 404 thay vì dispatch lại
+	ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), dispatchedKey{}, true))
+	ctx.Request.URL.Path = "/api/" + version + strings.TrimPrefix(path, "/api")
+	m.engine.HandleContext(ctx)
+}
+
+type dispatchedKey struct{}
+
+// Resolve xác định version theo thứ tự: header API-Version, media type trong Accept, Default
+func (m *Manager) Resolve(r *http.Request) (string, bool) {
+	if header := strings.TrimSpace(r.Header.Get(HeaderAPIVersion)); header != "" {
+		name := strings.ToLower(header)
+		if !strings.HasPrefix(name, "v") {
+			name = "v" + name
+		}
+		_, ok := m.versions[name]
+		return name, ok
+	}
+
+	if match := mediaTypeRegex.FindStringSubmatch(r.Header.Get("Accept")); match != nil {
+		_, ok := m.versions[match[1]]
+		return match[1], ok
+	}
+
+	return m.def, m.def != ""
+}
+
+func (m *Manager) Versions() []Version {
+	result := make([]Version, 0, len(m.versions))
+	for _, v := range m.versions {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// GetAPIVersions trả về vòng đời các version và số lần client gọi version đã deprecated
+func (m *Manager) GetAPIVersions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"default":  m.def,
+		"versions": m.Versions(),
+		"usage":    m.usage.Snapshot(),
+	})
+}
+
+// maxClientIDLength: X-Client-ID dài hơn bị cắt để 1 header lớn không chiếm bộ nhớ của UsageCounter
+const maxClientIDLength = 64
+
+// ClientID ưu tiên header X-Client-ID, không có thì dùng IP
+func ClientID(ctx *gin.Context) string {
+	if id := ctx.GetHeader(HeaderClientID); id != "" {
+		return id[:min(len(id), maxClientIDLength)]
+	}
+	return ctx.ClientIP()
+}
+
+// successorPath đổi version trong path sang version kế tiếp, giữ nguyên phần còn lại:
+// /api/v1/users/1 thành /api/v2/users/1
+func successorPath(path string, version Version) string {
+	match := pathRegex.FindStringSubmatchIndex(path)
+	if match == nil || path[match[2]:match[3]] != version.Name {
+		return path
+	}
+	return path[:match[2]] + version.Successor + path[match[3]:]
+}
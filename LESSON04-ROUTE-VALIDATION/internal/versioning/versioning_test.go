@@ -0,0 +1,154 @@
+package versioning
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newEngine(t *testing.T) (*gin.Engine, *Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	m := NewManager(r, DefaultConfig())
+
+	for _, name := range []string{"v1", "v2"} {
+		group := r.Group("/api/"+name, m.Middleware(name))
+		group.GET("/users/:id", func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, name+" user "+ctx.Param("id"))
+		})
+	}
+	r.NoRoute(m.Dispatch)
+	return r, m
+}
+
+func serve(r *gin.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDispatch(t *testing.T) {
+	r, _ := newEngine(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"default version", "/api/users/1", nil, http.StatusOK, "v1 user 1"},
+		{"API-Version", "/api/users/1", map[string]string{HeaderAPIVersion: "2"}, http.StatusOK, "v2 user 1"},
+		{"API-Version with prefix", "/api/users/1", map[string]string{HeaderAPIVersion: "V2"}, http.StatusOK, "v2 user 1"},
+		{"Accept vendor type", "/api/users/1", map[string]string{"Accept": "application/vnd.mamba.v2+json"}, http.StatusOK, "v2 user 1"},
+		{"API-Version over Accept", "/api/users/1", map[string]string{HeaderAPIVersion: "v1", "Accept": "application/vnd.mamba.v2+json"}, http.StatusOK, "v1 user 1"},
+		{"unknown API-Version", "/api/users/1", map[string]string{HeaderAPIVersion: "9"}, http.StatusBadRequest, ""},
+		{"unknown vendor version", "/api/users/1", map[string]string{"Accept": "application/vnd.mamba.v9+json"}, http.StatusBadRequest, ""},
+		{"versioned path is not dispatched", "/api/v2/missing", nil, http.StatusNotFound, ""},
+		{"dispatched path without route", "/api/missing", map[string]string{HeaderAPIVersion: "2"}, http.StatusNotFound, ""},
+		{"outside /api", "/users/1", nil, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.path, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	r, m := newEngine(t)
+	v1 := m.versions["v1"]
+
+	w := serve(r, "/api/v1/users/7", map[string]string{HeaderClientID: "mobile"})
+	if got := w.Header().Get(HeaderAPIVersion); got != "v1" {
+		t.Errorf("API-Version = %q", got)
+	}
+	if got, want := w.Header().Get("Deprecation"), fmt.Sprintf("@%d", v1.DeprecatedAt.Unix()); got != want {
+		t.Errorf("Deprecation = %q, want %q", got, want)
+	}
+	sunset, err := http.ParseTime(w.Header().Get("Sunset"))
+	if err != nil || !sunset.Equal(*v1.Sunset) {
+		t.Errorf("Sunset = %q, want %v", w.Header().Get("Sunset"), v1.Sunset)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2/users/7>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	// Request chuyển từ /api/... cũng có header của version được chọn
+	if w := serve(r, "/api/users/7", nil); w.Header().Get("Deprecation") == "" {
+		t.Error("dispatched v1 request without Deprecation")
+	}
+
+	w = serve(r, "/api/v2/users/7", map[string]string{HeaderClientID: "web"})
+	if w.Header().Get(HeaderAPIVersion) != "v2" || w.Header().Get("Deprecation") != "" || w.Header().Get("Sunset") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("v2 headers = %v", w.Header())
+	}
+
+	// Request chuyển tới v1 không có X-Client-ID được đếm theo IP
+	usage := m.Usage().Snapshot()
+	if len(usage) != 1 || !slices.ContainsFunc(usage["v1"], func(c ClientUsage) bool { return c.Client == "mobile" }) || len(usage["v1"]) != 2 {
+		t.Errorf("usage = %+v, want mobile and the IP client on v1 only", usage)
+	}
+}
+
+func TestSuccessorPath(t *testing.T) {
+	v1 := Version{Name: "v1", Successor: "v2"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1", "/api/v2"},
+		{"/api/v1/", "/api/v2/"},
+		{"/api/v1/products/widget/images", "/api/v2/products/widget/images"},
+		{"/api/v10/users", "/api/v10/users"},
+		{"/api/users/1", "/api/users/1"},
+	}
+	for _, tt := range tests {
+		if got := successorPath(tt.path, v1); got != tt.want {
+			t.Errorf("successorPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestUsageLimit(t *testing.T) {
+	usage := newUsageCounter(2)
+	for _, client := range []string{"a", "b", "a", "c", "a"} {
+		usage.Record("v1", client)
+	}
+
+	got := usage.Snapshot()["v1"]
+	var clients []string
+	for _, c := range got {
+		clients = append(clients, c.Client)
+	}
+	// b lâu nhất không gọi nên bị bỏ khi c tới
+	if !slices.Equal(clients, []string{"a", "c"}) || got[0].Requests != 3 {
+		t.Errorf("usage = %+v", got)
+	}
+	if time.Since(got[0].LastSeen) > time.Minute {
+		t.Errorf("last_seen = %v", got[0].LastSeen)
+	}
+
+	r, m := newEngine(t)
+	serve(r, "/api/v1/users/1", map[string]string{HeaderClientID: strings.Repeat("x", 10_000)})
+	if client := m.Usage().Snapshot()["v1"][0].Client; len(client) != maxClientIDLength {
+		t.Errorf("client id length = %d, want %d", len(client), maxClientIDLength)
+	}
+}
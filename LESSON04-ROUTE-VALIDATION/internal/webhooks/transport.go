@@ -0,0 +1,45 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var errPrivateAddress = errors.New("webhook url resolves to a private address")
+
+// newTransport chặn kết nối tới địa chỉ nội bộ. Kiểm tra ở Control (sau khi resolve DNS)
+// nên tên miền trỏ về 127.0.0.1 hay mạng private cũng bị chặn.
+func newTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Không đi qua proxy của môi trường, nếu không Control chỉ thấy địa chỉ proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	transport.MaxIdleConnsPerHost = 4
+	return transport
+}
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() &&
+		// 100.64.0.0/10 (CGNAT) thường là mạng nội bộ của cloud
+		!netip.MustParsePrefix("100.64.0.0/10").Contains(addr)
+}
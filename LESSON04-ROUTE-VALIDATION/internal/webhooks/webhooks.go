@@ -0,0 +1,278 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/tracing"
+)
+
+const (
+	// Queue là queue của jobs.Manager dành cho việc gửi webhook
+	Queue       = "webhooks"
+	TypeDeliver = "webhook.deliver"
+
+	HeaderEvent    = "X-Webhook-Event"
+	HeaderDelivery = "X-Webhook-Delivery"
+	// HeaderTimestamp là unix giây lúc gửi, được ký cùng body để chặn replay
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+	HeaderSignature = "X-Webhook-Signature"
+
+	// maxResponseBody: chỉ lưu chừng này byte response của đối tác vào log delivery
+	maxResponseBody = 1 << 10
+)
+
+// AllEvents trong Events của subscription là nhận mọi event
+const AllEvents = "*"
+
+// Event là body gửi cho đối tác
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type Options struct {
+	// Timeout của 1 lần gửi
+	Timeout time.Duration
+	// MaxAttempts: số lần gửi của 1 delivery trước khi bị đánh dấu failed
+	MaxAttempts int
+	// DisableAfter: số lần gửi lỗi liên tiếp (tính mọi delivery) thì tắt subscription
+	DisableAfter int
+	// AllowPrivate cho phép gửi tới địa chỉ nội bộ (loopback, mạng private), chỉ dùng khi dev
+	AllowPrivate bool
+}
+
+// Dispatcher tạo delivery cho mỗi subscription nhận event và gửi qua job queue
+// để được retry với backoff
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	queue  *jobs.Manager
+	client *http.Client
+	opts   Options
+}
+
+// DeliverPayload là payload của job TypeDeliver
+type DeliverPayload struct {
+	SubscriptionID string `json:"subscription_id"`
+	DeliveryID     string `json:"delivery_id"`
+}
+
+func NewDispatcher(repo repository.WebhookRepository, queue *jobs.Manager, opts Options) *Dispatcher {
+	d := &Dispatcher{
+		repo:  repo,
+		queue: queue,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: newTransport(opts.AllowPrivate),
+			// Redirect bị coi là lỗi: đối tác phải đăng ký đúng URL, và không bị dẫn vào mạng nội bộ
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		opts: opts,
+	}
+
+	jobs.Register(queue, TypeDeliver, jobs.TypeOptions{Queue: Queue, MaxAttempts: opts.MaxAttempts, Timeout: opts.Timeout + 5*time.Second}, d.deliver)
+	return d
+}
+
+// NewSecret sinh secret ngẫu nhiên cho subscription
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// Sign trả về giá trị header X-Webhook-Signature
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify dành cho phía nhận: kiểm tra chữ ký và timestamp không lệch quá tolerance
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// Handle là consumer của events.Bus: tạo delivery cho mọi subscription đang nhận event.
+// Event có thể được gửi lại (at-least-once), subscription đã có delivery của event thì bỏ qua.
+func (d *Dispatcher) Handle(ctx context.Context, event events.Event) error {
+	subscribers := d.repo.ListSubscribers(ctx, event.Type)
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Event{ID: event.ID, Type: event.Type, CreatedAt: event.OccurredAt, Data: event.Data})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscribers {
+		if d.repo.HasDelivery(ctx, sub.ID, event.ID) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			Event:          event.Type,
+			Payload:        body,
+			Status:         models.WebhookDeliveryPending,
+		}
+		// Subscription vừa bị xoá thì bỏ qua
+		if err := d.enqueue(ctx, delivery); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver gửi lại payload của delivery cũ trong 1 delivery mới
+func (d *Dispatcher) Redeliver(ctx context.Context, original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		RedeliveryOf:   original.ID,
+	}
+	return delivery, d.enqueue(ctx, delivery)
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := d.repo.CreateDelivery(ctx, delivery); err != nil {
+		return err
+	}
+	_, err := d.queue.Enqueue(ctx, TypeDeliver, DeliverPayload{
+		SubscriptionID: delivery.SubscriptionID,
+		DeliveryID:     delivery.ID,
+	}, jobs.EnqueueOptions{})
+	return err
+}
+
+// deliver là handler của job TypeDeliver, lỗi trả về làm job được retry
+func (d *Dispatcher) deliver(ctx context.Context, payload DeliverPayload) error {
+	sub, ok := d.repo.FindSubscription(ctx, payload.SubscriptionID)
+	if !ok {
+		return jobs.Permanent(errors.New("webhook subscription was deleted"))
+	}
+	delivery, ok := d.repo.FindDelivery(ctx, sub.ID, payload.DeliveryID)
+	if !ok {
+		return jobs.Permanent(errors.New("webhook delivery not found"))
+	}
+	if !sub.Active {
+		err := errors.New("webhook subscription is disabled")
+		d.record(ctx, delivery, models.WebhookAttempt{Error: err.Error(), At: time.Now().UTC()}, models.WebhookDeliveryFailed)
+		return jobs.Permanent(err)
+	}
+
+	attempt, err := d.send(ctx, sub, delivery)
+	gone := attempt.StatusCode == http.StatusGone
+
+	disableAfter := d.opts.DisableAfter
+	reason := fmt.Sprintf("%d consecutive failed deliveries", disableAfter)
+	if gone {
+		// 410: đối tác báo endpoint không còn dùng, tắt ngay thay vì chờ đủ số lần lỗi
+		disableAfter, reason = 1, "endpoint responded 410 Gone"
+	}
+	disabled, recordErr := d.repo.RecordResult(ctx, sub.ID, err == nil, disableAfter, reason)
+	if recordErr != nil && !errors.Is(recordErr, repository.ErrNotFound) {
+		return recordErr
+	}
+	if disabled {
+		logging.FromContext(ctx).Warn("webhook subscription disabled", slog.String("subscription_id", sub.ID), slog.String("reason", reason))
+	}
+
+	switch {
+	case err == nil:
+		metrics.WebhookDeliveries.Inc(delivery.Event, "succeeded")
+		d.record(ctx, delivery, attempt, models.WebhookDeliverySucceeded)
+		return nil
+	case disabled || gone || len(delivery.Attempts)+1 >= d.opts.MaxAttempts:
+		metrics.WebhookDeliveries.Inc(delivery.Event, "failed")
+		d.record(ctx, delivery, attempt, models.WebhookDeliveryFailed)
+		return jobs.Permanent(err)
+	default:
+		metrics.WebhookDeliveries.Inc(delivery.Event, "retrying")
+		d.record(ctx, delivery, attempt, models.WebhookDeliveryRetrying)
+		return err
+	}
+}
+
+func (d *Dispatcher) record(ctx context.Context, delivery *models.WebhookDelivery, attempt models.WebhookAttempt, status string) {
+	if err := d.repo.AddAttempt(ctx, delivery.ID, attempt, status); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logging.FromContext(ctx).Error("record webhook attempt failed", slog.String("delivery_id", delivery.ID), slog.String("error", err.Error()))
+	}
+}
+
+// send gửi 1 lần, lỗi khi không kết nối được hoặc status không phải 2xx
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (models.WebhookAttempt, error) {
+	start := time.Now()
+	attempt := models.WebhookAttempt{At: start.UTC()}
+	defer func() { attempt.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, err
+	}
+
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mamba-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, delivery.Payload))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseBody = strings.ToValidUTF8(string(body), "")
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("endpoint responded %d", resp.StatusCode)
+		attempt.Error = err.Error()
+		return attempt, err
+	}
+	return attempt, nil
+}
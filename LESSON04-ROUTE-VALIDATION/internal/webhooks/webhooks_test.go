@@ -0,0 +1,277 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/models"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/webhooks"
+)
+
+const secret = "whsec_test"
+
+// received là 1 request receiver nhận được
+type received struct {
+	header http.Header
+	body   []byte
+	valid  bool
+}
+
+// receiver trả lần lượt các status trong statuses, hết thì trả status cuối
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+
+	rcv := &receiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+
+		rcv.requests = append(rcv.requests, received{
+			header: r.Header.Clone(),
+			body:   body,
+			valid:  webhooks.Verify(secret, r.Header.Get(webhooks.HeaderTimestamp), r.Header.Get(webhooks.HeaderSignature), body, time.Minute),
+		})
+		status := rcv.statuses[min(len(rcv.requests), len(rcv.statuses))-1]
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *receiver) received() []received {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]received(nil), rcv.requests...)
+}
+
+type fixture struct {
+	repo       *repository.InMemoryWebhookRepository
+	dispatcher *webhooks.Dispatcher
+	sub        *models.WebhookSubscription
+}
+
+func newFixture(t *testing.T, url string, opts webhooks.Options) *fixture {
+	t.Helper()
+
+	store, err := jobs.OpenStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	manager := jobs.NewManager(store, jobs.Options{
+		Queues:       map[string]int{webhooks.Queue: 1},
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  10,
+		RetryBase:    10 * time.Millisecond,
+		RetryMax:     20 * time.Millisecond,
+	})
+	repo := repository.NewInMemoryWebhookRepository()
+	dispatcher := webhooks.NewDispatcher(repo, manager, opts)
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { manager.Close(context.Background()) })
+
+	sub := &models.WebhookSubscription{URL: url, Events: []string{webhooks.AllEvents}, Secret: secret, Active: true}
+	if err := repo.CreateSubscription(context.Background(), sub); err != nil {
+		t.Fatal(err)
+	}
+	return &fixture{repo: repo, dispatcher: dispatcher, sub: sub}
+}
+
+func (f *fixture) publish(t *testing.T) events.Event {
+	t.Helper()
+
+	event, err := events.New("product.created", "product", "1", map[string]any{"id": 1, "name": "Widget"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.dispatcher.Handle(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// waitDelivery chờ delivery duy nhất của subscription xong (succeeded hoặc failed)
+func (f *fixture) waitDelivery(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries := f.repo.ListDeliveries(context.Background(), f.sub.ID)
+		if len(deliveries) == 1 {
+			switch deliveries[0].Status {
+			case models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+				return deliveries[0]
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("delivery did not finish: %+v", f.repo.ListDeliveries(context.Background(), f.sub.ID))
+	return models.WebhookDelivery{}
+}
+
+func TestDeliverySignedAndRetried(t *testing.T) {
+	rcv := newReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK)
+	f := newFixture(t, rcv.URL, webhooks.Options{Timeout: time.Second, MaxAttempts: 5, DisableAfter: 10, AllowPrivate: true})
+	event := f.publish(t)
+
+	delivery := f.waitDelivery(t)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	var statuses []int
+	for _, attempt := range delivery.Attempts {
+		statuses = append(statuses, attempt.StatusCode)
+	}
+	if want := []int{503, 500, 200}; !slices.Equal(statuses, want) {
+		t.Errorf("attempt statuses = %v, want %v", statuses, want)
+	}
+	if delivery.Attempts[0].ResponseBody != "Service Unavailable" || delivery.Attempts[0].Error == "" {
+		t.Errorf("first attempt = %+v", delivery.Attempts[0])
+	}
+
+	requests := rcv.received()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(requests))
+	}
+	for i, req := range requests {
+		if !req.valid {
+			t.Errorf("request %d: invalid signature %q", i, req.header.Get(webhooks.HeaderSignature))
+		}
+		// Mỗi lần thử ký lại với timestamp mới nhưng giữ nguyên delivery id và body
+		if got := req.header.Get(webhooks.HeaderDelivery); got != delivery.ID {
+			t.Errorf("request %d: delivery id = %q, want %q", i, got, delivery.ID)
+		}
+		if got := req.header.Get(webhooks.HeaderEvent); got != "product.created" {
+			t.Errorf("request %d: event = %q", i, got)
+		}
+		if string(req.body) != string(requests[0].body) {
+			t.Errorf("request %d: body changed between attempts", i)
+		}
+	}
+
+	var body webhooks.Event
+	if err := json.Unmarshal(requests[0].body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ID != event.ID || body.Type != event.Type {
+		t.Errorf("body = %+v", body)
+	}
+
+	// Thành công thì reset số lần lỗi liên tiếp
+	sub, _ := f.repo.FindSubscription(context.Background(), f.sub.ID)
+	if sub.FailureCount != 0 || !sub.Active {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	// Event được bus gửi lại (at-least-once) không tạo delivery mới
+	if err := f.dispatcher.Handle(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.repo.ListDeliveries(context.Background(), f.sub.ID)); n != 1 {
+		t.Errorf("%d deliveries after duplicate event, want 1", n)
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	rcv := newReceiver(t, http.StatusInternalServerError)
+	f := newFixture(t, rcv.URL, webhooks.Options{Timeout: time.Second, MaxAttempts: 3, DisableAfter: 3, AllowPrivate: true})
+	f.publish(t)
+
+	delivery := f.waitDelivery(t)
+	if delivery.Status != models.WebhookDeliveryFailed || len(delivery.Attempts) != 3 {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if n := len(rcv.received()); n != 3 {
+		t.Errorf("receiver got %d requests, want 3", n)
+	}
+
+	sub, _ := f.repo.FindSubscription(context.Background(), f.sub.ID)
+	if sub.Active || sub.FailureCount != 3 || !strings.Contains(sub.DisabledReason, "3 consecutive") {
+		t.Errorf("subscription should be disabled: %+v", sub)
+	}
+}
+
+func TestDeliveryGoneDisablesSubscription(t *testing.T) {
+	rcv := newReceiver(t, http.StatusGone)
+	f := newFixture(t, rcv.URL, webhooks.Options{Timeout: time.Second, MaxAttempts: 5, DisableAfter: 10, AllowPrivate: true})
+	f.publish(t)
+
+	delivery := f.waitDelivery(t)
+	if delivery.Status != models.WebhookDeliveryFailed || len(delivery.Attempts) != 1 {
+		t.Fatalf("410 should not be retried: %+v", delivery)
+	}
+	sub, _ := f.repo.FindSubscription(context.Background(), f.sub.ID)
+	if sub.Active {
+		t.Error("subscription should be disabled after 410")
+	}
+}
+
+func TestDeliveryBlocksPrivateAddress(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	f := newFixture(t, rcv.URL, webhooks.Options{Timeout: time.Second, MaxAttempts: 1, DisableAfter: 10})
+	f.publish(t)
+
+	delivery := f.waitDelivery(t)
+	if delivery.Status != models.WebhookDeliveryFailed || !strings.Contains(delivery.Attempts[0].Error, "private address") {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if n := len(rcv.received()); n != 0 {
+		t.Errorf("receiver got %d requests, want none", n)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		want      bool
+	}{
+		{"valid", secret, ts, webhooks.Sign(secret, now, body), body, true},
+		{"wrong secret", "other", ts, webhooks.Sign(secret, now, body), body, false},
+		{"tampered body", secret, ts, webhooks.Sign(secret, now, body), []byte(`{"id":"2"}`), false},
+		{"timestamp not signed", secret, strconv.FormatInt(now-1, 10), webhooks.Sign(secret, now, body), body, false},
+		{"replayed", secret, strconv.FormatInt(now-600, 10), webhooks.Sign(secret, now-600, body), body, false},
+		{"from the future", secret, strconv.FormatInt(now+600, 10), webhooks.Sign(secret, now+600, body), body, false},
+		{"bad timestamp", secret, "yesterday", webhooks.Sign(secret, now, body), body, false},
+	}
+	for _, tt := range tests {
+		if got := webhooks.Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute); got != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"mamba.com/route-group/internal/bulk"
+	"mamba.com/route-group/internal/cache"
+	"mamba.com/route-group/internal/compress"
+	"mamba.com/route-group/internal/config"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/health"
+	"mamba.com/route-group/internal/idempotency"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/openapi"
+	"mamba.com/route-group/internal/ratelimit"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/server"
+	"mamba.com/route-group/internal/service"
+	"mamba.com/route-group/internal/storage"
+	"mamba.com/route-group/internal/stream"
+	"mamba.com/route-group/internal/tasks"
+	"mamba.com/route-group/internal/tracing"
+	"mamba.com/route-group/internal/versioning"
+	"mamba.com/route-group/internal/webhooks"
+	"mamba.com/route-group/utils"
+)
+
+func main() {
+	if err := utils.RegisterValidators(); err != nil {
+		log.Fatal(err)
+	}
+
+	loader := config.NewLoader(os.Args[0], os.Args[1:])
+	openAPIOut := loader.FlagSet().String("openapi-out", "", "write the generated OpenAPI spec to this file and exit")
+
+	cfg, err := loader.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *openAPIOut != "" {
+		if err := writeOpenAPI(cfg, *openAPIOut); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var logLevel slog.LevelVar
+	logLevel.Set(mustParseLevel(cfg.Log.Level))
+	logger, err := logging.New(os.Stdout, logging.Options{Format: cfg.Log.Format, Level: &logLevel})
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	utils.SetUploadPolicy(uploadPolicy(cfg.Upload))
+	utils.SetRequireIfMatch(cfg.Concurrency.RequireIfMatch)
+	bulk.SetMaxItems(cfg.Bulk.MaxItems)
+
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "redis" {
+		limitStore = ratelimit.NewRedisStore(ratelimit.RedisOptions{
+			Addr:     cfg.RateLimit.RedisAddr,
+			Password: cfg.RateLimit.RedisPassword,
+			DB:       cfg.RateLimit.RedisDB,
+		})
+	}
+	byAPIKey := ratelimit.ByAPIKey(ratelimit.HeaderAPIKey)
+	apiLimiter := ratelimit.NewLimiter("api", limitStore, byAPIKey, limit(cfg.RateLimit.API))
+	uploadLimiter := ratelimit.NewLimiter("upload", limitStore, byAPIKey, limit(cfg.RateLimit.Upload))
+	searchLimiter := ratelimit.NewLimiter("search", limitStore, byAPIKey, limit(cfg.RateLimit.Search))
+	adminLimiter := ratelimit.NewLimiter("admin", limitStore, ratelimit.ByUser(clientCertSubject, ratelimit.ByIP()), limit(cfg.RateLimit.Admin))
+
+	// Key của mỗi client (API key, không có thì IP) tách riêng để client khác không đoán được
+	idempotent := idempotency.Middleware(idempotency.NewStore(), idempotency.Options{
+		TTL:         cfg.Idempotency.TTL.Std(),
+		WaitTimeout: cfg.Idempotency.WaitTimeout.Std(),
+		Scope:       byAPIKey,
+	})
+
+	// Chỉ log level, upload policy, quota rate limit, require If-Match và số phần tử bulk đổi được lúc chạy,
+	// phần còn lại cần restart
+	loader.Watch(context.Background(), cfg, func(next *config.Config) {
+		logLevel.Set(mustParseLevel(next.Log.Level))
+		utils.SetUploadPolicy(uploadPolicy(next.Upload))
+		apiLimiter.SetLimit(limit(next.RateLimit.API))
+		uploadLimiter.SetLimit(limit(next.RateLimit.Upload))
+		searchLimiter.SetLimit(limit(next.RateLimit.Search))
+		adminLimiter.SetLimit(limit(next.RateLimit.Admin))
+		utils.SetRequireIfMatch(next.Concurrency.RequireIfMatch)
+		bulk.SetMaxItems(next.Bulk.MaxItems)
+	})
+
+	workers := health.NewHeartbeats()
+
+	exporter, err := newTraceExporter(cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tracerOpts := tracing.Options{
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Exporter:    exporter,
+	}
+	if exporter != nil {
+		workers.Register("trace-exporter", 3*tracing.FlushInterval)
+		tracerOpts.Heartbeat = func() { workers.Beat("trace-exporter") }
+	}
+	tracer := tracing.NewTracer(tracerOpts)
+	tracing.SetDefault(tracer)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	r.Use(tracing.Middleware(), logging.Middleware(logger), metrics.Middleware(), gin.Recovery())
+	if cfg.Compression.Enabled {
+		r.Use(compress.Middleware(compress.Options{MinSize: int(cfg.Compression.MinSize)}))
+	}
+
+	if cfg.OpenAPI.Spec != "" {
+		doc, err := openapi.LoadDocument(cfg.OpenAPI.Spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		validator, err := openapi.NewValidator(doc, openapi.ValidatorOptions{
+			ValidateResponses: cfg.OpenAPI.ValidateResponses || cfg.OpenAPI.Strict,
+			StrictResponses:   cfg.OpenAPI.Strict,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		r.Use(validator.Middleware())
+	}
+
+	versionCfg := versioning.DefaultConfig()
+	if cfg.Versioning.File != "" {
+		versionCfg, err = versioning.LoadConfig(cfg.Versioning.File)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	versions := versioning.NewManager(r, versionCfg)
+	// /api/users + API-Version: 2 hoặc Accept: application/vnd.mamba.v2+json -> /api/v2/users
+	r.NoRoute(versions.Dispatch)
+
+	outbox, err := events.OpenOutbox(cfg.Events.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	workers.Register("events", 3*cfg.Events.PollInterval.Std())
+	eventBus := events.NewBus(outbox, events.Options{
+		PollInterval: cfg.Events.PollInterval.Std(),
+		BatchSize:    cfg.Events.BatchSize,
+		MaxAttempts:  cfg.Events.MaxAttempts,
+		RetryBase:    cfg.Events.RetryBase.Std(),
+		RetryMax:     cfg.Events.RetryMax.Std(),
+		Retention:    cfg.Events.Retention.Std(),
+		Heartbeat:    func() { workers.Beat("events") },
+	})
+
+	newsRepo := repository.NewInMemoryNewsRepository(outbox)
+	productRepo := repository.NewInMemoryProductRepository(outbox)
+	productImports := imports.NewManager(cfg.Import.TTL.Std())
+
+	jobStore, err := jobs.OpenStore(cfg.Jobs.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	workers.Register("jobs", 3*cfg.Jobs.PollInterval.Std())
+	jobManager := jobs.NewManager(jobStore, jobs.Options{
+		Queues: map[string]int{
+			jobs.DefaultQueue: cfg.Jobs.DefaultConcurrency,
+			tasks.QueueMedia:  cfg.Jobs.MediaConcurrency,
+			webhooks.Queue:    cfg.Webhooks.Concurrency,
+		},
+		PollInterval: cfg.Jobs.PollInterval.Std(),
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		RetryBase:    cfg.Jobs.RetryBase.Std(),
+		RetryMax:     cfg.Jobs.RetryMax.Std(),
+		Timeout:      cfg.Jobs.Timeout.Std(),
+		Retention:    cfg.Jobs.Retention.Std(),
+		Heartbeat:    func() { workers.Beat("jobs") },
+	})
+	webhookRepo := repository.NewInMemoryWebhookRepository()
+	webhookEvents := webhooks.NewDispatcher(webhookRepo, jobManager, webhooks.Options{
+		Timeout:      cfg.Webhooks.Timeout.Std(),
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		DisableAfter: cfg.Webhooks.DisableAfter,
+		AllowPrivate: cfg.Webhooks.AllowPrivate,
+	})
+	eventBus.Subscribe("webhooks", webhookEvents.Handle)
+	streamHub := stream.NewHub(stream.Options{
+		History:      cfg.Stream.History,
+		ClientBuffer: cfg.Stream.ClientBuffer,
+		Heartbeat:    cfg.Stream.Heartbeat.Std(),
+		WriteTimeout: cfg.Stream.WriteTimeout.Std(),
+	})
+	eventBus.Subscribe("stream", stream.NewFeed(streamHub, productRepo.List(context.Background())).Handle)
+	// nil khi tắt cache: middleware của cache chỉ chuyển tiếp request
+	var responseCache *cache.Cache
+	if cfg.Cache.Enabled {
+		responseCache = cache.New(cache.NewMemoryStore(cfg.Cache.MaxEntries, int(cfg.Cache.MaxSize)), r)
+		eventBus.Subscribe("cache", responseCache.HandleEvent)
+	}
+	tasks.Register(jobManager, tasks.Deps{News: newsRepo, Uploads: storage.NewLocalStorage(cfg.Upload.Dir)})
+	if err := jobManager.Schedule("news-publish", cfg.Jobs.NewsPublishSchedule, tasks.TypeNewsPublishDue, tasks.NewsPublishDuePayload{}); err != nil {
+		log.Fatal(err)
+	}
+	if err := jobManager.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if err := eventBus.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	categoryRepo := repository.NewInMemoryCategoryRepository()
+	userRepo := repository.NewInMemoryUserRepository(outbox)
+	userService := service.NewUserService(userRepo)
+
+	checks := health.NewRegistry(health.Options{CacheTTL: cfg.Health.CacheTTL.Std()})
+	checks.Register("database", health.Readiness, health.PingCheck(map[string]health.Pinger{
+		"news":       newsRepo,
+		"products":   productRepo,
+		"categories": categoryRepo,
+		"users":      userRepo,
+		"jobs":       jobStore,
+		"events":     outbox,
+		"webhooks":   webhookRepo,
+	}))
+	checks.Register("storage", health.Readiness, health.StorageCheck(cfg.Upload.Dir, cfg.Health.MinFreeDiskMB<<20))
+	checks.Register("workers", health.Liveness, workers)
+	registerRoutes(r, routeDeps{
+		cfg:            cfg,
+		versions:       versions,
+		checks:         checks,
+		apiLimiter:     apiLimiter,
+		uploadLimiter:  uploadLimiter,
+		searchLimiter:  searchLimiter,
+		adminLimiter:   adminLimiter,
+		idempotent:     idempotent,
+		responseCache:  responseCache,
+		userService:    userService,
+		productRepo:    productRepo,
+		productImports: productImports,
+		categoryRepo:   categoryRepo,
+		newsRepo:       newsRepo,
+		webhookRepo:    webhookRepo,
+		jobManager:     jobManager,
+		eventBus:       eventBus,
+		streamHub:      streamHub,
+		webhookEvents:  webhookEvents,
+	})
+
+	var tlsConf *tls.Config
+	if cfg.Server.TLS.CertFile != "" {
+		tlsConf, err = server.NewTLSConfig(server.TLSOptions{
+			CertFile:       cfg.Server.TLS.CertFile,
+			KeyFile:        cfg.Server.TLS.KeyFile,
+			ClientCAFile:   cfg.Server.TLS.ClientCAFile,
+			ReloadInterval: cfg.Server.TLS.ReloadInterval.Std(),
+		}, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	runner, err := server.NewRunner(r, server.Options{
+		Addr:              cfg.Server.Addr,
+		ReadTimeout:       cfg.Server.ReadTimeout.Std(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Std(),
+		WriteTimeout:      cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:       cfg.Server.IdleTimeout.Std(),
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout.Std(),
+		DrainDelay:        cfg.Server.DrainDelay.Std(),
+		TLS:               tlsConf,
+		H2C:               cfg.Server.H2C,
+		HTTP3:             cfg.Server.TLS.HTTP3,
+	}, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	runner.OnDrain(func() { checks.SetDraining(true) })
+	// Stream không tự kết thúc, phải ngắt trước khi Shutdown chờ request đang chạy
+	runner.OnDrain(streamHub.Close)
+	if store, ok := limitStore.(*ratelimit.RedisStore); ok {
+		runner.OnStop("ratelimit-redis", store.Close)
+	}
+	runner.OnStop("product-import", productImports.Close)
+	// Dừng relay trước job queue vì consumer webhooks xếp job vào đó, outbox đóng sau cùng
+	// vì job (VD: publish tin hẹn giờ) vẫn ghi event
+	runner.OnStop("events", func(ctx context.Context) error {
+		workers.Unregister("events")
+		return eventBus.Close(ctx)
+	})
+	runner.OnStop("jobs", func(ctx context.Context) error {
+		workers.Unregister("jobs")
+		return jobManager.Close(ctx)
+	})
+	runner.OnStop("outbox", func(context.Context) error {
+		return outbox.Close()
+	})
+	runner.OnStop("trace-exporter", func(ctx context.Context) error {
+		workers.Unregister("trace-exporter")
+		return tracer.Shutdown(ctx)
+	})
+
+	if err := runner.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newTraceExporter(cfg config.TracingConfig) (tracing.Exporter, error) {
+	switch cfg.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return tracing.NewWriterExporter(os.Stdout)
+	case "file":
+		return tracing.NewFileExporter(cfg.File)
+	case "otlp":
+		return tracing.NewOTLPExporter(context.Background(), cfg.OTLPEndpoint, cfg.OTLPHeaders)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+func uploadPolicy(cfg config.UploadConfig) utils.UploadPolicy {
+	return utils.UploadPolicy{
+		MaxSize:          int64(cfg.MaxSize),
+		AllowedExts:      cfg.AllowedExts,
+		AllowedMimeTypes: cfg.AllowedMimeTypes,
+	}
+}
+
+func limit(rate config.Rate) ratelimit.Limit {
+	return ratelimit.Limit{Requests: rate.Requests, Period: rate.Period}
+}
+
+// clientCertSubject là user đã xác thực bằng mTLS (group admin), chưa có thì trả rỗng
+func clientCertSubject(ctx *gin.Context) string {
+	state := ctx.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
+
+// mustParseLevel: level đã được validate trong config
+func mustParseLevel(value string) slog.Level {
+	level, err := logging.ParseLevel(value)
+	if err != nil {
+		panic(err)
+	}
+	return level
+}
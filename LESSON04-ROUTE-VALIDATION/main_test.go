@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mamba.com/route-group/internal/config"
+	"mamba.com/route-group/internal/openapi"
+)
+
+// -openapi-out không được mở SQLite (data/*.db) hay cần config của store nào
+func TestWriteOpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	cfg := config.Default()
+	out := filepath.Join(dir, "openapi.json")
+	if err := writeOpenAPI(&cfg, out); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/api/v1/users", "/api/v2/users/{uuid}", "/admin/jobs/{id}", "/sitemap.xml"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("spec is missing %s", path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("only the spec should be written, found %d entries", len(entries))
+	}
+}
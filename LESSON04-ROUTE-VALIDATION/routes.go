@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	v1handler "mamba.com/route-group/internal/api/v1/handler"
+	v2handler "mamba.com/route-group/internal/api/v2/handler"
+	"mamba.com/route-group/internal/cache"
+	"mamba.com/route-group/internal/config"
+	"mamba.com/route-group/internal/events"
+	"mamba.com/route-group/internal/health"
+	"mamba.com/route-group/internal/imports"
+	"mamba.com/route-group/internal/jobs"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/openapi"
+	"mamba.com/route-group/internal/ratelimit"
+	"mamba.com/route-group/internal/repository"
+	"mamba.com/route-group/internal/server"
+	"mamba.com/route-group/internal/service"
+	"mamba.com/route-group/internal/sitemap"
+	"mamba.com/route-group/internal/stream"
+	"mamba.com/route-group/internal/versioning"
+	"mamba.com/route-group/internal/webhooks"
+)
+
+// routeDeps là các dependency mà route cần. Khi chỉ sinh spec (-openapi-out) thì jobManager,
+// eventBus và webhookEvents để nil: gin chỉ giữ method value của handler, không gọi tới.
+type routeDeps struct {
+	cfg      *config.Config
+	versions *versioning.Manager
+	checks   *health.Registry
+
+	apiLimiter    *ratelimit.Limiter
+	uploadLimiter *ratelimit.Limiter
+	searchLimiter *ratelimit.Limiter
+	adminLimiter  *ratelimit.Limiter
+	idempotent    gin.HandlerFunc
+	responseCache *cache.Cache
+
+	userService    *service.UserService
+	productRepo    *repository.InMemoryProductRepository
+	productImports *imports.Manager
+	categoryRepo   *repository.InMemoryCategoryRepository
+	newsRepo       *repository.InMemoryNewsRepository
+	webhookRepo    *repository.InMemoryWebhookRepository
+
+	jobManager    *jobs.Manager
+	eventBus      *events.Bus
+	streamHub     *stream.Hub
+	webhookEvents *webhooks.Dispatcher
+}
+
+// registerRoutes đăng ký toàn bộ route lên r và trả về generator của spec OpenAPI.
+// Middleware toàn cục phải được r.Use trước khi gọi.
+func registerRoutes(r *gin.Engine, d routeDeps) *openapi.Generator {
+	cacheRule := func(name string, tags ...string) cache.Rule {
+		return cache.Rule{
+			Name:                 name,
+			TTL:                  d.cfg.Cache.TTL.Std(),
+			StaleWhileRevalidate: d.cfg.Cache.StaleWhileRevalidate.Std(),
+			Vary:                 []string{"Accept"},
+			Tags:                 tags,
+		}
+	}
+
+	r.GET("/healthz", d.checks.GetHealthz)
+	r.GET("/readyz", d.checks.GetReadyz)
+
+	r.Static("/uploads", d.cfg.Upload.Dir)
+	r.GET("/metrics", metrics.Default.Handler())
+
+	sitemapHandler := v1handler.NewSitemapHandler(sitemap.NewSiteGenerator(d.productRepo, d.categoryRepo, d.newsRepo))
+	r.GET("/sitemap.xml", sitemapHandler.GetSitemapIndexV1)
+	r.GET("/sitemap.xml.gz", sitemapHandler.GetSitemapIndexV1)
+	r.GET("/sitemaps/:file", sitemapHandler.GetSitemapShardV1)
+
+	spec := openapi.NewGenerator(openapi.Info{Title: "Mamba API", Version: "1.0.0"})
+	v1handler.DescribeOpenAPI(spec)
+	v2handler.DescribeOpenAPI(spec)
+	spec.Describe(
+		openapi.Route{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness probe", Errors: []int{http.StatusServiceUnavailable}},
+		openapi.Route{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness probe", Errors: []int{http.StatusServiceUnavailable}},
+		openapi.Route{Method: http.MethodGet, Path: "/docs/:file", Summary: "Swagger UI assets", Errors: []int{http.StatusNotFound}},
+		openapi.Route{Method: http.MethodGet, Path: "/admin/jobs", Summary: "List jobs (status=dead for the dead-letter queue)", Input: jobs.GetJobsParam{}},
+		openapi.Route{Method: http.MethodGet, Path: "/admin/jobs/:id", Summary: "Get job", Input: jobs.GetJobByIDParam{}, Errors: []int{http.StatusNotFound}},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/admin/jobs/:id/retry", Summary: "Retry a failed or dead job",
+			Input: jobs.GetJobByIDParam{}, Status: http.StatusOK, Errors: []int{http.StatusNotFound, http.StatusConflict},
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/admin/jobs/:id/cancel", Summary: "Cancel a job, 202 while a running job is stopping",
+			Input: jobs.GetJobByIDParam{}, Status: http.StatusOK, Errors: []int{http.StatusNotFound, http.StatusConflict}, Responses: []int{http.StatusAccepted},
+		},
+	)
+	// Status do middleware trả về, thiếu thì strict mode đổi các response này thành 500
+	spec.DescribeMiddleware(
+		// gin.Recovery
+		openapi.Middleware{Responses: []int{http.StatusInternalServerError}},
+		// apiLimiter, searchLimiter, uploadLimiter, adminLimiter
+		openapi.Middleware{Prefix: "/api/", Responses: []int{http.StatusTooManyRequests}},
+		openapi.Middleware{Prefix: "/admin/", Responses: []int{http.StatusTooManyRequests, http.StatusForbidden}},
+		// idempotent: key sai định dạng, request gốc chưa xong, key dùng lại với request khác
+		openapi.Middleware{
+			Prefix:    "/api/",
+			Methods:   []string{http.MethodPost, http.MethodPatch},
+			Responses: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+	)
+
+	openAPIHandler := openapi.NewHandler(spec, r)
+	r.GET("/openapi.json", openAPIHandler.GetOpenAPISpec)
+	r.GET("/docs", openAPIHandler.GetDocs)
+	r.GET("/docs/:file", openAPIHandler.GetDocsAsset)
+
+	admin := r.Group("/admin")
+	if d.cfg.Server.TLS.ClientCAFile != "" {
+		admin.Use(server.RequireClientCert())
+	}
+	admin.Use(d.adminLimiter.Middleware())
+	{
+		admin.GET("/api-versions", d.versions.GetAPIVersions)
+		admin.GET("/jobs", d.jobManager.GetJobs)
+		admin.GET("/jobs/schedules", d.jobManager.GetJobSchedules)
+		admin.GET("/jobs/:id", d.jobManager.GetJobByID)
+		admin.POST("/jobs/:id/retry", d.jobManager.PostJobRetry)
+		admin.POST("/jobs/:id/cancel", d.jobManager.PostJobCancel)
+		admin.GET("/events", d.eventBus.GetEvents)
+		admin.GET("/events/consumers", d.eventBus.GetConsumers)
+	}
+
+	v1 := r.Group("/api/v1", d.versions.Middleware("v1"), d.apiLimiter.Middleware(), d.idempotent)
+	{
+		user := v1.Group("/users")
+		{
+			userHandlerV1 := v1handler.NewUserHandler(d.userService)
+			user.GET("", userHandlerV1.GetUsersV1)
+			user.GET("/:id", userHandlerV1.GetUsersByIdV1)
+			user.GET("/admin/:uuid", userHandlerV1.GetUsersByUuidV1)
+			user.POST("", userHandlerV1.PostUsersV1)
+			user.POST("/bulk", userHandlerV1.PostUsersBulkV1)
+			user.PUT("/bulk", userHandlerV1.PutUsersBulkV1)
+			user.DELETE("/bulk", userHandlerV1.DeleteUsersBulkV1)
+			user.PUT("/:id", userHandlerV1.PutUsersByIdV1)
+			user.DELETE("/:id", userHandlerV1.DeleteUsersByIdV1)
+		}
+
+		product := v1.Group("/products")
+		{
+			productHandlerV1 := v1handler.NewProductHandler(d.productRepo, d.productImports, v1handler.ImportLimits{
+				MaxSize: int64(d.cfg.Import.MaxSize),
+				MaxRows: d.cfg.Import.MaxRows,
+			})
+			product.GET("", d.searchLimiter.Middleware(), productHandlerV1.GetProductsV1)
+			product.GET("/export", d.searchLimiter.Middleware(), productHandlerV1.GetProductsExportV1)
+			product.POST("/import", d.uploadLimiter.Middleware(), productHandlerV1.PostProductsImportV1)
+			product.GET("/import/:id", productHandlerV1.GetProductsImportV1)
+			product.GET("/import/:id/report", productHandlerV1.GetProductsImportReportV1)
+			product.GET("/:slug", d.responseCache.Middleware(cacheRule("product")), productHandlerV1.GetProductsBySlugV1)
+			product.POST("", productHandlerV1.PostProductsV1)
+			product.POST("/bulk", productHandlerV1.PostProductsBulkV1)
+			product.PUT("/bulk", productHandlerV1.PutProductsBulkV1)
+			product.DELETE("/bulk", productHandlerV1.DeleteProductsBulkV1)
+			product.PUT("/:id", productHandlerV1.PutProductsByIdV1)
+			product.DELETE("/:id", productHandlerV1.DeleteProductsByIdV1)
+		}
+
+		category := v1.Group("/categories")
+		{
+			categoryHandlerV1 := v1handler.NewCategoryHandler(d.categoryRepo)
+			// Category không ghi event vào outbox nên route ghi tự xoá cache
+			category.GET("/:category", d.responseCache.Middleware(cacheRule("category", "category")), categoryHandlerV1.GetCategoryByCategoryV1)
+			category.POST("", d.responseCache.Invalidate("category"), categoryHandlerV1.PostCategoriesV1)
+			category.POST("/bulk", d.responseCache.Invalidate("category"), categoryHandlerV1.PostCategoriesBulkV1)
+			category.PUT("/bulk", d.responseCache.Invalidate("category"), categoryHandlerV1.PutCategoriesBulkV1)
+			category.DELETE("/bulk", d.responseCache.Invalidate("category"), categoryHandlerV1.DeleteCategoriesBulkV1)
+		}
+
+		news := v1.Group("/news")
+		{
+			newsHandlerV1 := v1handler.NewNewsHandler(d.newsRepo, d.cfg.Upload.Dir, d.jobManager)
+			news.GET("", d.responseCache.Middleware(cacheRule("news", events.AggregateNews)), newsHandlerV1.GetNewsV1)
+			news.GET("/:slug", d.responseCache.Middleware(cacheRule("news", events.AggregateNews)), newsHandlerV1.GetNewsBySlugV1)
+			news.GET("/feed.rss", newsHandlerV1.GetNewsFeedRssV1)
+			news.GET("/feed.atom", newsHandlerV1.GetNewsFeedAtomV1)
+			news.GET("/feed.json", newsHandlerV1.GetNewsFeedJsonV1)
+			news.POST("", d.uploadLimiter.Middleware(), newsHandlerV1.PostNewsV1)
+			news.POST("/upload-file", d.uploadLimiter.Middleware(), newsHandlerV1.PostUploadFileNewsV1)
+			news.POST("/upload-multiple-file", d.uploadLimiter.Middleware(), newsHandlerV1.PostUploadMultipleFileNewsV1)
+		}
+
+		v1.GET("/stream", d.streamHub.GetStream)
+		v1.GET("/stream/ws", stream.RequireAPIKey(d.cfg.Stream.APIKeys), d.streamHub.GetStreamWS)
+
+		webhook := v1.Group("/webhooks")
+		{
+			webhookHandlerV1 := v1handler.NewWebhookHandler(d.webhookRepo, d.webhookEvents)
+			webhook.GET("", webhookHandlerV1.GetWebhooksV1)
+			webhook.POST("", webhookHandlerV1.PostWebhooksV1)
+			webhook.GET("/:id", webhookHandlerV1.GetWebhookByIdV1)
+			webhook.PATCH("/:id", webhookHandlerV1.PatchWebhookByIdV1)
+			webhook.DELETE("/:id", webhookHandlerV1.DeleteWebhookByIdV1)
+			webhook.GET("/:id/deliveries", webhookHandlerV1.GetWebhookDeliveriesV1)
+			webhook.GET("/:id/deliveries/:delivery_id", webhookHandlerV1.GetWebhookDeliveryByIdV1)
+			webhook.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandlerV1.PostWebhookRedeliverV1)
+		}
+	}
+
+	v2 := r.Group("/api/v2", d.versions.Middleware("v2"), d.apiLimiter.Middleware(), d.idempotent)
+	{
+		userV2 := v2.Group("/users")
+		{
+			userHandlerV2 := v2handler.NewUserHandler(d.userService)
+			userV2.GET("", userHandlerV2.GetUsersV2)
+			userV2.GET("/:uuid", userHandlerV2.GetUsersByUuidV2)
+			userV2.POST("", userHandlerV2.PostUsersV2)
+			userV2.PUT("/:uuid", userHandlerV2.PutUsersByUuidV2)
+			userV2.DELETE("/:uuid", userHandlerV2.DeleteUsersByUuidV2)
+		}
+	}
+
+	return spec
+}
+
+// writeOpenAPI ghi spec sinh từ bảng route ra path. Spec chỉ cần route nên repository là bản
+// in-memory trống và các dependency chạy nền để nil: không mở SQLite, không chạy worker nào.
+func writeOpenAPI(cfg *config.Config, path string) error {
+	r := gin.New()
+	limitStore := ratelimit.NewMemoryStore()
+	limiter := func(name string) *ratelimit.Limiter {
+		return ratelimit.NewLimiter(name, limitStore, ratelimit.ByIP(), ratelimit.Limit{})
+	}
+
+	spec := registerRoutes(r, routeDeps{
+		cfg:            cfg,
+		versions:       versioning.NewManager(r, versioning.DefaultConfig()),
+		checks:         health.NewRegistry(health.Options{}),
+		apiLimiter:     limiter("api"),
+		uploadLimiter:  limiter("upload"),
+		searchLimiter:  limiter("search"),
+		adminLimiter:   limiter("admin"),
+		idempotent:     func(ctx *gin.Context) { ctx.Next() },
+		userService:    service.NewUserService(repository.NewInMemoryUserRepository(nil)),
+		productRepo:    repository.NewInMemoryProductRepository(nil),
+		productImports: imports.NewManager(cfg.Import.TTL.Std()),
+		categoryRepo:   repository.NewInMemoryCategoryRepository(),
+		newsRepo:       repository.NewInMemoryNewsRepository(nil),
+		webhookRepo:    repository.NewInMemoryWebhookRepository(),
+		streamHub:      stream.NewHub(stream.Options{}),
+	})
+
+	data, err := json.MarshalIndent(spec.Generate(r.Routes()), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
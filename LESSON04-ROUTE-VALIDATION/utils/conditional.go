@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag tạo strong ETag từ nội dung response
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// VersionETag tạo strong ETag từ version của resource, dùng cho If-Match và response của PUT.
+// GET gắn thêm hậu tố representation (RepresentationETag), Precondition bỏ hậu tố đó khi so.
+func VersionETag(kind string, id any, version int) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s:%v:%d", kind, id, version))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+var requireIfMatch atomic.Bool
+
+func init() {
+	requireIfMatch.Store(true)
+}
+
+// SetRequireIfMatch bật/tắt 428 khi update/delete không gửi If-Match, đổi được lúc chạy (config reload)
+func SetRequireIfMatch(require bool) {
+	requireIfMatch.Store(require)
+}
+
+// CheckPrecondition kiểm tra If-Match trước khi update/delete resource có ETag etag.
+// Trả về false khi đã render lỗi: 428 nếu thiếu If-Match (khi bắt buộc), 412 nếu resource đã bị đổi.
+func CheckPrecondition(ctx *gin.Context, etag string) bool {
+	switch Precondition(ctx.GetHeader("If-Match"), etag) {
+	case http.StatusPreconditionRequired:
+		Render(ctx, http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return false
+	case http.StatusPreconditionFailed:
+		RenderPreconditionFailed(ctx, etag)
+		return false
+	}
+	return true
+}
+
+// Precondition so ifMatch với etag, trả về 0 khi được phép ghi, 428 hoặc 412 khi không.
+// API bulk gọi trực tiếp với If-Match của từng phần tử.
+// If-Match dùng so sánh strong (RFC 9110 13.1.1) nên weak ETag (W/"...") không bao giờ khớp.
+func Precondition(ifMatch, etag string) int {
+	if ifMatch == "" {
+		if requireIfMatch.Load() {
+			return http.StatusPreconditionRequired
+		}
+		return 0
+	}
+
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag || versionPart(candidate) == etag {
+			return 0
+		}
+	}
+	return http.StatusPreconditionFailed
+}
+
+// RepresentationETag gắn vào ETag version định dạng response (theo Accept) và danh sách fields=,
+// để JSON, XML hay response chỉ có vài field của cùng 1 version không chung 1 strong ETag.
+func RepresentationETag(ctx *gin.Context, etag string) string {
+	format := NegotiateContentType(ctx.GetHeader("Accept"), renderOffers)
+	var fields []string
+	for field := range strings.SplitSeq(ctx.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s;%s", format, strings.Join(fields, ",")))
+	return strings.TrimSuffix(etag, `"`) + "-" + hex.EncodeToString(sum[:4]) + `"`
+}
+
+// versionPart bỏ hậu tố representation, ETag không có hậu tố giữ nguyên
+func versionPart(etag string) string {
+	if i := strings.LastIndexByte(etag, '-'); i > 0 && strings.HasSuffix(etag, `"`) {
+		return etag[:i] + `"`
+	}
+	return etag
+}
+
+// NotModified set ETag representation của resource và trả 304 nếu client đã có bản đó.
+// Không gửi Last-Modified vì HTTP date chỉ chính xác tới giây, 2 lần update trong 1 giây sẽ bị 304 sai.
+func NotModified(ctx *gin.Context, etag string) bool {
+	if !CheckNotModified(ctx, RepresentationETag(ctx, etag), time.Time{}) {
+		return false
+	}
+	ctx.Status(http.StatusNotModified)
+	return true
+}
+
+// ModifiedMessage là "error" của 412 khi resource đã bị request khác sửa/xoá
+const ModifiedMessage = "Resource has been modified, fetch it again and retry"
+
+// RenderPreconditionFailed trả 412 kèm ETag hiện tại để client biết phải GET lại
+func RenderPreconditionFailed(ctx *gin.Context, etag string) {
+	if etag != "" {
+		ctx.Header("ETag", etag)
+	}
+	Render(ctx, http.StatusPreconditionFailed, gin.H{"error": ModifiedMessage})
+}
+
+// CheckNotModified set ETag / Last-Modified và trả về true nếu client đã có bản mới nhất.
+// If-None-Match được ưu tiên hơn If-Modified-Since (RFC 9110 13.2.2)
+func CheckNotModified(ctx *gin.Context, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		ctx.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := ctx.GetHeader("If-None-Match"); inm != "" {
+		return etagMatch(inm, etag)
+	}
+
+	if ims := ctx.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP date chỉ chính xác tới giây
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+func etagMatch(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
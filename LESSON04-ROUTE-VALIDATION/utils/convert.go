@@ -15,3 +15,11 @@ func camelToSnake(str string) string {
 	snake = matchAllCap.ReplaceAllString(str, "${1}_${2}")
 	return strings.ToLower(snake)
 }
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify chỉ giữ lại chữ thường và số, các ký tự khác (kể cả tiếng Việt có dấu) thành "-"
+func Slugify(str string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(str), "-")
+	return strings.Trim(slug, "-")
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,70 +9,132 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/tracing"
 )
 
-var allowedExts = map[string]bool{
-	".jpg":  true,
-	".jpeg": true,
-	".png":  true,
+// UploadPolicy là điều kiện để ValidateAndSaveFile nhận file, có thể đổi lúc đang chạy
+// (config reload) bằng SetUploadPolicy
+type UploadPolicy struct {
+	MaxSize          int64
+	AllowedExts      []string
+	AllowedMimeTypes []string
 }
 
-var allowedMimeTypes = map[string]bool{
-	"image/jpeg": true,
-	"image/png":  true,
+func DefaultUploadPolicy() UploadPolicy {
+	return UploadPolicy{
+		MaxSize:          5 << 20,
+		AllowedExts:      []string{".jpg", ".jpeg", ".png"},
+		AllowedMimeTypes: []string{"image/jpeg", "image/png"},
+	}
 }
 
-const maxSize = 5 << 20
+var uploadPolicy atomic.Pointer[UploadPolicy]
 
-func ValidateAndSaveFile(fileHeader *multipart.FileHeader, uploadDir string) (string, error) {
+func init() {
+	SetUploadPolicy(DefaultUploadPolicy())
+}
+
+func SetUploadPolicy(policy UploadPolicy) {
+	uploadPolicy.Store(&policy)
+}
+
+func CurrentUploadPolicy() UploadPolicy {
+	return *uploadPolicy.Load()
+}
+
+// ValidateAndSaveFile có span "upload" với 2 span con "upload.validate" và "upload.save"
+// để thấy được file bị từ chối ở bước nào và ghi đĩa mất bao lâu
+func ValidateAndSaveFile(ctx context.Context, fileHeader *multipart.FileHeader, uploadDir string) (filename string, err error) {
+	ctx, span := tracing.Start(ctx, "upload",
+		tracing.String("upload.filename", fileHeader.Filename),
+		tracing.Int64("upload.size", fileHeader.Size),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
 
-	// Check extension in filename
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if !allowedExts[ext] {
-		return "", errors.New("Unsupported file extension")
+	if err := validateFile(ctx, CurrentUploadPolicy(), fileHeader, ext); err != nil {
+		return "", err
+	}
+
+	// Change file name
+	filename = fmt.Sprintf("%s%s", uuid.New().String(), ext)
+	span.SetAttributes(tracing.String("upload.saved_as", filename))
+
+	_, saveSpan := tracing.Start(ctx, "upload.save")
+	defer saveSpan.End()
+
+	// Create folder if not exist
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		metrics.UploadRejections.Inc("storage")
+		saveSpan.RecordError(err)
+		return "", errors.New("Cannot create upload folder")
+	}
+
+	// uploadDir "./upload" + filename "abc.jpg"
+	savePath := filepath.Join(uploadDir, filename)
+	if err := saveFile(fileHeader, savePath); err != nil {
+		metrics.UploadRejections.Inc("storage")
+		saveSpan.RecordError(err)
+		return "", err
+	}
+
+	metrics.UploadFiles.Inc()
+	metrics.UploadBytes.Add(float64(fileHeader.Size))
+
+	return filename, nil
+}
+
+func validateFile(ctx context.Context, policy UploadPolicy, fileHeader *multipart.FileHeader, ext string) (err error) {
+	_, span := tracing.Start(ctx, "upload.validate")
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// Check extension in filename
+	if !slices.Contains(policy.AllowedExts, ext) {
+		metrics.UploadRejections.Inc("extension")
+		return errors.New("Unsupported file extension")
 	}
 
 	// Check size
-	if fileHeader.Size > maxSize {
-		return "", errors.New("File is too large (max 5 MB)")
+	if fileHeader.Size > policy.MaxSize {
+		metrics.UploadRejections.Inc("size")
+		return fmt.Errorf("File is too large (max %s)", formatSize(policy.MaxSize))
 	}
 
 	// Check file type
 	file, err := fileHeader.Open()
 	if err != nil {
-		return "", errors.New("Cannot open file")
+		metrics.UploadRejections.Inc("open")
+		return errors.New("Cannot open file")
 	}
 	defer file.Close()
 
 	buffer := make([]byte, 512)
 	_, err = file.Read(buffer)
 	if err != nil {
-		return "", errors.New("Cannot read file")
+		metrics.UploadRejections.Inc("read")
+		return errors.New("Cannot read file")
 	}
 
 	mimeType := http.DetectContentType(buffer)
-	if !allowedMimeTypes[mimeType] {
-		return "", fmt.Errorf("Invalid MIME type : %s", mimeType)
+	span.SetAttributes(tracing.String("upload.mime_type", mimeType))
+	if !slices.Contains(policy.AllowedMimeTypes, mimeType) {
+		metrics.UploadRejections.Inc("mime")
+		return fmt.Errorf("Invalid MIME type : %s", mimeType)
 	}
 
-	// Change file name
-	filename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
-
-	// Create folder if not exist
-	if err := os.MkdirAll("./uploads", os.ModePerm); err != nil {
-		return "", errors.New("Cannot create upload folder")
-	}
-
-	// uploadDir "./upload" + filename "abc.jpg"
-	savePath := filepath.Join(uploadDir, filename)
-	if err := saveFile(fileHeader, savePath); err != nil {
-		return "", err
-	}
-
-	return filename, nil
+	return nil
 }
 
 func saveFile(fileHeader *multipart.FileHeader, destination string) error {
@@ -94,3 +157,15 @@ func saveFile(fileHeader *multipart.FileHeader, destination string) error {
 
 	return err
 }
+
+// formatSize: 5242880 -> "5 MB"
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", size>>10)
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
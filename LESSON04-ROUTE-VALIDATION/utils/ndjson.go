@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MIMENDJSON là newline-delimited JSON: mỗi dòng 1 object, client đọc được từng dòng khi đang tải
+const MIMENDJSON = "application/x-ndjson"
+
+// NDJSONBatchSize là số dòng handler đọc từ repository mỗi lần khi stream NDJSON
+const NDJSONBatchSize = 500
+
+// Số dòng giữa 2 lần flush, client nhận dữ liệu đều đặn thay vì chờ buffer của server đầy
+const ndjsonFlushRows = 100
+
+// AcceptsNDJSON cho biết client chọn NDJSON thay vì định dạng của Render.
+// NDJSON đứng sau mọi offer khác nên Accept rỗng hoặc */* vẫn nhận JSON như cũ.
+func AcceptsNDJSON(ctx *gin.Context) bool {
+	offers := slices.Concat(renderOffers, []string{MIMENDJSON})
+	return NegotiateContentType(ctx.GetHeader("Accept"), offers) == MIMENDJSON
+}
+
+// RenderNDJSON encode từng phần tử của rows ngay khi đọc được từ repository,
+// không dựng cả danh sách trong bộ nhớ. encode chuyển row sang dạng response.
+// Header đã gửi trước dòng đầu tiên nên lỗi giữa chừng chỉ trả về để caller ghi log,
+// client nhận response bị cắt.
+func RenderNDJSON[T any](ctx *gin.Context, rows iter.Seq[T], encode func(T) (any, error)) error {
+	ctx.Header("Vary", "Accept")
+	ctx.Header("Content-Type", MIMENDJSON)
+	ctx.Status(http.StatusOK)
+
+	rc := http.NewResponseController(ctx.Writer)
+	encoder := json.NewEncoder(ctx.Writer)
+	count := 0
+	for row := range rows {
+		value, err := encode(row)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		if count++; count%ndjsonFlushRows == 0 {
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+	}
+	// Không flush phần cuối: handler kết thúc thì response được gửi hết, danh sách ngắn
+	// vẫn là 1 response thường (VD: dưới ngưỡng nén thì không bị nén)
+	return ctx.Request.Context().Err()
+}
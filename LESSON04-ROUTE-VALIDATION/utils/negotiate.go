@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	mimeType string
+	q        float64
+}
+
+// QualityValue là 1 phần tử của header có q-value (Accept, Accept-Encoding...)
+type QualityValue struct {
+	Value string
+	Q     float64
+}
+
+// ParseQualityValues tách header thành các giá trị đã chuyển về chữ thường kèm q-value,
+// thiếu q là 1, q sai là 0. VD: "gzip;q=0.8, zstd" -> [{gzip 0.8} {zstd 1}]
+func ParseQualityValues(header string) []QualityValue {
+	var values []QualityValue
+	for part := range strings.SplitSeq(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, raw, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+
+		values = append(values, QualityValue{Value: value, Q: q})
+	}
+	return values
+}
+
+// parseAccept tách header Accept thành danh sách media range kèm q-value.
+// VD: "application/xml;q=0.9, */*;q=0.1"
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, v := range ParseQualityValues(header) {
+		ranges = append(ranges, acceptRange{mimeType: normalizeSuffix(v.Value), q: v.Q})
+	}
+	return ranges
+}
+
+// normalizeSuffix quy vendor media type có structured suffix về kiểu gốc,
+// VD: application/vnd.mamba.v2+json -> application/json
+func normalizeSuffix(mimeType string) string {
+	mainType, subType, ok := strings.Cut(mimeType, "/")
+	if !ok {
+		return mimeType
+	}
+	if _, suffix, ok := strings.Cut(subType, "+"); ok && suffix != "" {
+		return mainType + "/" + suffix
+	}
+	return mimeType
+}
+
+// qualityFor trả về q của media range cụ thể nhất khớp với mimeType, -1 nếu không khớp
+func qualityFor(ranges []acceptRange, mimeType string) float64 {
+	mainType, _, _ := strings.Cut(mimeType, "/")
+
+	best, bestSpecificity := -1.0, -1
+	for _, r := range ranges {
+		specificity := -1
+		switch {
+		case r.mimeType == mimeType:
+			specificity = 2
+		case r.mimeType == mainType+"/*":
+			specificity = 1
+		case r.mimeType == "*/*":
+			specificity = 0
+		}
+
+		if specificity > bestSpecificity {
+			best, bestSpecificity = r.q, specificity
+		}
+	}
+
+	return best
+}
+
+// NegotiateContentType chọn offer phù hợp nhất với header Accept.
+// Header rỗng thì lấy offer đầu tiên, không có offer nào chấp nhận được thì trả về "".
+// Khi q bằng nhau thì ưu tiên theo thứ tự của offers.
+func NegotiateContentType(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	type candidate struct {
+		offer string
+		q     float64
+		index int
+	}
+
+	var candidates []candidate
+	for i, offer := range offers {
+		if q := qualityFor(ranges, offer); q > 0 {
+			candidates = append(candidates, candidate{offer: offer, q: q, index: i})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	return candidates[0].offer
+}
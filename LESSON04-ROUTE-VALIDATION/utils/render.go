@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/protobuf/types/known/structpb"
+	"mamba.com/route-group/internal/logging"
+	"mamba.com/route-group/internal/metrics"
+	"mamba.com/route-group/internal/tracing"
+)
+
+// Thứ tự cũng là thứ tự ưu tiên khi client chấp nhận nhiều loại với cùng q
+var renderOffers = []string{
+	binding.MIMEJSON,
+	binding.MIMEXML,
+	binding.MIMEXML2,
+	binding.MIMEYAML2,
+	binding.MIMEYAML,
+	binding.MIMEMSGPACK2,
+	binding.MIMEMSGPACK,
+	binding.MIMEPROTOBUF,
+}
+
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// Render thay cho ctx.JSON: chọn định dạng response theo header Accept,
+// trả về 406 nếu không có định dạng nào client chấp nhận.
+func Render(ctx *gin.Context, code int, obj any) {
+	ctx.Header("Vary", "Accept")
+
+	format := NegotiateContentType(ctx.GetHeader("Accept"), renderOffers)
+	// Client stream NDJSON (RenderNDJSON) vẫn đọc được lỗi dạng JSON 1 dòng
+	if format == "" && code >= http.StatusBadRequest && AcceptsNDJSON(ctx) {
+		format = binding.MIMEJSON
+	}
+	if format == "" {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"error":     "Không hỗ trợ định dạng yêu cầu trong header Accept",
+			"supported": renderOffers,
+		})
+		return
+	}
+
+	// 204 không được có body
+	if code == http.StatusNoContent {
+		ctx.Status(code)
+		return
+	}
+
+	if code >= http.StatusBadRequest {
+		obj = withTraceID(ctx, obj)
+	}
+
+	switch format {
+	case binding.MIMEXML, binding.MIMEXML2:
+		ctx.Render(code, xmlRender{data: obj, contentType: format})
+	case binding.MIMEYAML, binding.MIMEYAML2:
+		ctx.YAML(code, obj)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		ctx.Render(code, render.MsgPack{Data: obj})
+	case binding.MIMEPROTOBUF:
+		msg, err := toProtoValue(obj)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot encode protobuf response"})
+			return
+		}
+		ctx.ProtoBuf(code, msg)
+	default:
+		ctx.JSON(code, obj)
+	}
+}
+
+// BindBody bind body theo Content-Type (JSON, XML, YAML, msgpack, form)
+// và validate giống ctx.ShouldBind.
+func BindBody(ctx *gin.Context, obj any) error {
+	contentType := ctx.ContentType()
+	switch contentType {
+	case "", binding.MIMEJSON:
+		return ctx.ShouldBindJSON(obj)
+	case binding.MIMEXML, binding.MIMEXML2, binding.MIMEYAML, binding.MIMEYAML2,
+		binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		// Đọc hết body trước khi decode: decoder msgpack báo EOF sai
+		// khi đọc trực tiếp từ request body theo từng chunk
+		return ctx.ShouldBindBodyWith(obj, binding.Default(ctx.Request.Method, contentType).(binding.BindingBody))
+	case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+		return ctx.ShouldBindWith(obj, binding.Default(ctx.Request.Method, contentType))
+	}
+
+	return ErrUnsupportedMediaType
+}
+
+// RenderBindError trả lỗi của BindBody: 415 nếu sai Content-Type, còn lại 400
+func RenderBindError(ctx *gin.Context, err error) {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		Render(ctx, http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type " + ctx.ContentType() + " không được hỗ trợ",
+		})
+		return
+	}
+
+	RenderValidationError(ctx, err)
+}
+
+// RenderValidationError trả 400 theo định dạng của HandleValidationError, đếm lỗi theo
+// tag và field, ghi log mức debug từng rule bị vi phạm (không ghi giá trị vì có thể là password)
+func RenderValidationError(ctx *gin.Context, err error) {
+	recordValidationError(ctx, err)
+	Render(ctx, http.StatusBadRequest, HandleValidationError(err))
+}
+
+// ItemValidationError giống RenderValidationError nhưng không render, trả về phần "error"
+// để API bulk đặt vào kết quả của từng phần tử
+func ItemValidationError(ctx *gin.Context, err error) any {
+	recordValidationError(ctx, err)
+	return HandleValidationError(err)["error"]
+}
+
+func recordValidationError(ctx *gin.Context, err error) {
+	logger := logging.From(ctx)
+	debug := logger.Enabled(ctx.Request.Context(), slog.LevelDebug)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, e := range validationErrors {
+			metrics.ValidationFailures.Inc(e.Tag(), e.Namespace())
+			if debug {
+				logger.LogAttrs(ctx.Request.Context(), slog.LevelDebug, "validation failed",
+					slog.String("route", ctx.FullPath()),
+					slog.String("field", e.Namespace()),
+					slog.String("tag", e.Tag()),
+					slog.String("param", e.Param()),
+				)
+			}
+		}
+		return
+	}
+
+	// Lỗi parse (JSON sai cú pháp, uri không phải số...) không có tag và field
+	metrics.ValidationFailures.Inc("bind", "")
+	if debug {
+		logger.LogAttrs(ctx.Request.Context(), slog.LevelDebug, "validation failed",
+			slog.String("route", ctx.FullPath()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// withTraceID thêm trace_id vào body lỗi dạng {"error": ...} để client báo lỗi kèm
+// trace id, từ đó tìm được toàn bộ span và log của request
+func withTraceID(ctx *gin.Context, obj any) any {
+	body, ok := obj.(gin.H)
+	if !ok {
+		return obj
+	}
+	if _, ok := body["error"]; !ok {
+		return obj
+	}
+
+	traceID := tracing.TraceIDFrom(ctx)
+	if traceID == "" {
+		return obj
+	}
+
+	result := make(gin.H, len(body)+1)
+	for key, value := range body {
+		result[key] = value
+	}
+	result["trace_id"] = traceID
+	return result
+}
+
+// toProtoValue chuyển obj qua JSON rồi sang google.protobuf.Value
+func toProtoValue(obj any) (*structpb.Value, error) {
+	generic, err := toGeneric(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return structpb.NewValue(generic)
+}
+
+// toGeneric chuẩn hoá obj (struct, gin.H, map[string]string...) về map[string]any / []any
+func toGeneric(obj any) (any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
@@ -37,41 +37,8 @@ func HandleValidationError(err error) gin.H {
 
 			fieldPart := strings.Join(parts, ".")
 
-			switch e.Tag() {
-			case "gt":
-				err[fieldPart] = fmt.Sprintf("%s phải lớn hơn %s", fieldPart, e.Param())
-			case "lt":
-				err[fieldPart] = fmt.Sprintf("%s phải nhỏ hơn %s", fieldPart, e.Param())
-			case "gte":
-				err[fieldPart] = fmt.Sprintf("%s phải lớn hơn hoặc bằng %s", fieldPart, e.Param())
-			case "lte":
-				err[fieldPart] = fmt.Sprintf("%s phải nhỏ hơn hoặc bằng %s", fieldPart, e.Param())
-			case "uuid":
-				err[fieldPart] = fmt.Sprintf("%s phải là UUID hợp lệ", fieldPart)
-			case "slug":
-				err[fieldPart] = fmt.Sprintf("%s chỉ được chứa chữ thường, số, dấu gạch ngang hoặc dấu chấm", fieldPart)
-			case "min":
-				err[fieldPart] = fmt.Sprintf("%s phải nhiều hơn %s kí tự", fieldPart, e.Param())
-			case "max":
-				err[fieldPart] = fmt.Sprintf("%s phải ít hơn %s kí tự", fieldPart, e.Param())
-			case "min_int":
-				err[fieldPart] = fmt.Sprintf("%s phải có giá trị lớn hơn %s", fieldPart, e.Param())
-			case "max_int":
-				err[fieldPart] = fmt.Sprintf("%s phải có giá trị nhỏ hơn %s", fieldPart, e.Param())
-			case "oneof":
-				allowedValues := strings.Join(strings.Split(e.Param(), " "), ", ")
-				err[fieldPart] = fmt.Sprintf("%s phải là một trong các giá trị: %s", fieldPart, allowedValues)
-			case "required":
-				err[fieldPart] = fmt.Sprintf("%s là bắt buộc", fieldPart)
-			case "search":
-				err[fieldPart] = fmt.Sprintf("%s chỉ được chứa chữ thường, in hoa, số và khoảng trắng", fieldPart)
-			case "email":
-				err[fieldPart] = fmt.Sprintf("%s phải đúng định dạng là email", fieldPart)
-			case "datetime":
-				err[fieldPart] = fmt.Sprintf("%s phải đúng định dạng YYYY-MM-DD", fieldPart)
-			case "file_ext":
-				allowedValues := strings.Join(strings.Split(e.Param(), " "), ", ")
-				err[fieldPart] = fmt.Sprintf("%s chỉ cho phép file có extension: %s", fieldPart, allowedValues)
+			if message := ValidationMessage(fieldPart, e.Tag(), e.Param()); message != "" {
+				err[fieldPart] = message
 			}
 		}
 		return gin.H{"error": err}
@@ -79,6 +46,75 @@ func HandleValidationError(err error) gin.H {
 	return gin.H{"error": "Yêu cầu không hợp lệ " + err.Error()}
 }
 
+// ValidationMessage trả về thông báo lỗi cho 1 rule, dùng chung cho validator của gin
+// và các nơi khác muốn trả lỗi cùng định dạng (VD: openapi middleware)
+func ValidationMessage(field, tag, param string) string {
+	switch tag {
+	case "gt":
+		return fmt.Sprintf("%s phải lớn hơn %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s phải nhỏ hơn %s", field, param)
+	case "gte":
+		return fmt.Sprintf("%s phải lớn hơn hoặc bằng %s", field, param)
+	case "lte":
+		return fmt.Sprintf("%s phải nhỏ hơn hoặc bằng %s", field, param)
+	case "uuid":
+		return fmt.Sprintf("%s phải là UUID hợp lệ", field)
+	case "slug":
+		return fmt.Sprintf("%s chỉ được chứa chữ thường, số, dấu gạch ngang hoặc dấu chấm", field)
+	case "min":
+		return fmt.Sprintf("%s phải nhiều hơn %s kí tự", field, param)
+	case "max":
+		return fmt.Sprintf("%s phải ít hơn %s kí tự", field, param)
+	case "min_int":
+		return fmt.Sprintf("%s phải có giá trị lớn hơn %s", field, param)
+	case "max_int":
+		return fmt.Sprintf("%s phải có giá trị nhỏ hơn %s", field, param)
+	case "oneof":
+		allowedValues := strings.Join(strings.Split(param, " "), ", ")
+		return fmt.Sprintf("%s phải là một trong các giá trị: %s", field, allowedValues)
+	case "required":
+		return fmt.Sprintf("%s là bắt buộc", field)
+	case "required_if":
+		return fmt.Sprintf("%s là bắt buộc khi %s", field, strings.Replace(param, " ", " = ", 1))
+	case "required_with":
+		return fmt.Sprintf("%s là bắt buộc khi có %s", field, param)
+	case "excluded_without":
+		return fmt.Sprintf("%s chỉ được đặt khi có %s", field, param)
+	case "hostname_port":
+		return fmt.Sprintf("%s phải có dạng host:port", field)
+	case "ip":
+		return fmt.Sprintf("%s phải là địa chỉ IP", field)
+	case "cidr|ip":
+		return fmt.Sprintf("%s phải là địa chỉ IP hoặc dải CIDR", field)
+	case "url":
+		return fmt.Sprintf("%s phải là URL hợp lệ", field)
+	case "startswith":
+		return fmt.Sprintf("%s phải bắt đầu bằng %s", field, param)
+	case "contains":
+		return fmt.Sprintf("%s phải chứa %s", field, param)
+	case "search":
+		return fmt.Sprintf("%s chỉ được chứa chữ thường, in hoa, số và khoảng trắng", field)
+	case "email":
+		return fmt.Sprintf("%s phải đúng định dạng là email", field)
+	case "datetime":
+		if param == "2006-01-02" {
+			return fmt.Sprintf("%s phải đúng định dạng YYYY-MM-DD", field)
+		}
+		return fmt.Sprintf("%s phải đúng định dạng %s", field, param)
+	case "cron":
+		return fmt.Sprintf("%s phải là biểu thức cron 5 trường, VD: */5 * * * *", field)
+	case "file_ext":
+		allowedValues := strings.Join(strings.Split(param, " "), ", ")
+		return fmt.Sprintf("%s chỉ cho phép file có extension: %s", field, allowedValues)
+	case "pattern":
+		return fmt.Sprintf("%s không đúng định dạng %s", field, param)
+	case "type":
+		return fmt.Sprintf("%s phải có kiểu %s", field, param)
+	}
+	return ""
+}
+
 func RegisterValidators() error {
 	v, ok := binding.Validator.Engine().(*validator.Validate)
 	if !ok {
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var xmlNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]*$`)
+
+// xmlRender encode bất kỳ giá trị nào (kể cả map) thành XML:
+//   - object: mỗi key là 1 element, key không hợp lệ với XML thành <entry key="...">
+//   - array: lặp lại element cùng tên
+type xmlRender struct {
+	data        any
+	contentType string
+}
+
+func (r xmlRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	generic, err := toGeneric(r.data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	if err := encodeXMLValue(enc, xml.StartElement{Name: xml.Name{Local: "response"}}, generic); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+func (r xmlRender) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if len(header["Content-Type"]) == 0 {
+		header["Content-Type"] = []string{r.contentType + "; charset=utf-8"}
+	}
+}
+
+func xmlElement(key string) xml.StartElement {
+	if xmlNameRegex.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
+
+func encodeXMLValue(enc *xml.Encoder, start xml.StartElement, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := encodeXMLValue(enc, xmlElement(key), v[key]); err != nil {
+				return err
+			}
+		}
+
+		return enc.EncodeToken(start.End())
+	case []any:
+		for _, item := range v {
+			if err := encodeXMLValue(enc, start, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		return enc.EncodeElement("", start)
+	default:
+		return enc.EncodeElement(fmt.Sprint(v), start)
+	}
+}
+
+// XMLMap là map[string]any đọc được từ XML, các element con thành key/value dạng string.
+// JSON, YAML và msgpack vẫn dùng như map bình thường.
+type XMLMap map[string]any
+
+func (m *XMLMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	result := XMLMap{}
+	err := DecodeXMLMap(d, func(key string, child xml.StartElement) error {
+		var value string
+		if err := d.DecodeElement(&value, &child); err != nil {
+			return err
+		}
+		result[key] = value
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*m = result
+	return nil
+}
+
+// DecodeXMLMap đọc các element con của map, key là tên element hoặc attribute key
+// (khớp với cách xmlRender ghi key không hợp lệ), decode được gọi cho từng phần tử.
+func DecodeXMLMap(d *xml.Decoder, decode func(key string, child xml.StartElement) error) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			key := t.Name.Local
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "key" {
+					key = attr.Value
+				}
+			}
+
+			if err := decode(key, t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}